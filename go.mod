@@ -38,7 +38,7 @@ require (
 	github.com/tidwall/pretty v1.0.2
 	github.com/x-cray/logrus-prefixed-formatter v0.5.2
 	golang.org/x/crypto v0.0.0-20200820211705-5c72a883971a
-	golang.org/x/net v0.0.0-20200904194848-62affa334b73 // indirect
+	golang.org/x/net v0.0.0-20200904194848-62affa334b73
 	golang.org/x/sys v0.0.0-20200915084602-288bc346aa39
 	gopkg.in/ini.v1 v1.61.0 // indirect
 	gopkg.in/src-d/go-git.v4 v4.13.1
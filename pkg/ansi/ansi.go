@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"regexp"
 	"runtime"
 	"time"
 
@@ -13,6 +14,11 @@ import (
 	"golang.org/x/crypto/ssh/terminal"
 )
 
+// escapeSequences matches CSI sequences such as colors and cursor
+// movements, and OSC sequences such as hyperlinks, which end with either BEL
+// or ST.
+var escapeSequences = regexp.MustCompile("\x1b\\[[0-?]*[ -/]*[@-~]|\x1b\\][^\x07\x1b]*(?:\x07|\x1b\\\\)")
+
 var darkTerminalStyle = &pretty.Style{
 	Key:    [2]string{"\x1B[34m", "\x1B[0m"},
 	String: [2]string{"\x1B[30m", "\x1B[0m"},
@@ -59,6 +65,12 @@ func ColorizeJSON(json string, darkStyle bool, w io.Writer) string {
 		return json
 	}
 
+	return ForceColorizeJSON(json, darkStyle)
+}
+
+// ForceColorizeJSON returns a colorized version of the input JSON, whether or
+// not the output supports colors.
+func ForceColorizeJSON(json string, darkStyle bool) string {
 	style := (*pretty.Style)(nil)
 	if darkStyle {
 		style = darkTerminalStyle
@@ -69,8 +81,12 @@ func ColorizeJSON(json string, darkStyle bool, w io.Writer) string {
 
 // ColorizeStatus returns a colorized number for HTTP status code
 func ColorizeStatus(status int) aurora.Value {
-	color := Color(os.Stdout)
+	return ColorizeStatusWith(Color(os.Stdout), status)
+}
 
+// ColorizeStatusWith returns a colorized number for HTTP status code, using
+// the given aurora instance to decide whether colors are enabled
+func ColorizeStatusWith(color aurora.Aurora, status int) aurora.Value {
 	switch {
 	case status >= 500:
 		return color.Red(status).Bold()
@@ -100,6 +116,12 @@ func Linkify(text, url string, w io.Writer) string {
 		return text
 	}
 
+	return ForceLinkify(text, url)
+}
+
+// ForceLinkify returns an ANSI escape sequence with an hyperlink, whether or
+// not the output supports it.
+func ForceLinkify(text, url string) string {
 	// See https://gist.github.com/egmontkob/eb114294efbcd5adb1944c9f3cb5feda
 	// for more information about this escape sequence.
 	return fmt.Sprintf("\x1b]8;;%s\x1b\\%s\x1b]8;;\x1b\\", url, text)
@@ -120,6 +142,17 @@ func getCharset() charset {
 
 const duration = time.Duration(100) * time.Millisecond
 
+// IsTerminal returns true if the writer is a terminal.
+func IsTerminal(w io.Writer) bool {
+	return isTerminal(w)
+}
+
+// ShouldUseColors returns true if colors should be used when writing to w,
+// taking the color settings and environment into account.
+func ShouldUseColors(w io.Writer) bool {
+	return shouldUseColors(w)
+}
+
 // StartNewSpinner starts a new spinner with the given message. If the writer is not
 // a terminal or doesn't support colors, it simply prints the message.
 func StartNewSpinner(msg string, w io.Writer) *spinner.Spinner {
@@ -169,6 +202,13 @@ func StopSpinner(s *spinner.Spinner, msg string, w io.Writer) {
 	s.Stop()
 }
 
+// Strip removes the colors and other ANSI escape sequences from text,
+// including hyperlinks, e.g. to write text formatted for a terminal to a
+// file.
+func Strip(text string) string {
+	return escapeSequences.ReplaceAllString(text, "")
+}
+
 // StrikeThrough returns struck though text if the writer supports colors
 func StrikeThrough(text string) string {
 	color := Color(os.Stdout)
@@ -181,7 +221,7 @@ func StrikeThrough(text string) string {
 
 func isTerminal(w io.Writer) bool {
 	switch v := w.(type) {
-	case *os.File:
+	case interface{ Fd() uintptr }:
 		return terminal.IsTerminal(int(v.Fd()))
 	default:
 		return false
@@ -0,0 +1,25 @@
+package ansi
+
+import (
+	"testing"
+
+	"github.com/logrusorgru/aurora"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrip(t *testing.T) {
+	color := aurora.NewAurora(true)
+
+	require.Equal(t, "402 POST /v1/charges", Strip(color.Red(402).Bold().String()+" POST /v1/charges"))
+	require.Equal(t, "2024-01-02 15:04:05", Strip(color.Faint("2024-01-02 15:04:05").String()))
+	require.Equal(t, "req_123", Strip(ForceLinkify("req_123", "https://dashboard.stripe.com/test/logs/req_123")))
+
+	// OSC sequences can also end with BEL
+	require.Equal(t, "req_123", Strip("\x1b]8;;https://dashboard.stripe.com\x07req_123\x1b]8;;\x07"))
+
+	// Cursor movements and line clearing
+	require.Equal(t, "\r12 request logs received", Strip("\x1b[1A\r12 request logs received\x1b[K"))
+
+	require.Equal(t, "plain text", Strip("plain text"))
+	require.Equal(t, `{"status":200}`, Strip(ForceColorizeJSON(`{"status":200}`, false)))
+}
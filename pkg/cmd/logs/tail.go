@@ -1,7 +1,11 @@
 package logs
 
 import (
+	"fmt"
+	"io"
+	"os"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -18,13 +22,89 @@ const requestLogsWebSocketFeature = "request_logs"
 
 // TailCmd wraps the configuration for the tail command
 type TailCmd struct {
-	apiBaseURL string
-	cfg        *config.Config
-	Cmd        *cobra.Command
-	format     string
-	livemode   bool
-	LogFilters *logTailing.LogFilters
-	noWSS      bool
+	apiBaseURL       string
+	burstGap         time.Duration
+	cfg              *config.Config
+	check            bool
+	collapse         bool
+	color            string
+	compactErrors    bool
+	controlRecords   bool
+	countOnly        bool
+	dayDividers      bool
+	diagnose         bool
+	Cmd              *cobra.Command
+	emptyURLText     string
+	errorsOnly       bool
+	eventSocket      string
+	excludePaths     []string
+	expandErrors     bool
+	filterNoise      bool
+	forceColorJSON   bool
+	forwardBatchSize int
+	forwardInterval  time.Duration
+	forwardURL       string
+	format           string
+	gapThreshold     time.Duration
+	grpcTarget       string
+	headers          []string
+	heartbeat        time.Duration
+	highlight        bool
+	includeDevice    bool
+	includeEventID   bool
+	includeHostname  bool
+	includeMetadata  bool
+	jsonArray        bool
+	lineColor        bool
+	livemode         bool
+	malformedLimit   float64
+	maskAccount      bool
+	maxAge           time.Duration
+	maxBytes         int64
+	maxErrorLen      int
+	mutatingOnly     bool
+	LogFilters       *logTailing.LogFilters
+	newPathsOnly     bool
+	noBanner         bool
+	noisePaths       []string
+	onErrorCommand   string
+	otlpEndpoint     string
+	noSpinner        bool
+	noWSS            bool
+	omitEmptyURL     bool
+	outFile          string
+	outputQueue      int
+	partitionBy      string
+	flushInterval    time.Duration
+	flushPolicy      string
+	flushSize        int
+	pausable         bool
+	quietUntilError  bool
+	redactPatterns   []string
+	replayFile       string
+	reportFile       string
+	responsive       bool
+	replaySpeed      float64
+	showFilters      bool
+	showMatched      bool
+	showSeq          bool
+	showSessionID    bool
+	showSize         bool
+	showWebSocketURL bool
+	statusGlyph      bool
+	strictJSON       bool
+	strictReplay     bool
+	stripQuery       bool
+	syslog           bool
+	syslogAddr       string
+	syslogNetwork    string
+	syslogTag        string
+	throughput       time.Duration
+	timeZone         string
+	transitions      bool
+	watchBell        bool
+	watchRequestID   string
+	wrapWidth        int
 }
 
 // NewTailCmd creates and initializes the tail command for the logs package
@@ -53,7 +133,268 @@ HTTP methods, IP addresses, paths, response status, and more.`,
 		"",
 		`Specifies the output format of request logs
 Acceptable values:
-	'JSON' - Output logs in JSON format`,
+	'JSON'     - Output logs in JSON format
+	'logfmt'   - Output logs as logfmt key=value pairs
+	'protobuf' - Output logs as length-prefixed protobuf Event messages`,
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.controlRecords,
+		"control-records",
+		false,
+		`Write a {"_control":"reconnect"} record to the JSON output when reconnecting, to detect possible gaps`,
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.dayDividers,
+		"day-dividers",
+		false,
+		"Print a divider with the date whenever the day changes, and only the time on each line (ignored with --format JSON)",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.check,
+		"check",
+		false,
+		"Check that the session is authorized and the websocket reachable, print the results and exit without tailing",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.diagnose,
+		"diagnose",
+		false,
+		"Check the connection to Stripe with a session and websocket handshake, then exit without tailing",
+	)
+
+	// Shadows the global --color flag, whose on and off values are still
+	// accepted for always and never
+	tailCmd.Cmd.Flags().StringVar(
+		&tailCmd.color,
+		"color",
+		"auto",
+		"Color request logs: auto (when the output supports it), always (even when redirected) or never",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.compactErrors,
+		"compact-errors",
+		false,
+		"Print the error fields of a request log on a single line",
+	)
+
+	tailCmd.Cmd.Flags().DurationVar(
+		&tailCmd.burstGap,
+		"burst-gap",
+		0,
+		"Print a --- divider between bursts of request logs received more than this apart (0 disables, ignored with --format JSON)",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.collapse,
+		"collapse",
+		false,
+		"Group consecutive request logs with the same method, path and status into a single line (ignored with --format JSON)",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.countOnly,
+		"count-only",
+		false,
+		"Only display a live count of received request logs",
+	)
+
+	tailCmd.Cmd.Flags().DurationVar(
+		&tailCmd.throughput,
+		"throughput-interval",
+		time.Second,
+		"How often to update the request logs per second gauge with --count-only (0 disables)",
+	)
+
+	tailCmd.Cmd.Flags().StringVar(
+		&tailCmd.timeZone,
+		"time-zone",
+		"",
+		"Time zone to print request log times in, by IANA name such as America/New_York (default: local)",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.transitions,
+		"transitions",
+		false,
+		"Only display a line when the status class of a path changes (e.g. 2xx to 5xx)",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.newPathsOnly,
+		"new-paths-only",
+		false,
+		"Only display a line the first time each endpoint (e.g. GET /v1/customers/:id) is hit",
+	)
+
+	tailCmd.Cmd.Flags().StringVar(
+		&tailCmd.eventSocket,
+		"event-socket",
+		"",
+		"Path of a Unix domain socket to also send request logs to as NDJSON",
+	)
+
+	tailCmd.Cmd.Flags().StringSliceVar(
+		&tailCmd.excludePaths,
+		"exclude-path",
+		[]string{},
+		"Request path to never show request logs for, e.g. /v1/tokens",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.filterNoise,
+		"filter-noise",
+		false,
+		"Hide request logs for health checks and similar noise, e.g. /healthz or /ping",
+	)
+
+	tailCmd.Cmd.Flags().StringArrayVar(
+		&tailCmd.noisePaths,
+		"noise-path",
+		[]string{},
+		"Additional path suffix to hide with --filter-noise",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.errorsOnly,
+		"errors-only",
+		false,
+		"Only show request logs that failed, with an error or a status of 400 and above",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.expandErrors,
+		"expand-errors",
+		false,
+		"Print the full JSON payload of request logs with an error status",
+	)
+
+	tailCmd.Cmd.Flags().IntVar(
+		&tailCmd.wrapWidth,
+		"wrap-width",
+		0,
+		"Wrap the payloads printed by --expand-errors at this column (0 uses the terminal width, -1 disables)",
+	)
+
+	tailCmd.Cmd.Flags().StringVar(
+		&tailCmd.forwardURL,
+		"forward-url",
+		"",
+		"HTTP endpoint to also POST request logs to as JSON arrays",
+	)
+	tailCmd.Cmd.Flags().IntVar(
+		&tailCmd.forwardBatchSize,
+		"forward-batch-size",
+		1,
+		"Number of request logs to send to --forward-url in a single request",
+	)
+	tailCmd.Cmd.Flags().DurationVar(
+		&tailCmd.forwardInterval,
+		"forward-flush-interval",
+		0,
+		"Maximum time to buffer request logs before sending them to --forward-url (e.g. 5s)",
+	)
+
+	tailCmd.Cmd.Flags().StringVar(
+		&tailCmd.grpcTarget,
+		"grpc-target",
+		"",
+		"Address of a gRPC collector (host:port, or an https:// URL) to also stream request logs to",
+	)
+
+	tailCmd.Cmd.Flags().StringVar(
+		&tailCmd.onErrorCommand,
+		"on-error-command",
+		"",
+		"Shell command run for each server error received, even in --count-only mode or while paused, with fields such as $STRIPE_STATUS and $STRIPE_REQUEST_ID in its environment",
+	)
+
+	tailCmd.Cmd.Flags().StringVar(
+		&tailCmd.otlpEndpoint,
+		"otlp-endpoint",
+		"",
+		"OTLP/HTTP logs endpoint (e.g. http://localhost:4318/v1/logs) to also export request logs to as OpenTelemetry logs",
+	)
+
+	tailCmd.Cmd.Flags().DurationVar(
+		&tailCmd.gapThreshold,
+		"gap-threshold",
+		0,
+		"Warn about possibly lost request logs when their creation times jump by more than this (e.g. 1m)",
+	)
+
+	tailCmd.Cmd.Flags().DurationVar(
+		&tailCmd.heartbeat,
+		"heartbeat",
+		0,
+		"Print a line when no request logs have been received for this long (e.g. 1m)",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.highlight,
+		"highlight-latest",
+		false,
+		"Show the most recent request log line in reverse video (terminals only)",
+	)
+
+	tailCmd.Cmd.Flags().StringVar(
+		&tailCmd.watchRequestID,
+		"watch-request-id",
+		"",
+		"Show the request log of this request id in reverse video when it arrives",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.watchBell,
+		"watch-bell",
+		false,
+		"Ring the terminal bell when the request log of --watch-request-id arrives",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.includeDevice,
+		"include-device",
+		false,
+		"Add the device name to the envelope of JSON request logs",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.includeEventID,
+		"include-event-id",
+		false,
+		"Add a stable hash of each request log to the envelope of JSON request logs, to dedupe across CLI instances",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.includeHostname,
+		"include-hostname",
+		false,
+		"Add the host name of this machine to the envelope of JSON request logs",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.includeMetadata,
+		"include-metadata",
+		false,
+		"Wrap JSON request logs in an envelope with the request log ID, websocket message type and error kind",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.jsonArray,
+		"json-array",
+		false,
+		"Write --format JSON as a single JSON array instead of one object per line (requires --replay-file)",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.lineColor,
+		"line-color-by-status",
+		false,
+		"Color the whole line of request logs by status class instead of only the status code",
 	)
 
 	tailCmd.Cmd.Flags().BoolVar(
@@ -63,6 +404,264 @@ Acceptable values:
 		"[WARNING: experimental] Tail live logs (default: test)",
 	)
 
+	tailCmd.Cmd.Flags().DurationVar(
+		&tailCmd.maxAge,
+		"max-age",
+		0,
+		"Skip request logs created longer ago than this, e.g. when replaying an old capture (0 keeps all)",
+	)
+
+	tailCmd.Cmd.Flags().Int64Var(
+		&tailCmd.maxBytes,
+		"max-bytes",
+		0,
+		"Stop once more than this many bytes have been written to the output file and other sinks (0 for no limit)",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.maskAccount,
+		"mask-account",
+		false,
+		"Mask account IDs in the output except for their last 4 characters, e.g. acct_***a1b2",
+	)
+
+	tailCmd.Cmd.Flags().IntVar(
+		&tailCmd.maxErrorLen,
+		"max-error-length",
+		0,
+		"Truncate error messages longer than this many characters (0 disables, ignored with --format JSON)",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.mutatingOnly,
+		"mutating-only",
+		false,
+		"Only show requests that can change data: POST, PUT, PATCH and DELETE",
+	)
+
+	tailCmd.Cmd.Flags().Float64Var(
+		&tailCmd.malformedLimit,
+		"malformed-threshold",
+		0,
+		"Stop with an error when more than this share (0-1) of recent messages are malformed (0 disables)",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.noBanner,
+		"no-banner",
+		false,
+		"Don't print a banner once connected",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.noSpinner,
+		"no-spinner",
+		false,
+		"Don't show the spinner while connecting",
+	)
+
+	tailCmd.Cmd.Flags().StringVar(
+		&tailCmd.outFile,
+		"out-file",
+		"",
+		"File to also append request logs to as NDJSON",
+	)
+	tailCmd.Cmd.Flags().IntVar(
+		&tailCmd.outputQueue,
+		"output-queue-size",
+		0,
+		"Queue up to this many writes to the console so that a slow terminal doesn't hold up processing (0 disables)",
+	)
+	tailCmd.Cmd.Flags().StringVar(
+		&tailCmd.partitionBy,
+		"partition-by",
+		"",
+		`Split --out-file into one file per period of the request logs' creation time
+Acceptable values:
+	'hour' - One file per hour, e.g. traffic-2024-01-02-15.ndjson
+	'day'  - One file per day, e.g. traffic-2024-01-02.ndjson`,
+	)
+	tailCmd.Cmd.Flags().StringVar(
+		&tailCmd.flushPolicy,
+		"flush-policy",
+		"line",
+		`When to write request logs buffered for --out-file to disk
+Acceptable values:
+	'line'     - After every request log
+	'interval' - Every --flush-interval
+	'size'     - Once --flush-size bytes are buffered`,
+	)
+	tailCmd.Cmd.Flags().DurationVar(
+		&tailCmd.flushInterval,
+		"flush-interval",
+		time.Second,
+		"How often to flush --out-file with --flush-policy interval",
+	)
+	tailCmd.Cmd.Flags().IntVar(
+		&tailCmd.flushSize,
+		"flush-size",
+		64*1024,
+		"Number of buffered bytes that flushes --out-file with --flush-policy size",
+	)
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.forceColorJSON,
+		"force-color-json",
+		false,
+		"Colorize --format JSON output even when it isn't a terminal, e.g. for less -R",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.pausable,
+		"pausable",
+		false,
+		"Press space to pause and resume the output, buffering request logs while paused",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.quietUntilError,
+		"quiet-until-error",
+		false,
+		"Only print request logs with a status of 400 and above, in full, and a summary on exit. Combine with --heartbeat to know the tail is alive",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.showFilters,
+		"show-filters",
+		false,
+		"Print a summary of the active filters at startup",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.showMatched,
+		"show-matched-filter",
+		false,
+		"Show which client-side filters let each request log through",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.showSeq,
+		"show-seq",
+		false,
+		"Number the displayed request logs, also adding a seq field to the JSON output",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.showSessionID,
+		"show-session-id",
+		false,
+		"Show the websocket session ID in the connected banner, e.g. for support requests",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.showSize,
+		"show-size",
+		false,
+		"Show the size of each request log's payload, also adding a payload_bytes field to the JSON output",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.stripQuery,
+		"strip-query",
+		false,
+		"Hide query strings from request paths (ignored with --format JSON)",
+	)
+
+	tailCmd.Cmd.Flags().StringVar(
+		&tailCmd.emptyURLText,
+		"empty-url-text",
+		logTailing.DefaultEmptyURLText,
+		"Text shown in place of the path of request logs without a URL (see --omit-empty-url)",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.omitEmptyURL,
+		"omit-empty-url",
+		false,
+		"Omit the path of request logs without a URL instead of showing --empty-url-text",
+	)
+
+	tailCmd.Cmd.Flags().StringSliceVar(
+		&tailCmd.redactPatterns,
+		"redact",
+		[]string{},
+		"Regular expression whose matches are replaced with [REDACTED] in request logs",
+	)
+
+	tailCmd.Cmd.Flags().StringVar(
+		&tailCmd.reportFile,
+		"report-file",
+		"",
+		"Write a JSON summary of the session to this file on exit, or - for stderr",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.responsive,
+		"responsive",
+		false,
+		"Adapt the columns of request logs to the width of the terminal",
+	)
+
+	tailCmd.Cmd.Flags().StringVar(
+		&tailCmd.replayFile,
+		"replay-file",
+		"",
+		"Replay request logs from an NDJSON file (as written by --format JSON) instead of tailing, or - to read from stdin",
+	)
+	tailCmd.Cmd.Flags().Float64Var(
+		&tailCmd.replaySpeed,
+		"replay-speed",
+		0,
+		"Replay with the original timing between request logs scaled by this factor (0 replays as fast as possible)",
+	)
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.statusGlyph,
+		"status-glyph",
+		false,
+		"Show a colored ● instead of the status code, keeping the code for errors only",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.strictJSON,
+		"strict-json",
+		false,
+		"Guarantee that only valid JSON objects are written to stdout, sending everything else to stderr (requires --format JSON)",
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.syslog,
+		"syslog",
+		false,
+		"Also write request logs to the syslog, with errors at the err priority",
+	)
+
+	tailCmd.Cmd.Flags().StringVar(
+		&tailCmd.syslogAddr,
+		"syslog-addr",
+		"",
+		"Address of a remote syslog to write to with --syslog (defaults to the local syslog)",
+	)
+
+	tailCmd.Cmd.Flags().StringVar(
+		&tailCmd.syslogNetwork,
+		"syslog-network",
+		"",
+		"Network of the remote syslog given by --syslog-addr, e.g. udp or tcp",
+	)
+
+	tailCmd.Cmd.Flags().StringVar(
+		&tailCmd.syslogTag,
+		"syslog-tag",
+		"",
+		`Tag of the syslog messages written with --syslog (default "stripe-cli")`,
+	)
+
+	tailCmd.Cmd.Flags().BoolVar(
+		&tailCmd.strictReplay,
+		"strict-replay",
+		false,
+		"Stop the replay with an error on the first malformed line instead of skipping it",
+	)
+
 	// Log filters
 	tailCmd.Cmd.Flags().StringSliceVar(
 		&tailCmd.LogFilters.FilterAccount,
@@ -86,6 +685,9 @@ Acceptable values:
 	'DELETE' - HTTP delete requests`,
 	)
 	tailCmd.Cmd.Flags().StringSliceVar(&tailCmd.LogFilters.FilterRequestPath, "filter-request-path", []string{}, "Filter request logs by request path")
+	tailCmd.Cmd.Flags().StringSliceVar(&tailCmd.LogFilters.FilterRequestID, "filter-request-id", []string{}, "Filter request logs by request id")
+	tailCmd.Cmd.Flags().StringSliceVar(&tailCmd.LogFilters.FilterErrorCode, "filter-error-code", []string{}, "Filter request logs by error code, e.g. card_declined")
+	tailCmd.Cmd.Flags().StringSliceVar(&tailCmd.LogFilters.FilterDeclineCode, "filter-decline-code", []string{}, "Filter request logs by decline code, e.g. insufficient_funds")
 	tailCmd.Cmd.Flags().StringSliceVar(
 		&tailCmd.LogFilters.FilterRequestStatus,
 		"filter-request-status",
@@ -105,6 +707,27 @@ Acceptable values:
 	'DASHBOARD' - Requests that came through the Stripe Dashboard`,
 	)
 	tailCmd.Cmd.Flags().StringSliceVar(&tailCmd.LogFilters.FilterStatusCode, "filter-status-code", []string{}, "Filter request logs by status code")
+	tailCmd.Cmd.Flags().StringSliceVar(
+		&tailCmd.LogFilters.FilterStatusCategory,
+		"filter-status-category",
+		[]string{},
+		`Filter request logs by status category
+Acceptable values:
+	'informational' - 1XX status codes
+	'success'       - 2XX status codes
+	'redirect'      - 3XX status codes
+	'client-error'  - 4XX status codes
+	'server-error'  - 5XX status codes
+	'error'         - 4XX and 5XX status codes`,
+	)
+	tailCmd.Cmd.Flags().StringSliceVar(
+		&tailCmd.LogFilters.FilterStatusText,
+		"filter-status-text",
+		[]string{},
+		"Filter request logs by HTTP status text, e.g. not-found or too-many-requests",
+	)
+	tailCmd.Cmd.Flags().IntVar(&tailCmd.LogFilters.MinStatus, "min-status", 0, "Only show request logs with a status code of at least this value")
+	tailCmd.Cmd.Flags().IntVar(&tailCmd.LogFilters.MaxStatus, "max-status", 0, "Only show request logs with a status code of at most this value")
 	tailCmd.Cmd.Flags().StringSliceVar(
 		&tailCmd.LogFilters.FilterStatusCodeType,
 		"filter-status-code-type",
@@ -116,6 +739,13 @@ Acceptable values:
 	'5XX' - All 5XX status codes`,
 	)
 
+	tailCmd.Cmd.Flags().StringArrayVar(
+		&tailCmd.headers,
+		"websocket-header",
+		[]string{},
+		"Additional header to send when connecting to Stripe, as \"Name: value\" (e.g. for a proxy in front of Stripe)",
+	)
+
 	// Hidden configuration flags, useful for dev/debugging
 	tailCmd.Cmd.Flags().StringVar(&tailCmd.apiBaseURL, "api-base", "", "Sets the API base URL")
 	tailCmd.Cmd.Flags().MarkHidden("api-base") // #nosec G104
@@ -123,6 +753,9 @@ Acceptable values:
 	tailCmd.Cmd.Flags().BoolVar(&tailCmd.noWSS, "no-wss", false, "Force unencrypted ws:// protocol instead of wss://")
 	tailCmd.Cmd.Flags().MarkHidden("no-wss") // #nosec G104
 
+	tailCmd.Cmd.Flags().BoolVar(&tailCmd.showWebSocketURL, "show-websocket-url", false, "Print the resolved websocket URL before connecting")
+	tailCmd.Cmd.Flags().MarkHidden("show-websocket-url") // #nosec G104
+
 	return tailCmd
 }
 
@@ -137,6 +770,11 @@ func (tailCmd *TailCmd) runTailCmd(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	headers, err := parseHeaders(tailCmd.headers)
+	if err != nil {
+		return err
+	}
+
 	deviceName, err := tailCmd.cfg.Profile.GetDeviceName()
 	if err != nil {
 		return err
@@ -149,16 +787,114 @@ func (tailCmd *TailCmd) runTailCmd(cmd *cobra.Command, args []string) error {
 
 	version.CheckLatestVersion()
 
-	tailer := logTailing.New(&logTailing.Config{
-		APIBaseURL:       tailCmd.apiBaseURL,
-		DeviceName:       deviceName,
-		Filters:          tailCmd.LogFilters,
-		Key:              key,
-		Log:              log.StandardLogger(),
-		NoWSS:            tailCmd.noWSS,
-		OutputFormat:     strings.ToUpper(tailCmd.format),
-		WebSocketFeature: requestLogsWebSocketFeature,
-	})
+	var input io.Reader
+
+	replayFile := tailCmd.replayFile
+	if replayFile == "-" {
+		input = os.Stdin
+		replayFile = ""
+	}
+
+	tailCfg := &logTailing.Config{
+		APIBaseURL:           tailCmd.apiBaseURL,
+		BurstGap:             tailCmd.burstGap,
+		Collapse:             tailCmd.collapse,
+		ColorMode:            tailCmd.color,
+		CompactErrors:        tailCmd.compactErrors,
+		ControlRecords:       tailCmd.controlRecords,
+		CountOnly:            tailCmd.countOnly,
+		DayDividers:          tailCmd.dayDividers,
+		DeviceName:           deviceName,
+		EmptyURLText:         tailCmd.emptyURLText,
+		ErrorsOnly:           tailCmd.errorsOnly,
+		EventSocket:          tailCmd.eventSocket,
+		ExcludeExactPaths:    append(append([]string{}, logTailing.DefaultExcludeExactPaths...), tailCmd.excludePaths...),
+		ExpandErrors:         tailCmd.expandErrors,
+		FilterNoise:          tailCmd.filterNoise,
+		Filters:              tailCmd.LogFilters,
+		FlushInterval:        tailCmd.flushInterval,
+		FlushPolicy:          tailCmd.flushPolicy,
+		FlushSize:            tailCmd.flushSize,
+		ForceColorJSON:       tailCmd.forceColorJSON,
+		ForwardURL:           tailCmd.forwardURL,
+		ForwardBatchSize:     tailCmd.forwardBatchSize,
+		ForwardFlushInterval: tailCmd.forwardInterval,
+		GapThreshold:         tailCmd.gapThreshold,
+		GRPCTarget:           tailCmd.grpcTarget,
+		Headers:              headers,
+		Heartbeat:            tailCmd.heartbeat,
+		HighlightLatest:      tailCmd.highlight,
+		IncludeDevice:        tailCmd.includeDevice,
+		IncludeEventID:       tailCmd.includeEventID,
+		IncludeHostname:      tailCmd.includeHostname,
+		IncludeMetadata:      tailCmd.includeMetadata,
+		Input:                input,
+		JSONArray:            tailCmd.jsonArray,
+		Key:                  key,
+		LineColorByStatus:    tailCmd.lineColor,
+		Log:                  log.StandardLogger(),
+		MalformedThreshold:   tailCmd.malformedLimit,
+		MaskAccount:          tailCmd.maskAccount,
+		MaxAge:               tailCmd.maxAge,
+		MaxBytes:             tailCmd.maxBytes,
+		MaxErrorMessageLen:   tailCmd.maxErrorLen,
+		MutatingOnly:         tailCmd.mutatingOnly,
+		NewPathsOnly:         tailCmd.newPathsOnly,
+		NoBanner:             tailCmd.noBanner,
+		NoSpinner:            tailCmd.noSpinner,
+		NoWSS:                tailCmd.noWSS,
+		NoisePaths:           tailCmd.noisePaths,
+		OmitEmptyURL:         tailCmd.omitEmptyURL,
+		OnErrorCommand:       tailCmd.onErrorCommand,
+		OTLPEndpoint:         tailCmd.otlpEndpoint,
+		OutFile:              tailCmd.outFile,
+		OutputFormat:         strings.ToUpper(tailCmd.format),
+		OutputQueueSize:      tailCmd.outputQueue,
+		PartitionBy:          tailCmd.partitionBy,
+		Pausable:             tailCmd.pausable,
+		QuietUntilError:      tailCmd.quietUntilError,
+		RedactPatterns:       tailCmd.redactPatterns,
+		ReplayFile:           replayFile,
+		ReplaySpeed:          tailCmd.replaySpeed,
+		ReportFile:           tailCmd.reportFile,
+		Responsive:           tailCmd.responsive,
+		ShowFilters:          tailCmd.showFilters,
+		ShowMatchedFilter:    tailCmd.showMatched,
+		ShowSeq:              tailCmd.showSeq,
+		ShowSessionID:        tailCmd.showSessionID,
+		ShowSize:             tailCmd.showSize,
+		ShowWebSocketURL:     tailCmd.showWebSocketURL,
+		StatusGlyph:          tailCmd.statusGlyph,
+		StrictJSON:           tailCmd.strictJSON,
+		StrictReplay:         tailCmd.strictReplay,
+		StripQuery:           tailCmd.stripQuery,
+		Syslog:               tailCmd.syslog,
+		SyslogAddr:           tailCmd.syslogAddr,
+		SyslogNetwork:        tailCmd.syslogNetwork,
+		SyslogTag:            tailCmd.syslogTag,
+		ThroughputInterval:   tailCmd.throughput,
+		TimeZone:             tailCmd.timeZone,
+		Transitions:          tailCmd.transitions,
+		WatchBell:            tailCmd.watchBell,
+		WatchRequestID:       tailCmd.watchRequestID,
+		WrapWidth:            tailCmd.wrapWidth,
+		WebSocketFeature:     requestLogsWebSocketFeature,
+	}
+
+	if tailCmd.diagnose {
+		return logTailing.Diagnose(context.Background(), tailCfg)
+	}
+
+	if tailCmd.check {
+		result, err := logTailing.New(tailCfg).Preflight(context.Background())
+		if result != nil {
+			printPreflight(os.Stdout, result)
+		}
+
+		return err
+	}
+
+	tailer := logTailing.New(tailCfg)
 
 	err = tailer.Run(context.Background())
 	if err != nil {
@@ -169,6 +905,16 @@ func (tailCmd *TailCmd) runTailCmd(cmd *cobra.Command, args []string) error {
 }
 
 func (tailCmd *TailCmd) validateArgs() error {
+	switch tailCmd.color {
+	case "auto", "always", "never":
+	case config.ColorOn:
+		tailCmd.color = "always"
+	case config.ColorOff:
+		tailCmd.color = "never"
+	default:
+		return fmt.Errorf("invalid --color %q. Expected auto, always or never", tailCmd.color)
+	}
+
 	err := validators.CallNonEmptyArray(validators.Account, tailCmd.LogFilters.FilterAccount)
 	if err != nil {
 		return err
@@ -202,6 +948,49 @@ func (tailCmd *TailCmd) validateArgs() error {
 	return nil
 }
 
+// printPreflight prints the outcome of each check run by Preflight.
+func printPreflight(w io.Writer, result *logTailing.PreflightResult) {
+	status := func(ok bool) string {
+		if ok {
+			return "ok"
+		}
+
+		return "FAIL"
+	}
+
+	fmt.Fprintf(w, "%-20s%s\n", "Feature:", result.Feature)
+	fmt.Fprintf(w, "%-20s%s\n", "Session authorized:", status(result.FeatureAuthorized))
+
+	if result.WebSocketURL != "" {
+		fmt.Fprintf(w, "%-20s%s\n", "WebSocket URL:", result.WebSocketURL)
+	}
+
+	fmt.Fprintf(w, "%-20s%s\n", "Host reachable:", status(result.DialOK))
+
+	handshake := status(result.HandshakeOK)
+	if result.HandshakeOK {
+		handshake += fmt.Sprintf(" (%v)", result.Latency.Round(time.Millisecond))
+	}
+
+	fmt.Fprintf(w, "%-20s%s\n", "Handshake:", handshake)
+}
+
+// parseHeaders parses headers given as "Name: value".
+func parseHeaders(headers []string) (map[string]string, error) {
+	parsed := make(map[string]string, len(headers))
+
+	for _, header := range headers {
+		parts := strings.SplitN(header, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid header %q. Expected \"Name: value\"", header)
+		}
+
+		parsed[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+
+	return parsed, nil
+}
+
 func (tailCmd *TailCmd) convertArgs() error {
 	// The backend expects to receive the status code type as a string representing the start of the range (e.g., '200')
 	if len(tailCmd.LogFilters.FilterStatusCodeType) > 0 {
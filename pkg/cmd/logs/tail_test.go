@@ -0,0 +1,61 @@
+package logs
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/config"
+	logTailing "github.com/stripe/stripe-cli/pkg/logtailing"
+	"github.com/stripe/stripe-cli/pkg/websocket"
+)
+
+func TestFilterStatusCodeTypeConverted(t *testing.T) {
+	tailCmd := NewTailCmd(&config.Config{})
+	require.NoError(t, tailCmd.Cmd.Flags().Set("filter-status-code-type", "4XX"))
+	require.NoError(t, tailCmd.convertArgs())
+
+	// Stripe expects the start of the range
+	require.Equal(t, []string{"400"}, tailCmd.LogFilters.FilterStatusCodeType)
+
+	var buf bytes.Buffer
+
+	tailer := logTailing.New(&logTailing.Config{Filters: tailCmd.LogFilters, NoColor: true, Out: &buf})
+
+	for i, status := range []int{200, 404, 500, 402} {
+		tailer.ProcessMessage(websocket.IncomingMessage{
+			RequestLogEvent: &websocket.RequestLogEvent{
+				EventPayload: fmt.Sprintf(`{"method":"GET","status":%d,"url":"/v1/charges","request_id":"req_%d"}`, status, i),
+				RequestLogID: "resp_123",
+				Type:         "request_log_event",
+			},
+		})
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], "[404]")
+	require.Contains(t, lines[1], "[402]")
+}
+
+func TestColorFlag(t *testing.T) {
+	for _, color := range []string{"auto", "always", "never"} {
+		tailCmd := NewTailCmd(&config.Config{})
+		require.NoError(t, tailCmd.Cmd.Flags().Set("color", color))
+		require.NoError(t, tailCmd.validateArgs())
+	}
+
+	tailCmd := NewTailCmd(&config.Config{})
+	require.Equal(t, "auto", tailCmd.color)
+
+	// The values of the global --color flag it shadows
+	require.NoError(t, tailCmd.Cmd.Flags().Set("color", "off"))
+	require.NoError(t, tailCmd.validateArgs())
+	require.Equal(t, "never", tailCmd.color)
+
+	require.NoError(t, tailCmd.Cmd.Flags().Set("color", "sometimes"))
+	require.EqualError(t, tailCmd.validateArgs(), `invalid --color "sometimes". Expected auto, always or never`)
+}
@@ -0,0 +1,119 @@
+package logtailing
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMalformedWindow = 10 * time.Second
+
+	// minMalformedSamples is the number of messages that must have been
+	// received within the window before the breaker can trip, so that a
+	// single bad message at startup doesn't end the session.
+	minMalformedSamples = 10
+)
+
+// malformedBreaker trips when the share of malformed messages received
+// within a sliding window exceeds a threshold.
+type malformedBreaker struct {
+	threshold float64
+	window    time.Duration
+
+	mu      sync.Mutex
+	samples []malformedSample
+	tripped chan error
+}
+
+type malformedSample struct {
+	at        time.Time
+	malformed bool
+}
+
+func newMalformedBreaker(threshold float64, window time.Duration) *malformedBreaker {
+	if window <= 0 {
+		window = defaultMalformedWindow
+	}
+
+	return &malformedBreaker{
+		threshold: threshold,
+		window:    window,
+		tripped:   make(chan error, 1),
+	}
+}
+
+// record adds a received message to the window and trips the breaker if the
+// malformed rate exceeds the threshold.
+func (b *malformedBreaker) record(now time.Time, malformed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.samples = append(b.samples, malformedSample{at: now, malformed: malformed})
+
+	cutoff := now.Add(-b.window)
+
+	i := 0
+	for i < len(b.samples) && b.samples[i].at.Before(cutoff) {
+		i++
+	}
+
+	b.samples = b.samples[i:]
+
+	if len(b.samples) < minMalformedSamples {
+		return
+	}
+
+	nMalformed := 0
+
+	for _, s := range b.samples {
+		if s.malformed {
+			nMalformed++
+		}
+	}
+
+	if float64(nMalformed)/float64(len(b.samples)) <= b.threshold {
+		return
+	}
+
+	err := fmt.Errorf("%d of the last %d messages received within %s were malformed. "+
+		"This usually means that this version of the Stripe CLI doesn't support the websocket feature; "+
+		"try upgrading the Stripe CLI", nMalformed, len(b.samples), b.window)
+
+	select {
+	case b.tripped <- err:
+	default:
+	}
+}
+
+// recordMessage counts a received message and records it with the breaker,
+// if enabled. The caller must hold t.mu.
+func (t *Tailer) recordMessage(malformed bool) {
+	if malformed {
+		t.malformed++
+	}
+
+	if t.breaker != nil {
+		t.breaker.record(t.cfg.Now(), malformed)
+	}
+}
+
+// processMalformedFrame counts a websocket frame that couldn't be decoded
+// as a malformed message.
+func (t *Tailer) processMalformedFrame(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.onError(fmt.Errorf("received malformed message: %v", err))
+	t.recordMessage(true)
+}
+
+// breakerTripped returns the channel the breaker's error is sent on, or nil
+// if the breaker is disabled.
+func (t *Tailer) breakerTripped() <-chan error {
+	if t.breaker == nil {
+		return nil
+	}
+
+	return t.breaker.tripped
+}
@@ -0,0 +1,120 @@
+package logtailing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/websocket"
+)
+
+func TestMalformedBreakerTrips(t *testing.T) {
+	tailer := New(&Config{MalformedThreshold: 0.5})
+
+	for i := 0; i < minMalformedSamples; i++ {
+		tailer.processRequestLogEvent(requestLogMessage(`not json`))
+	}
+
+	select {
+	case err := <-tailer.breakerTripped():
+		require.Contains(t, err.Error(), "10 of the last 10 messages received within 10s were malformed")
+	default:
+		t.Fatal("expected the breaker to trip")
+	}
+}
+
+func TestMalformedBreakerBelowThreshold(t *testing.T) {
+	b := newMalformedBreaker(0.5, time.Second)
+	now := time.Now()
+
+	for i := 0; i < 20; i++ {
+		b.record(now, i%3 == 0)
+	}
+
+	require.Len(t, b.tripped, 0)
+}
+
+func TestMalformedBreakerWindow(t *testing.T) {
+	b := newMalformedBreaker(0.5, time.Second)
+	now := time.Now()
+
+	// Old malformed messages fall out of the window
+	for i := 0; i < minMalformedSamples-1; i++ {
+		b.record(now, true)
+	}
+
+	for i := 0; i < minMalformedSamples; i++ {
+		b.record(now.Add(2*time.Second), false)
+	}
+
+	require.Len(t, b.tripped, 0)
+	require.Len(t, b.samples, minMalformedSamples)
+}
+
+func TestMalformedBreakerDisabled(t *testing.T) {
+	tailer := New(&Config{})
+
+	for i := 0; i < 2*minMalformedSamples; i++ {
+		tailer.processRequestLogEvent(requestLogMessage(`not json`))
+	}
+
+	require.Nil(t, tailer.breakerTripped())
+}
+
+func TestRunStopsWhenBreakerTrips(t *testing.T) {
+	lines := make([]string, 0, 2*minMalformedSamples)
+	for i := 0; i < 2*minMalformedSamples; i++ {
+		lines = append(lines, `<html>`)
+	}
+
+	tailer := New(&Config{
+		MalformedThreshold: 0.9,
+		ReplayFile:         writeReplayFile(t, lines...),
+	})
+
+	err := tailer.Run(context.Background())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "try upgrading the Stripe CLI")
+
+	// Malformed replay lines are skipped rather than counted as request logs
+	require.Equal(t, 0, tailer.count)
+	require.Equal(t, minMalformedSamples, tailer.malformed)
+}
+
+func TestMalformedBreakerIgnoresOtherEvents(t *testing.T) {
+	tailer := New(&Config{MalformedThreshold: 0.5})
+
+	for i := 0; i < 2*minMalformedSamples; i++ {
+		tailer.processMessage(websocket.IncomingMessage{WebhookEvent: &websocket.WebhookEvent{Type: "webhook_event"}}, "webhooks")
+	}
+
+	select {
+	case err := <-tailer.breakerTripped():
+		t.Fatalf("unexpected trip: %v", err)
+	default:
+	}
+
+	require.Zero(t, tailer.report().Malformed)
+}
+
+func TestMalformedBreakerTripsOnMalformedFrames(t *testing.T) {
+	var errs []error
+
+	tailer := New(&Config{MalformedThreshold: 0.5, OnError: func(err error) { errs = append(errs, err) }})
+
+	for i := 0; i < minMalformedSamples; i++ {
+		tailer.processMalformedFrame(websocket.ErrTruncatedMessage)
+	}
+
+	select {
+	case err := <-tailer.breakerTripped():
+		require.Contains(t, err.Error(), "10 of the last 10 messages received within 10s were malformed")
+	default:
+		t.Fatal("expected the breaker to trip")
+	}
+
+	require.Equal(t, minMalformedSamples, tailer.report().Malformed)
+	require.EqualError(t, errs[0], "received malformed message: truncated message")
+}
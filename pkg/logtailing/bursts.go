@@ -0,0 +1,38 @@
+package logtailing
+
+import "fmt"
+
+// newBurst records that an event is being displayed, and reports whether
+// it's received more than BurstGap after the previous one. The caller must
+// hold t.mu.
+func (t *Tailer) newBurst() bool {
+	if t.cfg.BurstGap <= 0 {
+		return false
+	}
+
+	now := t.cfg.Now()
+	burst := !t.lastDisplayed.IsZero() && now.Sub(t.lastDisplayed) > t.cfg.BurstGap
+	t.lastDisplayed = now
+
+	return burst
+}
+
+// printBurstDivider prints a divider to the i-th output between two bursts
+// of request logs. Pending collapsed request logs are printed first so that
+// they stay with their burst. The caller must hold t.mu.
+func (t *Tailer) printBurstDivider(i int) {
+	output := t.outputs[i]
+	if machineReadable(output.Format) {
+		return
+	}
+
+	if t.collapses(output) {
+		t.flushGroup(i)
+	}
+
+	if t.highlightEnabled(output) {
+		t.clearHighlight()
+	}
+
+	fmt.Fprintln(output.Out, t.color(output.Out).Faint("---"))
+}
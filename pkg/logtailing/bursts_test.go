@@ -0,0 +1,61 @@
+package logtailing
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBurstGap(t *testing.T) {
+	clock := newFakeClock()
+
+	var text, jsonBuf bytes.Buffer
+
+	tailer := New(&Config{
+		BurstGap: 5 * time.Second,
+		NoColor:  true,
+		Now:      clock.Now,
+		Out:      &text,
+		Outputs:  []Output{{Out: &jsonBuf, Format: "JSON"}},
+	})
+
+	process := func(requestID string) {
+		tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"` + requestID + `"}`))
+	}
+
+	process("req_1")
+	clock.Advance(time.Second)
+	process("req_2")
+
+	// Idle for longer than the gap
+	clock.Advance(10 * time.Second)
+	process("req_3")
+	clock.Advance(5 * time.Second)
+	process("req_4")
+
+	lines := strings.Split(strings.TrimSpace(text.String()), "\n")
+	require.Len(t, lines, 5)
+	require.Contains(t, lines[1], "[req_2]")
+	require.Equal(t, "---", lines[2])
+	require.Contains(t, lines[3], "[req_3]")
+	require.Equal(t, 1, strings.Count(text.String(), "---"))
+
+	require.NotContains(t, jsonBuf.String(), "---")
+}
+
+func TestBurstGapDisabled(t *testing.T) {
+	clock := newFakeClock()
+
+	var buf bytes.Buffer
+
+	tailer := New(&Config{NoColor: true, Now: clock.Now, Out: &buf})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_1"}`))
+	clock.Advance(time.Hour)
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_2"}`))
+
+	require.NotContains(t, buf.String(), "---")
+}
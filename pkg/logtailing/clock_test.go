@@ -0,0 +1,115 @@
+package logtailing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a clock that only moves when advanced.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 2, 15, 4, 5, 0, time.Local)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+func TestClockDefaultsToTimeNow(t *testing.T) {
+	tailer := New(&Config{})
+	require.NotNil(t, tailer.cfg.Now)
+	require.WithinDuration(t, time.Now(), tailer.cfg.Now(), time.Second)
+}
+
+func TestHeartbeatWithFakeClock(t *testing.T) {
+	var buf bytes.Buffer
+
+	clock := newFakeClock()
+	tailer := New(&Config{Heartbeat: time.Minute, NoColor: true, Now: clock.Now, Out: &buf})
+
+	tailer.lastActivity = clock.Now()
+	tailer.printHeartbeat()
+
+	require.Equal(t, "...still tailing (no events) [15:04:05]\n", buf.String())
+
+	clock.Advance(30 * time.Second)
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/charges"}`))
+
+	require.Equal(t, clock.Now(), tailer.lastActivity)
+}
+
+func TestHeartbeatTimeoutWithFakeClock(t *testing.T) {
+	var buf syncBuffer
+
+	clock := newFakeClock()
+	tailer := New(&Config{Heartbeat: 10 * time.Millisecond, NoColor: true, Now: clock.Now, Out: &buf})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go tailer.runHeartbeat(ctx)
+
+	// The heartbeat doesn't fire while the clock stands still
+	time.Sleep(50 * time.Millisecond)
+	require.Empty(t, buf.String())
+
+	clock.Advance(time.Second)
+
+	require.Eventually(t, func() bool {
+		return buf.String() == "...still tailing (no events) [15:04:06]\n"
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestReportDurationWithFakeClock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "report")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	reportFile := filepath.Join(dir, "report.json")
+
+	clock := newFakeClock()
+
+	tailer := New(&Config{
+		Middleware: []func(EventPayload) (EventPayload, bool){
+			func(payload EventPayload) (EventPayload, bool) {
+				clock.Advance(1500 * time.Millisecond)
+				return payload, true
+			},
+		},
+		Now:        clock.Now,
+		Out:        ioutil.Discard,
+		ReplayFile: writeReplayFile(t, `{"status":200}`, `{"status":200}`),
+		ReportFile: reportFile,
+	})
+	require.NoError(t, tailer.Run(context.Background()))
+
+	data, err := ioutil.ReadFile(reportFile)
+	require.NoError(t, err)
+
+	var report Report
+	require.NoError(t, json.Unmarshal(data, &report))
+	require.Equal(t, 3.0, report.DurationSeconds)
+}
@@ -0,0 +1,88 @@
+package logtailing
+
+import (
+	"context"
+	"time"
+)
+
+const defaultCollapseInterval = 1 * time.Second
+
+// collapsedGroup is a run of consecutive request logs with the same method,
+// URL and status that is printed as a single line.
+type collapsedGroup struct {
+	evt   *event
+	count int
+}
+
+func (g *collapsedGroup) matches(evt *event) bool {
+	return g.evt.payload.Method == evt.payload.Method &&
+		g.evt.payload.URL == evt.payload.URL &&
+		g.evt.payload.Status == evt.payload.Status
+}
+
+// collapseEnabled reports whether any output collapses request logs.
+func (t *Tailer) collapseEnabled() bool {
+	return t.collapsed != nil && !t.cfg.CountOnly
+}
+
+// collapses reports whether request logs written to output are collapsed.
+// Only the default format is collapsed so that machine-readable output keeps
+// every event.
+func (t *Tailer) collapses(output Output) bool {
+	return t.collapseEnabled() && !machineReadable(output.Format)
+}
+
+// collapseEvent adds evt to the pending group of the i-th output, printing
+// the group first if evt doesn't belong to it. The caller must hold t.mu.
+func (t *Tailer) collapseEvent(i int, evt *event) {
+	group := t.collapsed[i]
+
+	if group != nil && group.matches(evt) {
+		// Show the details of the most recent request of the group
+		group.evt = evt
+		group.count++
+
+		return
+	}
+
+	t.flushGroup(i)
+	t.collapsed[i] = &collapsedGroup{evt: evt, count: 1}
+}
+
+// flushGroup prints the pending group of the i-th output, if any. The caller
+// must hold t.mu.
+func (t *Tailer) flushGroup(i int) {
+	group := t.collapsed[i]
+	if group == nil {
+		return
+	}
+
+	t.writeLine(t.outputs[i], group.evt, group.count)
+	t.collapsed[i] = nil
+}
+
+// flushCollapsed prints the pending groups of all outputs. The caller must
+// hold t.mu.
+func (t *Tailer) flushCollapsed() {
+	for i := range t.collapsed {
+		t.flushGroup(i)
+	}
+}
+
+// runCollapseFlush periodically prints the pending groups so that a quiet
+// period doesn't hold back the last request logs, until ctx is canceled.
+func (t *Tailer) runCollapseFlush(ctx context.Context) {
+	ticker := time.NewTicker(t.cfg.CollapseInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.mu.Lock()
+			t.flushCollapsed()
+			t.mu.Unlock()
+		}
+	}
+}
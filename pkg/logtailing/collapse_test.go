@@ -0,0 +1,89 @@
+package logtailing
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollapse(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{Collapse: true, NoColor: true, Out: &buf})
+
+	for i := 0; i < 3; i++ {
+		tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","request_id":"req_1","status":429,"url":"/v1/charges"}`))
+	}
+
+	// The group is held until a different event arrives
+	require.Empty(t, buf.String())
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","request_id":"req_2","status":200,"url":"/v1/charges"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","request_id":"req_3","status":200,"url":"/v1/charges"}`))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 1)
+	require.True(t, strings.HasSuffix(lines[0], "[429] GET /v1/charges [req_1] (x3)"))
+
+	tailer.finish()
+
+	lines = strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	require.True(t, strings.HasSuffix(lines[1], "[200] GET /v1/charges [req_3] (x2)"))
+}
+
+func TestCollapseSingleEvent(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{Collapse: true, NoColor: true, Out: &buf})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","request_id":"req_1","status":200,"url":"/v1/customers"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","request_id":"req_2","status":200,"url":"/v1/customers"}`))
+	tailer.finish()
+
+	require.NotContains(t, buf.String(), "(x")
+	require.Len(t, strings.Split(strings.TrimSpace(buf.String()), "\n"), 2)
+}
+
+func TestCollapseFlushInterval(t *testing.T) {
+	var buf syncBuffer
+
+	tailer := New(&Config{Collapse: true, CollapseInterval: 20 * time.Millisecond, NoColor: true, Out: &buf})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go tailer.runCollapseFlush(ctx)
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","request_id":"req_1","status":404,"url":"/v1/charges/ch_123"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","request_id":"req_2","status":404,"url":"/v1/charges/ch_123"}`))
+
+	require.Eventually(t, func() bool {
+		return strings.Contains(buf.String(), "[404] GET /v1/charges/ch_123 [req_2] (x2)")
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestCollapseKeepsJSON(t *testing.T) {
+	var buf, jsonBuf bytes.Buffer
+
+	tailer := New(&Config{
+		Collapse: true,
+		NoColor:  true,
+		Out:      &buf,
+		Outputs:  []Output{{Out: &jsonBuf, Format: "JSON"}},
+	})
+
+	for i := 0; i < 3; i++ {
+		tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/charges"}`))
+	}
+
+	require.Len(t, strings.Split(strings.TrimSpace(jsonBuf.String()), "\n"), 3)
+
+	tailer.finish()
+
+	require.Contains(t, buf.String(), "(x3)")
+}
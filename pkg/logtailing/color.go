@@ -0,0 +1,46 @@
+package logtailing
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/stripe/stripe-cli/pkg/ansi"
+)
+
+const (
+	colorModeAuto   = "auto"
+	colorModeAlways = "always"
+	colorModeNever  = "never"
+)
+
+// validateColorMode returns an error if colorMode isn't a supported mode.
+func validateColorMode(colorMode string) error {
+	switch strings.ToLower(colorMode) {
+	case "", colorModeAuto, colorModeAlways, colorModeNever:
+		return nil
+	default:
+		return fmt.Errorf("unknown color mode %q. Expected %s, %s or %s", colorMode, colorModeAuto, colorModeAlways, colorModeNever)
+	}
+}
+
+// useColors reports whether colors and other ANSI sequences are written to
+// w. NoColor takes precedence over ColorMode, and the auto mode defers to
+// the ansi package, which detects terminals and honors the global color
+// settings.
+func (t *Tailer) useColors(w io.Writer) bool {
+	switch {
+	case t.colorsDisabled():
+		return false
+	case strings.EqualFold(t.cfg.ColorMode, colorModeAlways):
+		return true
+	default:
+		return ansi.ShouldUseColors(t.probed(w))
+	}
+}
+
+// colorsDisabled reports whether colors are turned off regardless of the
+// output, with either NoColor or the never mode.
+func (t *Tailer) colorsDisabled() bool {
+	return t.cfg.NoColor || strings.EqualFold(t.cfg.ColorMode, colorModeNever)
+}
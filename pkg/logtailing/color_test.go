@@ -0,0 +1,95 @@
+package logtailing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/ansi"
+)
+
+const colorTestPayload = `{"method":"POST","status":402,"url":"/v1/charges","request_id":"req_1","error":{"code":"card_declined"}}`
+
+func TestColorModeAuto(t *testing.T) {
+	var buf bytes.Buffer
+
+	// A buffer isn't a terminal
+	tailer := New(&Config{ColorMode: "auto", Out: &buf})
+	tailer.processRequestLogEvent(requestLogMessage(colorTestPayload))
+	require.NotContains(t, buf.String(), "\x1b[")
+
+	ansi.ForceColors = true
+	defer func() { ansi.ForceColors = false }()
+
+	buf.Reset()
+
+	// The global color settings are honored
+	tailer = New(&Config{Out: &buf})
+	tailer.processRequestLogEvent(requestLogMessage(colorTestPayload))
+	require.Contains(t, buf.String(), "\x1b[")
+}
+
+func TestColorModeAlways(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{ColorMode: "always", Out: &buf})
+	tailer.processRequestLogEvent(requestLogMessage(colorTestPayload))
+
+	require.Contains(t, buf.String(), "\x1b[")
+	require.Contains(t, buf.String(), "\x1b]8;;https://dashboard.stripe.com/test/logs/req_1")
+
+	buf.Reset()
+
+	tailer = New(&Config{ColorMode: "always", OutputFormat: "JSON", Out: &buf})
+	tailer.processRequestLogEvent(requestLogMessage(colorTestPayload))
+	require.Contains(t, buf.String(), "\x1b[")
+}
+
+func TestColorModeNever(t *testing.T) {
+	ansi.ForceColors = true
+	defer func() { ansi.ForceColors = false }()
+
+	var buf bytes.Buffer
+
+	tailer := New(&Config{ColorMode: "never", Out: &buf})
+	tailer.processRequestLogEvent(requestLogMessage(colorTestPayload))
+	require.NotContains(t, buf.String(), "\x1b")
+
+	buf.Reset()
+
+	// NoColor takes precedence over always
+	tailer = New(&Config{ColorMode: "always", NoColor: true, Out: &buf})
+	tailer.processRequestLogEvent(requestLogMessage(colorTestPayload))
+	require.NotContains(t, buf.String(), "\x1b")
+}
+
+func TestForceColorJSON(t *testing.T) {
+	var buf bytes.Buffer
+
+	// A buffer isn't a terminal, but the JSON is colorized anyway
+	tailer := New(&Config{ForceColorJSON: true, OutputFormat: "JSON", Out: &buf})
+	tailer.processRequestLogEvent(requestLogMessage(colorTestPayload))
+	require.Contains(t, buf.String(), "\x1b[")
+	require.True(t, json.Valid([]byte(ansi.Strip(buf.String()))))
+
+	buf.Reset()
+
+	// Other formats aren't affected
+	tailer = New(&Config{ForceColorJSON: true, Out: &buf})
+	tailer.processRequestLogEvent(requestLogMessage(colorTestPayload))
+	require.NotContains(t, buf.String(), "\x1b[")
+
+	buf.Reset()
+
+	tailer = New(&Config{ForceColorJSON: true, NoColor: true, OutputFormat: "JSON", Out: &buf})
+	tailer.processRequestLogEvent(requestLogMessage(colorTestPayload))
+	require.NotContains(t, buf.String(), "\x1b[")
+}
+
+func TestColorModeInvalid(t *testing.T) {
+	err := New(&Config{ColorMode: "sometimes"}).Run(context.Background())
+	require.EqualError(t, err, `unknown color mode "sometimes". Expected auto, always or never`)
+}
@@ -0,0 +1,59 @@
+package logtailing
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const controlReconnect = "reconnect"
+
+// ControlRecord is written to the JSON outputs and OutFile when
+// ControlRecords is set, so that NDJSON consumers can detect gaps in the
+// stream without an out-of-band notice breaking JSON parsing.
+type ControlRecord struct {
+	// Control is the kind of record, e.g. "reconnect"
+	Control string `json:"_control"`
+
+	// At is the time the record was emitted
+	At time.Time `json:"at"`
+}
+
+// recordReconnect counts a reconnection after the session expired, and emits
+// a reconnect control record.
+func (t *Tailer) recordReconnect() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.reconnects++
+	t.emitControl(controlReconnect)
+}
+
+// emitControl writes a control record of the given kind to the JSON outputs
+// and OutFile, if ControlRecords is set. The caller must hold t.mu.
+func (t *Tailer) emitControl(kind string) {
+	if !t.cfg.ControlRecords {
+		return
+	}
+
+	now := t.cfg.Now()
+
+	encoded, err := json.Marshal(ControlRecord{Control: kind, At: now.UTC()})
+	if err != nil {
+		t.onError(err)
+		return
+	}
+
+	line := string(encoded)
+
+	for _, output := range t.outputs {
+		if strings.EqualFold(output.Format, outputFormatJSON) {
+			fmt.Fprintln(output.Out, line)
+		}
+	}
+
+	if t.fileSink != nil {
+		t.fileSink.write(int(now.Unix()), line)
+	}
+}
@@ -0,0 +1,56 @@
+package logtailing
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestControlRecordOnReconnect(t *testing.T) {
+	var text, jsonBuf bytes.Buffer
+
+	clock := newFakeClock()
+
+	tailer := New(&Config{
+		ControlRecords: true,
+		NoColor:        true,
+		Now:            clock.Now,
+		Out:            &text,
+		Outputs:        []Output{{Out: &jsonBuf, Format: "JSON"}},
+	})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","request_id":"req_1","status":200}`))
+	tailer.recordReconnect()
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","request_id":"req_2","status":200}`))
+
+	lines := strings.Split(strings.TrimSpace(jsonBuf.String()), "\n")
+	require.Len(t, lines, 3)
+
+	var record ControlRecord
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &record))
+	require.Equal(t, "reconnect", record.Control)
+	require.True(t, clock.Now().Equal(record.At))
+
+	// Every line of the stream is still valid JSON
+	for _, line := range lines {
+		require.True(t, json.Valid([]byte(line)))
+	}
+
+	// The default format isn't a JSON stream
+	require.NotContains(t, text.String(), "_control")
+	require.Equal(t, 1, tailer.report().Reconnects)
+}
+
+func TestControlRecordsDisabled(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{Out: &buf, OutputFormat: "JSON"})
+
+	tailer.recordReconnect()
+
+	require.Empty(t, buf.String())
+	require.Equal(t, 1, tailer.report().Reconnects)
+}
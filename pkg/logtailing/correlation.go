@@ -0,0 +1,104 @@
+package logtailing
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type contextKey string
+
+// CorrelationIDKey is the context key of a correlation ID, e.g. a trace ID
+// of the system embedding the tailer. When the context passed to Run
+// carries one, it's added as a correlation_id field to every line the
+// tailer logs.
+const CorrelationIDKey = contextKey("correlation_id")
+
+// WithCorrelationID returns a copy of ctx carrying the correlation ID.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, CorrelationIDKey, id)
+}
+
+// correlationID returns the correlation ID carried by ctx, if any.
+func correlationID(ctx context.Context) string {
+	id, _ := ctx.Value(CorrelationIDKey).(string)
+	return id
+}
+
+// fieldsHook adds fields to every entry logged.
+type fieldsHook struct {
+	fields log.Fields
+}
+
+func (h *fieldsHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (h *fieldsHook) Fire(entry *log.Entry) error {
+	for key, value := range h.fields {
+		if _, ok := entry.Data[key]; !ok {
+			entry.Data[key] = value
+		}
+	}
+
+	return nil
+}
+
+// loggerWithFields returns a logger writing like logger, that adds fields to
+// every entry. logger itself is left untouched since it may be shared, e.g.
+// the standard logger.
+func loggerWithFields(logger *log.Logger, fields log.Fields) *log.Logger {
+	hooks := make(log.LevelHooks)
+	for level, levelHooks := range logger.Hooks {
+		hooks[level] = append(hooks[level], levelHooks...)
+	}
+
+	hooks.Add(&fieldsHook{fields: fields})
+
+	return &log.Logger{
+		Out:          logger.Out,
+		Formatter:    logger.Formatter,
+		Hooks:        hooks,
+		Level:        logger.GetLevel(),
+		ExitFunc:     logger.ExitFunc,
+		ReportCaller: logger.ReportCaller,
+	}
+}
+
+// useCorrelationID switches the tailer to a logger adding the correlation
+// ID carried by ctx, if any, to every entry. Config.Log is left untouched.
+func (t *Tailer) useCorrelationID(ctx context.Context) {
+	id := correlationID(ctx)
+	if id == "" {
+		return
+	}
+
+	logger := loggerWithFields(t.cfg.Log, log.Fields{"correlation_id": id})
+
+	t.log = logger
+	t.stripeAuthClient = newStripeAuthClient(t.cfg, logger)
+
+	if t.eventSocket != nil {
+		t.eventSocket.log = logger
+	}
+
+	if t.fileSink != nil {
+		t.fileSink.log = logger
+	}
+
+	if t.forwarder != nil {
+		t.forwarder.log = logger
+	}
+
+	if t.grpcSink != nil {
+		t.grpcSink.log = logger
+	}
+
+	if t.otlpSink != nil {
+		t.otlpSink.log = logger
+	}
+
+	if t.syslogSink != nil {
+		t.syslogSink.log = logger
+	}
+}
@@ -0,0 +1,59 @@
+package logtailing
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCorrelationID(t *testing.T) {
+	var logOut bytes.Buffer
+
+	logger := log.New()
+	logger.Out = &logOut
+	logger.Level = log.DebugLevel
+	logger.Formatter = &log.TextFormatter{DisableColors: true, DisableTimestamp: true}
+
+	tailer := New(&Config{
+		Input: strings.NewReader(`{"method":"GET","status":200}` + "\n" + `not json` + "\n"),
+		Log:   logger,
+		Out:   &bytes.Buffer{},
+	})
+
+	require.NoError(t, tailer.Run(WithCorrelationID(context.Background(), "trace-123")))
+
+	lines := strings.Split(strings.TrimSpace(logOut.String()), "\n")
+	require.NotEmpty(t, lines)
+
+	for _, line := range lines {
+		if strings.HasPrefix(line, "level=") {
+			require.Contains(t, line, "correlation_id=trace-123", line)
+		}
+	}
+
+	require.Contains(t, logOut.String(), "prefix=logtailing.Tailer.processRequestLogEvent")
+
+	// The logger passed in is shared, so it's left untouched, and so is the
+	// config
+	require.Empty(t, logger.Hooks)
+	require.Same(t, logger, tailer.cfg.Log)
+	require.NotSame(t, logger, tailer.log)
+}
+
+func TestNoCorrelationID(t *testing.T) {
+	var logOut bytes.Buffer
+
+	logger := log.New()
+	logger.Out = &logOut
+	logger.Level = log.DebugLevel
+
+	tailer := New(&Config{Input: strings.NewReader(`{"method":"GET","status":200}`), Log: logger, Out: &bytes.Buffer{}})
+
+	require.NoError(t, tailer.Run(context.Background()))
+	require.NotContains(t, logOut.String(), "correlation_id")
+	require.Same(t, logger, tailer.cfg.Log)
+}
@@ -0,0 +1,71 @@
+package logtailing
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/stripe/stripe-cli/pkg/stripe"
+)
+
+const diagnoseTimeout = 10 * time.Second
+
+// Diagnose checks that request logs can be tailed with cfg, without
+// streaming any: it resolves the API host, then runs the checks of
+// Preflight. Each step is reported with its timing on Log.Out, and the first
+// failing step is returned as an error.
+func Diagnose(ctx context.Context, cfg *Config) error {
+	t := New(cfg)
+
+	if err := t.validateConfig(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, diagnoseTimeout)
+	defer cancel()
+
+	apiBaseURL := cfg.APIBaseURL
+	if apiBaseURL == "" {
+		apiBaseURL = stripe.DefaultAPIBaseURL
+	}
+
+	apiURL, err := url.Parse(apiBaseURL)
+	if err != nil {
+		return fmt.Errorf("invalid API base URL: %v", err)
+	}
+
+	err = t.diagnoseStep(fmt.Sprintf("Resolving %s", apiURL.Hostname()), func() error {
+		_, err := net.DefaultResolver.LookupHost(ctx, apiURL.Hostname())
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = t.preflight(ctx, func(name string, check func() error) error {
+		return t.diagnoseStep(strings.ToUpper(name[:1])+name[1:], check)
+	})
+
+	return err
+}
+
+// diagnoseStep runs a step of Diagnose and reports its outcome and timing.
+func (t *Tailer) diagnoseStep(name string, step func() error) error {
+	color := t.color(t.log.Out)
+	start := time.Now()
+
+	err := step()
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	if err != nil {
+		fmt.Fprintf(t.log.Out, "%s... %s (%v)\n", name, color.Red("FAIL"), elapsed)
+		return fmt.Errorf("%s failed after %v: %v", name, elapsed, err)
+	}
+
+	fmt.Fprintf(t.log.Out, "%s... %s (%v)\n", name, color.Green("ok"), elapsed)
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package logtailing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	ws "github.com/gorilla/websocket"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/stripeauth"
+)
+
+// newDiagnoseServer returns a stub of the sessions endpoint and of the
+// websocket server. The handshake is rejected with rejectMessage if set.
+func newDiagnoseServer(t *testing.T, rejectMessage string) *httptest.Server {
+	upgrader := ws.Upgrader{}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/stripecli/sessions" {
+			json.NewEncoder(w).Encode(stripeauth.StripeCLISession{
+				WebSocketID:                "websocket-1",
+				WebSocketURL:               "wss://" + r.Host + "/subscribe",
+				WebSocketAuthorizedFeature: "request_logs",
+			})
+
+			return
+		}
+
+		if rejectMessage != "" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprintf(w, `{"error":{"message":%q}}`, rejectMessage)
+
+			return
+		}
+
+		require.Equal(t, "websocket-1", r.Header.Get("Websocket-Id"))
+
+		c, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		c.Close()
+	}))
+}
+
+func diagnoseConfig(apiBaseURL string, out *bytes.Buffer) *Config {
+	return &Config{
+		APIBaseURL:       apiBaseURL,
+		Key:              "sk_test_123",
+		Log:              &log.Logger{Out: out},
+		NoColor:          true,
+		NoWSS:            true,
+		WebSocketFeature: "request_logs",
+	}
+}
+
+func TestDiagnose(t *testing.T) {
+	ts := newDiagnoseServer(t, "")
+	defer ts.Close()
+
+	var out bytes.Buffer
+
+	require.NoError(t, Diagnose(context.Background(), diagnoseConfig(ts.URL, &out)))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 4)
+	require.Contains(t, lines[0], "Resolving 127.0.0.1... ok (")
+	require.Contains(t, lines[1], "Authorizing a request_logs session... ok (")
+	require.Contains(t, lines[2], "Connecting to the request_logs websocket host... ok (")
+	require.Contains(t, lines[3], "Completing the request_logs websocket handshake... ok (")
+}
+
+func TestDiagnoseHandshakeFailure(t *testing.T) {
+	ts := newDiagnoseServer(t, "Unknown WebSocket ID.")
+	defer ts.Close()
+
+	var out bytes.Buffer
+
+	err := Diagnose(context.Background(), diagnoseConfig(ts.URL, &out))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Completing the request_logs websocket handshake failed after")
+	require.Contains(t, err.Error(), "Unknown WebSocket ID.")
+
+	require.Contains(t, out.String(), "Completing the request_logs websocket handshake... FAIL (")
+}
+
+func TestDiagnoseSessionFailure(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	var out bytes.Buffer
+
+	err := Diagnose(context.Background(), diagnoseConfig(ts.URL, &out))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Authorizing a request_logs session failed after")
+	require.NotContains(t, out.String(), "Connecting")
+}
+
+func TestDiagnoseFeatureNotAuthorized(t *testing.T) {
+	ts := newDiagnoseServer(t, "")
+	defer ts.Close()
+
+	var out bytes.Buffer
+
+	// Checked by Preflight, which Diagnose shares its checks with
+	cfg := diagnoseConfig(ts.URL, &out)
+	cfg.WebSocketFeature = "webhook_payloads"
+
+	err := Diagnose(context.Background(), cfg)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "isn't authorized for the webhook_payloads feature")
+	require.Contains(t, out.String(), "Authorizing a webhook_payloads session... FAIL (")
+}
@@ -0,0 +1,138 @@
+package logtailing
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// DiffEntry compares the request log of a request in two captures. A or B is
+// nil when the request only appears in the other capture.
+type DiffEntry struct {
+	RequestID string
+
+	A *EventPayload
+	B *EventPayload
+
+	// Changes describe how the status and error fields differ between A and
+	// B, e.g. `status: 200 -> 402`. It's empty when both match.
+	Changes []string
+}
+
+// Matches reports whether the request has the same status and error in both
+// captures.
+func (d DiffEntry) Matches() bool {
+	return d.A != nil && d.B != nil && len(d.Changes) == 0
+}
+
+// Diff compares two NDJSON captures, as written by the JSON output format or
+// the out file, e.g. to check a deploy didn't change how requests are
+// handled. Request logs are aligned by request ID, keeping the last one when
+// a request appears several times. Entries are returned in the order the
+// requests appear in a, followed by the requests only found in b.
+func Diff(a, b string) ([]DiffEntry, error) {
+	before, beforeOrder, err := readCapture(a)
+	if err != nil {
+		return nil, err
+	}
+
+	after, afterOrder, err := readCapture(b)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]DiffEntry, 0, len(beforeOrder))
+
+	for _, id := range beforeOrder {
+		entry := DiffEntry{RequestID: id, A: before[id], B: after[id]}
+		if entry.B == nil {
+			entry.Changes = []string{"missing from " + b}
+		} else {
+			entry.Changes = diffPayloads(entry.A, entry.B)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	for _, id := range afterOrder {
+		if _, ok := before[id]; ok {
+			continue
+		}
+
+		entries = append(entries, DiffEntry{RequestID: id, B: after[id], Changes: []string{"missing from " + a}})
+	}
+
+	return entries, nil
+}
+
+// readCapture returns the request logs of an NDJSON capture by request ID,
+// and the request IDs in the order they first appear. Request logs without
+// a request ID can't be aligned and are skipped.
+func readCapture(path string) (map[string]*EventPayload, []string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	payloads := make(map[string]*EventPayload)
+
+	var order []string
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxReplayLineSize)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var payload EventPayload
+		if err := json.Unmarshal([]byte(unwrapEnvelope(line)), &payload); err != nil {
+			return nil, nil, fmt.Errorf("malformed line %d in %s: %v", n, path, err)
+		}
+
+		if payload.RequestID == "" {
+			continue
+		}
+
+		if _, ok := payloads[payload.RequestID]; !ok {
+			order = append(order, payload.RequestID)
+		}
+
+		payloads[payload.RequestID] = &payload
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return payloads, order, nil
+}
+
+// diffPayloads describes how the status and error fields of two request
+// logs differ.
+func diffPayloads(a, b *EventPayload) []string {
+	var changes []string
+
+	if a.Status != b.Status {
+		changes = append(changes, fmt.Sprintf("status: %d -> %d", a.Status, b.Status))
+	}
+
+	aError := reflect.ValueOf(&a.Error).Elem()
+	bError := reflect.ValueOf(&b.Error).Elem()
+	errType := aError.Type()
+
+	for i := 0; i < aError.NumField(); i++ {
+		before, after := aError.Field(i).String(), bError.Field(i).String()
+		if before != after {
+			changes = append(changes, fmt.Sprintf("error.%s: %q -> %q", errType.Field(i).Tag.Get("json"), before, after))
+		}
+	}
+
+	return changes
+}
@@ -0,0 +1,92 @@
+package logtailing
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const diffCaptureBefore = `{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_1"}
+{"method":"POST","status":200,"url":"/v1/charges","request_id":"req_2"}
+{"seq":3,"payload":{"method":"POST","status":402,"url":"/v1/charges","request_id":"req_3","error":{"type":"card_error","code":"card_declined"}}}
+{"method":"DELETE","status":200,"url":"/v1/customers/cus_1","request_id":"req_4"}
+`
+
+const diffCaptureAfter = `{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_1"}
+{"method":"POST","status":500,"url":"/v1/charges","request_id":"req_2","error":{"type":"api_error"}}
+
+{"method":"POST","status":402,"url":"/v1/charges","request_id":"req_3","error":{"type":"card_error","code":"expired_card"}}
+{"method":"GET","status":200,"url":"/v1/balance","request_id":"req_5"}
+`
+
+func writeCaptures(t *testing.T, captures ...string) []string {
+	dir, err := ioutil.TempDir("", "diff")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	paths := make([]string, len(captures))
+
+	for i, capture := range captures {
+		paths[i] = filepath.Join(dir, fmt.Sprintf("capture%d.ndjson", i))
+		require.NoError(t, ioutil.WriteFile(paths[i], []byte(capture), 0600))
+	}
+
+	return paths
+}
+
+func TestDiff(t *testing.T) {
+	paths := writeCaptures(t, diffCaptureBefore, diffCaptureAfter)
+
+	entries, err := Diff(paths[0], paths[1])
+	require.NoError(t, err)
+	require.Len(t, entries, 5)
+
+	require.Equal(t, "req_1", entries[0].RequestID)
+	require.True(t, entries[0].Matches())
+	require.Empty(t, entries[0].Changes)
+
+	require.Equal(t, "req_2", entries[1].RequestID)
+	require.False(t, entries[1].Matches())
+	require.Equal(t, []string{`status: 200 -> 500`, `error.type: "" -> "api_error"`}, entries[1].Changes)
+
+	// Envelopes are unwrapped
+	require.Equal(t, "req_3", entries[2].RequestID)
+	require.Equal(t, []string{`error.code: "card_declined" -> "expired_card"`}, entries[2].Changes)
+
+	require.Equal(t, "req_4", entries[3].RequestID)
+	require.Nil(t, entries[3].B)
+	require.False(t, entries[3].Matches())
+	require.Equal(t, []string{"missing from " + paths[1]}, entries[3].Changes)
+
+	require.Equal(t, "req_5", entries[4].RequestID)
+	require.Nil(t, entries[4].A)
+	require.Equal(t, "/v1/balance", entries[4].B.URL)
+	require.Equal(t, []string{"missing from " + paths[0]}, entries[4].Changes)
+}
+
+func TestDiffIdentical(t *testing.T) {
+	paths := writeCaptures(t, diffCaptureBefore, diffCaptureBefore)
+
+	entries, err := Diff(paths[0], paths[1])
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+
+	for _, entry := range entries {
+		require.True(t, entry.Matches(), entry.RequestID)
+	}
+}
+
+func TestDiffMalformed(t *testing.T) {
+	paths := writeCaptures(t, diffCaptureBefore, "{\"request_id\":\"req_1\"}\nnot json\n")
+
+	_, err := Diff(paths[0], paths[1])
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "malformed line 2 in "+paths[1])
+
+	_, err = Diff(paths[0], filepath.Join(filepath.Dir(paths[0]), "missing.ndjson"))
+	require.Error(t, err)
+}
@@ -0,0 +1,47 @@
+package logtailing
+
+import "fmt"
+
+const (
+	dateLayout     = "2006-01-02"
+	dateTimeLayout = "2006-01-02 15:04:05"
+	timeLayout     = "15:04:05"
+)
+
+// lineTimeLayout returns the layout of the time at the start of each line in
+// the default format. The date is left to the dividers when they're enabled.
+func (t *Tailer) lineTimeLayout() string {
+	if t.cfg.DayDividers {
+		return timeLayout
+	}
+
+	return dateTimeLayout
+}
+
+// printDayDivider prints a divider with the date of evt to the i-th output
+// before its first request log and whenever the day rolls over. Pending
+// collapsed request logs are printed first so that they stay under the
+// divider of their day. The caller must hold t.mu.
+func (t *Tailer) printDayDivider(i int, evt *event) {
+	output := t.outputs[i]
+	if !t.cfg.DayDividers || machineReadable(output.Format) {
+		return
+	}
+
+	day := t.createdAt(evt.payload.CreatedAt).Format(dateLayout)
+	if t.lastDays[i] == day {
+		return
+	}
+
+	if t.collapses(output) {
+		t.flushGroup(i)
+	}
+
+	t.lastDays[i] = day
+
+	if t.highlightEnabled(output) {
+		t.clearHighlight()
+	}
+
+	fmt.Fprintln(output.Out, t.color(output.Out).Faint(fmt.Sprintf("--- %s ---", day)))
+}
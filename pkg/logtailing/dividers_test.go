@@ -0,0 +1,74 @@
+package logtailing
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDayDividers(t *testing.T) {
+	var text, jsonBuf bytes.Buffer
+
+	tailer := New(&Config{
+		DayDividers: true,
+		NoColor:     true,
+		Out:         &text,
+		Outputs:     []Output{{Out: &jsonBuf, Format: "JSON"}},
+	})
+
+	for i, created := range []time.Time{
+		time.Date(2024, 1, 2, 23, 59, 58, 0, time.Local),
+		time.Date(2024, 1, 2, 23, 59, 59, 0, time.Local),
+		time.Date(2024, 1, 3, 0, 0, 1, 0, time.Local),
+	} {
+		tailer.processRequestLogEvent(requestLogMessage(fmt.Sprintf(`{"created_at":%d,"method":"GET","request_id":"req_%d","status":200,"url":"/v1/charges"}`, created.Unix(), i)))
+	}
+
+	require.Equal(t, strings.Join([]string{
+		"--- 2024-01-02 ---",
+		"23:59:58 [200] GET /v1/charges [req_0]",
+		"23:59:59 [200] GET /v1/charges [req_1]",
+		"--- 2024-01-03 ---",
+		"00:00:01 [200] GET /v1/charges [req_2]",
+	}, "\n")+"\n", text.String())
+
+	require.NotContains(t, jsonBuf.String(), "---")
+}
+
+func TestDayDividersCollapsed(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{Collapse: true, DayDividers: true, NoColor: true, Out: &buf})
+
+	for _, created := range []time.Time{
+		time.Date(2024, 1, 2, 23, 59, 59, 0, time.Local),
+		time.Date(2024, 1, 3, 0, 0, 1, 0, time.Local),
+	} {
+		tailer.processRequestLogEvent(requestLogMessage(fmt.Sprintf(`{"created_at":%d,"method":"GET","request_id":"req_1","status":200,"url":"/v1/charges"}`, created.Unix())))
+	}
+
+	tailer.finish()
+
+	// The group is split at midnight so that each day has its own line
+	require.Equal(t, strings.Join([]string{
+		"--- 2024-01-02 ---",
+		"23:59:59 [200] GET /v1/charges [req_1]",
+		"--- 2024-01-03 ---",
+		"00:00:01 [200] GET /v1/charges [req_1]",
+	}, "\n")+"\n", buf.String())
+}
+
+func TestDayDividersDisabled(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{NoColor: true, Out: &buf})
+
+	created := time.Date(2024, 1, 3, 0, 0, 1, 0, time.Local)
+	tailer.processRequestLogEvent(requestLogMessage(fmt.Sprintf(`{"created_at":%d,"method":"GET","request_id":"req_1","status":200,"url":"/v1/charges"}`, created.Unix())))
+
+	require.Equal(t, "2024-01-03 00:00:01 [200] GET /v1/charges [req_1]\n", buf.String())
+}
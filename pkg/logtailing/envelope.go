@@ -0,0 +1,151 @@
+package logtailing
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// hostname returns the host name of the machine. It's a variable so that
+// tests can replace it.
+var hostname = os.Hostname
+
+// Envelope wraps a request log payload with metadata added by the CLI. It is
+// written by the JSON output format and the sinks instead of the bare payload
+// whenever any metadata is requested.
+type Envelope struct {
+	Seq int `json:"seq,omitempty"`
+
+	// EventID is a hash of the request log that stays the same no matter
+	// which CLI instance delivered it, for consumers to dedupe on
+	EventID string `json:"event_id,omitempty"`
+
+	// RequestLogID and Type come from the websocket message that delivered
+	// the request log
+	RequestLogID string `json:"request_log_id,omitempty"`
+	Type         string `json:"type,omitempty"`
+
+	// ErrorKind is the classification of the request log's error, as
+	// returned by RedactedError.Kind
+	ErrorKind string `json:"error_kind,omitempty"`
+
+	// PayloadBytes is the size of the payload as received from Stripe
+	PayloadBytes int `json:"payload_bytes,omitempty"`
+
+	// Feature is the websocket feature the request log was received on,
+	// when several are streamed
+	Feature string `json:"feature,omitempty"`
+
+	// Device and Hostname identify the machine that captured the request
+	// log, so that captures merged from several hosts stay attributable
+	Device   string `json:"device,omitempty"`
+	Hostname string `json:"hostname,omitempty"`
+
+	// MatchedFilters are the client-side filters that let the request log
+	// through
+	MatchedFilters []string `json:"matched_filters,omitempty"`
+
+	Payload json.RawMessage `json:"payload"`
+}
+
+// event is a request log that passed the filters and is ready to be output.
+type event struct {
+	payload EventPayload
+
+	// raw is the JSON encoded payload
+	raw string
+
+	seq int
+
+	// requestLogID and msgType are the metadata of the websocket message
+	requestLogID string
+	msgType      string
+
+	// size is the length in bytes of the payload as received from Stripe
+	size int
+
+	// feature is the websocket feature the event was received on, if tagged
+	feature string
+
+	// matchedFilters are the client-side filters the event matched, when
+	// ShowMatchedFilter is set
+	matchedFilters []string
+
+	// rendered is the event in the default format as last written to an
+	// output, for Plain outputs to reuse
+	rendered string
+}
+
+// envelopeEnabled reports whether JSON output needs to be wrapped in an
+// Envelope.
+func (t *Tailer) envelopeEnabled() bool {
+	return t.cfg.ShowSeq || t.cfg.ShowSize || t.cfg.IncludeMetadata || t.cfg.IncludeDevice || t.cfg.IncludeEventID || t.cfg.IncludeHostname || t.cfg.ShowMatchedFilter || t.tagged()
+}
+
+// encodeJSON returns the JSON written for the event by the JSON output format
+// and the sinks: either the payload as is, or an Envelope when metadata is
+// requested.
+func (t *Tailer) encodeJSON(evt *event) string {
+	if !t.envelopeEnabled() {
+		return evt.raw
+	}
+
+	envelope := Envelope{Payload: json.RawMessage(evt.raw)}
+
+	if t.cfg.ShowSeq {
+		envelope.Seq = evt.seq
+	}
+
+	if t.cfg.ShowSize {
+		envelope.PayloadBytes = evt.size
+	}
+
+	envelope.Feature = evt.feature
+	envelope.MatchedFilters = evt.matchedFilters
+
+	if t.cfg.IncludeDevice {
+		envelope.Device = t.cfg.DeviceName
+	}
+
+	if t.cfg.IncludeEventID {
+		envelope.EventID = eventID(&evt.payload)
+	}
+
+	if t.cfg.IncludeHostname {
+		envelope.Hostname = t.hostname
+	}
+
+	if t.cfg.IncludeMetadata {
+		envelope.RequestLogID = evt.requestLogID
+		envelope.Type = evt.msgType
+		envelope.ErrorKind = evt.payload.Error.Kind()
+	}
+
+	encoded, err := json.Marshal(envelope)
+	if err != nil {
+		t.log.Debug("Unable to wrap malformed payload in envelope: ", err)
+		return evt.raw
+	}
+
+	return string(encoded)
+}
+
+// eventID returns the SHA-256 of the request ID, creation time and status of
+// the request log, as a hex string.
+func eventID(payload *EventPayload) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d\x00%d", payload.RequestID, payload.CreatedAt, payload.Status)))
+	return hex.EncodeToString(sum[:])
+}
+
+// unwrapEnvelope returns the payload of a captured JSON line, which may be
+// either a bare payload or an Envelope.
+func unwrapEnvelope(line string) string {
+	var envelope Envelope
+	if err := json.Unmarshal([]byte(line), &envelope); err != nil || len(envelope.Payload) == 0 {
+		return line
+	}
+
+	return string(envelope.Payload)
+}
@@ -0,0 +1,145 @@
+package logtailing
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShowSeqSkipsFilteredEvents(t *testing.T) {
+	var text, jsonBuf bytes.Buffer
+
+	tailer := New(&Config{
+		Filters: &LogFilters{FilterStatusCategory: []string{"error"}},
+		Out:     &text,
+		Outputs: []Output{{Out: &jsonBuf, Format: "JSON"}},
+		ShowSeq: true,
+	})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":400,"url":"/v1/charges","request_id":"req_1"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_2"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":500,"url":"/v1/customers","request_id":"req_3"}`))
+
+	lines := strings.Split(strings.TrimSpace(text.String()), "\n")
+	require.Len(t, lines, 2)
+	require.True(t, strings.HasPrefix(lines[0], "#1 "))
+	require.Contains(t, lines[0], "[req_1]")
+	require.True(t, strings.HasPrefix(lines[1], "#2 "))
+	require.Contains(t, lines[1], "[req_3]")
+
+	jsonLines := strings.Split(strings.TrimSpace(jsonBuf.String()), "\n")
+	require.Len(t, jsonLines, 2)
+
+	for i, line := range jsonLines {
+		var envelope Envelope
+		require.NoError(t, json.Unmarshal([]byte(line), &envelope))
+		require.Equal(t, i+1, envelope.Seq)
+	}
+
+	require.Equal(t, `{"seq":2,"payload":{"method":"GET","status":500,"url":"/v1/customers","request_id":"req_3"}}`, jsonLines[1])
+}
+
+func TestEnvelopeDisabledByDefault(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{Out: &buf, OutputFormat: "JSON"})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":200}`))
+
+	require.Equal(t, "{\"method\":\"POST\",\"status\":200}\n", buf.String())
+}
+
+func TestIncludeMetadata(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{IncludeMetadata: true, Out: &buf, OutputFormat: "JSON"})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":200}`))
+
+	require.Equal(t, "{\"request_log_id\":\"resp_123\",\"type\":\"request_log_event\",\"payload\":{\"method\":\"POST\",\"status\":200}}\n", buf.String())
+	require.Equal(t, `{"method":"POST","status":200}`, unwrapEnvelope(strings.TrimSpace(buf.String())))
+}
+
+func TestIncludeDevice(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{DeviceName: "ci-runner-1", IncludeDevice: true, Out: &buf, OutputFormat: "JSON"})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":200}`))
+
+	require.Equal(t, "{\"device\":\"ci-runner-1\",\"payload\":{\"method\":\"POST\",\"status\":200}}\n", buf.String())
+}
+
+func TestIncludeHostname(t *testing.T) {
+	defer func(original func() (string, error)) { hostname = original }(hostname)
+	hostname = func() (string, error) { return "build-host", nil }
+
+	var buf bytes.Buffer
+
+	tailer := New(&Config{DeviceName: "ci-runner-1", IncludeDevice: true, IncludeHostname: true, Out: &buf, OutputFormat: "JSON"})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":200}`))
+
+	var envelope Envelope
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &envelope))
+	require.Equal(t, "ci-runner-1", envelope.Device)
+	require.Equal(t, "build-host", envelope.Hostname)
+}
+
+func TestIncludeEventID(t *testing.T) {
+	eventIDOf := func(payload string) string {
+		var buf bytes.Buffer
+
+		tailer := New(&Config{IncludeEventID: true, Out: &buf, OutputFormat: "JSON"})
+		tailer.processRequestLogEvent(requestLogMessage(payload))
+
+		var envelope Envelope
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &envelope))
+		require.JSONEq(t, payload, string(envelope.Payload))
+
+		return envelope.EventID
+	}
+
+	id := eventIDOf(`{"created_at":1700000000,"method":"POST","request_id":"req_1","status":200,"url":"/v1/charges"}`)
+	require.Len(t, id, 64)
+
+	// Stable across instances, and independent of the fields that aren't hashed
+	require.Equal(t, id, eventIDOf(`{"created_at":1700000000,"method":"POST","request_id":"req_1","status":200,"url":"/v1/charges"}`))
+	require.Equal(t, id, eventIDOf(`{"created_at":1700000000,"method":"POST","request_id":"req_1","status":200,"url":"/v1/customers"}`))
+
+	require.NotEqual(t, id, eventIDOf(`{"created_at":1700000000,"method":"POST","request_id":"req_2","status":200,"url":"/v1/charges"}`))
+	require.NotEqual(t, id, eventIDOf(`{"created_at":1700000001,"method":"POST","request_id":"req_1","status":200,"url":"/v1/charges"}`))
+	require.NotEqual(t, id, eventIDOf(`{"created_at":1700000000,"method":"POST","request_id":"req_1","status":500,"url":"/v1/charges"}`))
+}
+
+func TestShowSize(t *testing.T) {
+	var text, jsonBuf, logfmt bytes.Buffer
+
+	payload := `{"method":"POST","request_id":"req_1","status":200,"url":"/v1/charges"}`
+
+	tailer := New(&Config{
+		NoColor:  true,
+		Out:      &text,
+		Outputs:  []Output{{Out: &jsonBuf, Format: "JSON"}, {Out: &logfmt, Format: "logfmt"}},
+		ShowSize: true,
+	})
+
+	tailer.processRequestLogEvent(requestLogMessage(payload))
+
+	require.True(t, strings.HasSuffix(strings.TrimSpace(text.String()), "[req_1] [71B]"))
+	require.True(t, strings.HasSuffix(strings.TrimSpace(logfmt.String()), "payload_bytes=71"))
+
+	var envelope Envelope
+	require.NoError(t, json.Unmarshal(jsonBuf.Bytes(), &envelope))
+	require.Equal(t, len(payload), envelope.PayloadBytes)
+	require.JSONEq(t, payload, string(envelope.Payload))
+}
+
+func TestUnwrapEnvelope(t *testing.T) {
+	require.Equal(t, `{"status":200}`, unwrapEnvelope(`{"seq":1,"payload":{"status":200}}`))
+	require.Equal(t, `{"status":200}`, unwrapEnvelope(`{"status":200}`))
+	require.Equal(t, `not json`, unwrapEnvelope(`not json`))
+}
@@ -0,0 +1,101 @@
+package logtailing
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// maxErrorCommands is the number of OnErrorCommand processes allowed to run
+// at the same time. Server errors arriving while they are all busy don't run
+// the command, so that a slow command never holds up the tail.
+const maxErrorCommands = 4
+
+// errorCommandsTimeout bounds the wait for the running OnErrorCommand
+// processes when the tail stops.
+const errorCommandsTimeout = 5 * time.Second
+
+// shellCommand returns the command running command through the shell.
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command) // #nosec G204
+	}
+
+	return exec.Command("sh", "-c", command) // #nosec G204
+}
+
+// runErrorCommand runs OnErrorCommand in the background for a server error,
+// with the request log's fields in its environment. It's called with the
+// event as handed to the sinks, before MaskAccount applies.
+func (t *Tailer) runErrorCommand(evt *event) {
+	if t.cfg.OnErrorCommand == "" || evt.payload.Status < 500 {
+		return
+	}
+
+	select {
+	case t.errorCommands <- struct{}{}:
+	default:
+		t.log.WithFields(log.Fields{
+			"prefix": "logtailing.Tailer.runErrorCommand",
+		}).Debug("Too many error commands running, skipping request log ", evt.payload.RequestID)
+
+		return
+	}
+
+	cmd := shellCommand(t.cfg.OnErrorCommand)
+	cmd.Env = append(os.Environ(), errorCommandEnv(&evt.payload)...)
+
+	t.errorCommandsWG.Add(1)
+
+	go func() {
+		defer t.errorCommandsWG.Done()
+		defer func() { <-t.errorCommands }()
+
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.log.WithFields(log.Fields{
+				"prefix": "logtailing.Tailer.runErrorCommand",
+				"output": string(output),
+			}).Warnf("Error command failed for request log %s: %v", evt.payload.RequestID, err)
+		}
+	}()
+}
+
+// waitErrorCommands waits for the running OnErrorCommand processes to exit,
+// so that their failures are still logged, for up to errorCommandsTimeout.
+func (t *Tailer) waitErrorCommands() {
+	done := make(chan struct{})
+
+	go func() {
+		t.errorCommandsWG.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(errorCommandsTimeout):
+		t.log.WithFields(log.Fields{
+			"prefix": "logtailing.Tailer.waitErrorCommands",
+		}).Debug("Timed out waiting for the error commands to exit")
+	}
+}
+
+// errorCommandEnv returns the environment variables describing a request log
+// to OnErrorCommand.
+func errorCommandEnv(payload *EventPayload) []string {
+	return []string{
+		"STRIPE_ACCOUNT=" + payload.Account,
+		"STRIPE_CREATED_AT=" + strconv.Itoa(payload.CreatedAt),
+		"STRIPE_ERROR_CODE=" + payload.Error.Code,
+		"STRIPE_ERROR_MESSAGE=" + payload.Error.Message,
+		"STRIPE_ERROR_TYPE=" + payload.Error.Type,
+		"STRIPE_METHOD=" + payload.Method,
+		"STRIPE_REQUEST_ID=" + payload.RequestID,
+		fmt.Sprintf("STRIPE_STATUS=%d", payload.Status),
+		"STRIPE_URL=" + payload.URL,
+	}
+}
@@ -0,0 +1,88 @@
+package logtailing
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnErrorCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the command uses sh syntax")
+	}
+
+	dir, err := ioutil.TempDir("", "logtailing")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "errors.txt")
+
+	tailer := New(&Config{
+		OnErrorCommand: `echo "$STRIPE_STATUS $STRIPE_REQUEST_ID $STRIPE_METHOD $STRIPE_URL" >> ` + path,
+		Out:            &bytes.Buffer{},
+	})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_1"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":402,"url":"/v1/charges","request_id":"req_2"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":503,"url":"/v1/charges","request_id":"req_3"}`))
+	tailer.errorCommandsWG.Wait()
+
+	written, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "503 req_3 POST /v1/charges\n", string(written))
+}
+
+func TestOnErrorCommandUnmaskedAndAwaited(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the command uses sh syntax")
+	}
+
+	dir, err := ioutil.TempDir("", "logtailing")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "errors.txt")
+
+	tailer := New(&Config{
+		CountOnly:      true,
+		MaskAccount:    true,
+		OnErrorCommand: `sleep 0.2; echo "$STRIPE_ACCOUNT $STRIPE_REQUEST_ID" >> ` + path,
+		Out:            &bytes.Buffer{},
+	})
+
+	// Run even though nothing is displayed in the count-only mode
+	tailer.processRequestLogEvent(requestLogMessage(`{"account":"acct_1Hd9xQa1b2","method":"POST","status":500,"request_id":"req_1"}`))
+
+	// finish waits for the command
+	tailer.finish()
+
+	written, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "acct_1Hd9xQa1b2 req_1\n", string(written))
+}
+
+func TestOnErrorCommandConcurrencyCap(t *testing.T) {
+	tailer := New(&Config{OnErrorCommand: "exit 0", Out: &bytes.Buffer{}})
+
+	// Take every slot, as if commands were still running
+	for i := 0; i < maxErrorCommands; i++ {
+		tailer.errorCommands <- struct{}{}
+	}
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":500,"request_id":"req_1"}`))
+	tailer.errorCommandsWG.Wait()
+
+	require.Len(t, tailer.errorCommands, maxErrorCommands)
+}
+
+func TestErrorCommandEnv(t *testing.T) {
+	env := errorCommandEnv(&EventPayload{Status: 500, RequestID: "req_1", Error: RedactedError{Code: "api_error"}})
+	require.Contains(t, env, "STRIPE_STATUS=500")
+	require.Contains(t, env, "STRIPE_REQUEST_ID=req_1")
+	require.Contains(t, env, "STRIPE_ERROR_CODE=api_error")
+}
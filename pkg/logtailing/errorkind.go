@@ -0,0 +1,49 @@
+package logtailing
+
+// The kinds of errors returned by RedactedError.Kind.
+const (
+	ErrorKindAPI            = "api_error"
+	ErrorKindAuthentication = "authentication"
+	ErrorKindCard           = "card_error"
+	ErrorKindCardDecline    = "card_decline"
+	ErrorKindIdempotency    = "idempotency"
+	ErrorKindInvalidRequest = "invalid_request"
+	ErrorKindRateLimit      = "rate_limit"
+	ErrorKindUnknown        = "unknown"
+)
+
+// errorKindsByType maps Stripe error types to their kind.
+var errorKindsByType = map[string]string{
+	"api_error":             ErrorKindAPI,
+	"api_connection_error":  ErrorKindAPI,
+	"authentication_error":  ErrorKindAuthentication,
+	"card_error":            ErrorKindCard,
+	"idempotency_error":     ErrorKindIdempotency,
+	"invalid_request_error": ErrorKindInvalidRequest,
+	"rate_limit_error":      ErrorKindRateLimit,
+}
+
+// IsDecline reports whether the error is a declined card.
+func (e RedactedError) IsDecline() bool {
+	return e.DeclineCode != "" || e.Code == "card_declined"
+}
+
+// Kind classifies the error based on its type and code. It returns an empty
+// string if there is no error, and ErrorKindUnknown if the error can't be
+// classified.
+func (e RedactedError) Kind() string {
+	switch {
+	case e == RedactedError{}:
+		return ""
+	case e.IsDecline():
+		return ErrorKindCardDecline
+	case e.Code == "rate_limit":
+		return ErrorKindRateLimit
+	}
+
+	if kind, ok := errorKindsByType[e.Type]; ok {
+		return kind
+	}
+
+	return ErrorKindUnknown
+}
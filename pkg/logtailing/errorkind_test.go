@@ -0,0 +1,49 @@
+package logtailing
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorKind(t *testing.T) {
+	decline := RedactedError{Type: "card_error", Code: "card_declined", DeclineCode: "insufficient_funds"}
+	require.True(t, decline.IsDecline())
+	require.Equal(t, ErrorKindCardDecline, decline.Kind())
+
+	expired := RedactedError{Type: "card_error", Code: "expired_card"}
+	require.False(t, expired.IsDecline())
+	require.Equal(t, ErrorKindCard, expired.Kind())
+
+	apiError := RedactedError{Type: "api_error", Message: "An unknown error occurred"}
+	require.False(t, apiError.IsDecline())
+	require.Equal(t, ErrorKindAPI, apiError.Kind())
+
+	require.Equal(t, ErrorKindInvalidRequest, RedactedError{Type: "invalid_request_error", Code: "parameter_missing"}.Kind())
+	require.Equal(t, ErrorKindRateLimit, RedactedError{Type: "invalid_request_error", Code: "rate_limit"}.Kind())
+	require.Equal(t, ErrorKindUnknown, RedactedError{Message: "Something went wrong"}.Kind())
+
+	none := RedactedError{}
+	require.False(t, none.IsDecline())
+	require.Equal(t, "", none.Kind())
+}
+
+func TestErrorKindInEnvelope(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{IncludeMetadata: true, Out: &buf, OutputFormat: "JSON"})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":402,"error":{"type":"card_error","code":"card_declined","decline_code":"generic_decline"}}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":200}`))
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	var envelope Envelope
+	require.NoError(t, json.Unmarshal(lines[0], &envelope))
+	require.Equal(t, ErrorKindCardDecline, envelope.ErrorKind)
+
+	require.NotContains(t, string(lines[1]), "error_kind")
+}
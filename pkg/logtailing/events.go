@@ -0,0 +1,79 @@
+package logtailing
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultEventChannelBuffer = 100
+
+// Events returns a channel that receives the payload of every request log
+// after filtering, middleware and redaction. The channel is closed when Run
+// returns.
+//
+// Unless DropWhenFull is set, a consumer that doesn't keep up blocks the
+// processing of request logs once EventChannelBuffer payloads are pending,
+// so the channel must be drained until it's closed. Once the context passed
+// to Run is canceled, payloads the consumer has no room for are dropped
+// instead, so that Run can return.
+func (t *Tailer) Events() <-chan EventPayload {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.events == nil {
+		t.events = make(chan EventPayload, t.cfg.EventChannelBuffer)
+	}
+
+	return t.events
+}
+
+// publishEvent sends a payload to the Events channel, if any. When the
+// channel is full and DropWhenFull is set, the oldest pending payload is
+// dropped to make room. t.mu must be held.
+func (t *Tailer) publishEvent(payload EventPayload) {
+	if t.events == nil || t.eventsClosed {
+		return
+	}
+
+	if !t.cfg.DropWhenFull {
+		select {
+		case t.events <- payload:
+		case <-t.eventsDone:
+			t.eventsDropped++
+
+			t.log.WithFields(log.Fields{
+				"prefix": "logtailing.Tailer.publishEvent",
+			}).Debug("Events channel is full and the tail stopped, dropping event")
+		}
+
+		return
+	}
+
+	for {
+		select {
+		case t.events <- payload:
+			return
+		default:
+		}
+
+		select {
+		case <-t.events:
+			t.eventsDropped++
+
+			t.log.WithFields(log.Fields{
+				"prefix": "logtailing.Tailer.publishEvent",
+			}).Debug("Events channel is full, dropping oldest event")
+		default:
+		}
+	}
+}
+
+// closeEvents closes the Events channel, if any.
+func (t *Tailer) closeEvents() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.events != nil && !t.eventsClosed {
+		close(t.events)
+		t.eventsClosed = true
+	}
+}
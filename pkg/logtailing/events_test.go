@@ -0,0 +1,117 @@
+package logtailing
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventsReceivesPayloads(t *testing.T) {
+	tailer := New(&Config{Out: &bytes.Buffer{}})
+	events := tailer.Events()
+
+	tailer.ProcessMessage(requestLogMessage(`{"method":"GET","request_id":"req_1","status":200,"url":"/v1/charges"}`))
+
+	evt := <-events
+	require.Equal(t, "req_1", evt.RequestID)
+	require.Equal(t, 200, evt.Status)
+
+	tailer.closeEvents()
+
+	_, ok := <-events
+	require.False(t, ok)
+}
+
+func TestEventsBlocksWhenFull(t *testing.T) {
+	tailer := New(&Config{Out: &bytes.Buffer{}, EventChannelBuffer: 1})
+	events := tailer.Events()
+
+	tailer.ProcessMessage(requestLogMessage(`{"request_id":"req_1","status":200}`))
+
+	processed := make(chan struct{})
+
+	go func() {
+		tailer.ProcessMessage(requestLogMessage(`{"request_id":"req_2","status":200}`))
+		close(processed)
+	}()
+
+	select {
+	case <-processed:
+		require.FailNow(t, "Expected processing to block until the consumer catches up")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.Equal(t, "req_1", (<-events).RequestID)
+	<-processed
+	require.Equal(t, "req_2", (<-events).RequestID)
+
+	require.Equal(t, 0, tailer.report().DroppedEvents)
+}
+
+func TestEventsDontBlockRunOnceStopped(t *testing.T) {
+	tailer := New(&Config{
+		EventChannelBuffer: 1,
+		Out:                &bytes.Buffer{},
+		ReplayFile: writeReplayFile(t,
+			`{"request_id":"req_1","status":200}`,
+			`{"request_id":"req_2","status":200}`,
+			`{"request_id":"req_3","status":200}`,
+		),
+	})
+	events := tailer.Events()
+
+	// The consumer never drains the channel
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	stopped := make(chan error)
+
+	go func() {
+		stopped <- tailer.Run(ctx)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		require.FailNow(t, "Timed out waiting for Run to return")
+	}
+
+	require.Equal(t, "req_1", (<-events).RequestID)
+
+	_, ok := <-events
+	require.False(t, ok)
+
+	// The replay may stop before the last request log
+	require.NotZero(t, tailer.report().DroppedEvents)
+}
+
+func TestEventsDropsOldestWhenFull(t *testing.T) {
+	tailer := New(&Config{Out: &bytes.Buffer{}, EventChannelBuffer: 2, DropWhenFull: true})
+	events := tailer.Events()
+
+	for i := 1; i <= 5; i++ {
+		tailer.ProcessMessage(requestLogMessage(fmt.Sprintf(`{"request_id":"req_%d","status":200}`, i)))
+	}
+
+	require.Equal(t, "req_4", (<-events).RequestID)
+	require.Equal(t, "req_5", (<-events).RequestID)
+
+	report := tailer.report()
+	require.Equal(t, 5, report.Total)
+	require.Equal(t, 3, report.DroppedEvents)
+}
+
+func TestEventsFiltered(t *testing.T) {
+	tailer := New(&Config{Out: &bytes.Buffer{}})
+	events := tailer.Events()
+
+	tailer.ProcessMessage(requestLogMessage(`{"request_id":"req_1","status":200,"url":"/v1/stripecli/sessions"}`))
+	tailer.ProcessMessage(requestLogMessage(`{"request_id":"req_2","status":200,"url":"/v1/charges"}`))
+
+	require.Equal(t, "req_2", (<-events).RequestID)
+	require.Empty(t, events)
+}
@@ -0,0 +1,430 @@
+package logtailing
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// statusRange is an inclusive range of HTTP status codes.
+type statusRange struct {
+	min int
+	max int
+}
+
+func (r statusRange) contains(status int) bool {
+	return status >= r.min && status <= r.max
+}
+
+// statusCategories maps the names accepted by FilterStatusCategory to the
+// status codes they cover.
+var statusCategories = map[string]statusRange{
+	"informational": {100, 199},
+	"success":       {200, 299},
+	"redirect":      {300, 399},
+	"client-error":  {400, 499},
+	"server-error":  {500, 599},
+	"error":         {400, 599},
+}
+
+// statusTexts maps the names accepted by FilterStatusText, derived from the
+// standard HTTP status texts (e.g. "Not Found" becomes "not-found"), to their
+// status code.
+var statusTexts = func() map[string]int {
+	texts := make(map[string]int)
+
+	for code := 100; code < 600; code++ {
+		if text := http.StatusText(code); text != "" {
+			texts[statusTextName(text)] = code
+		}
+	}
+
+	return texts
+}()
+
+func statusTextName(text string) string {
+	text = strings.NewReplacer("-", " ", "'", "").Replace(strings.ToLower(text))
+	return strings.Join(strings.Fields(text), "-")
+}
+
+// validate returns an error if any of the client-side filters can't be
+// applied.
+func (f *LogFilters) validate() error {
+	if f == nil {
+		return nil
+	}
+
+	for _, name := range f.FilterStatusCategory {
+		if _, ok := statusCategories[strings.ToLower(name)]; !ok {
+			return fmt.Errorf("unknown status category %q. Expected one of: %s", name, strings.Join(sortedKeys(statusCategories), ", "))
+		}
+	}
+
+	for _, name := range f.FilterStatusText {
+		if _, ok := statusTexts[strings.ToLower(name)]; !ok {
+			return fmt.Errorf("unknown status %q. Expected an HTTP status text such as not-found or too-many-requests", name)
+		}
+	}
+
+	return f.validateStatusBounds()
+}
+
+// validateStatusBounds returns an error if MinStatus or MaxStatus isn't a
+// valid status code, or if they don't form a range.
+func (f *LogFilters) validateStatusBounds() error {
+	if f.MinStatus != 0 && (f.MinStatus < 100 || f.MinStatus > 599) {
+		return fmt.Errorf("invalid minimum status %d. Expected a status code between 100 and 599", f.MinStatus)
+	}
+
+	if f.MaxStatus != 0 && (f.MaxStatus < 100 || f.MaxStatus > 599) {
+		return fmt.Errorf("invalid maximum status %d. Expected a status code between 100 and 599", f.MaxStatus)
+	}
+
+	if f.MinStatus != 0 && f.MaxStatus != 0 && f.MinStatus > f.MaxStatus {
+		return fmt.Errorf("minimum status %d is greater than maximum status %d", f.MinStatus, f.MaxStatus)
+	}
+
+	return nil
+}
+
+// matches reports whether the payload passes the filters that are applied
+// client-side. Filters that are sent to Stripe when creating the session are
+// not checked again here, except for FilterStatusCodeType which isn't
+// enforced by every backend.
+func (f *LogFilters) matches(payload *EventPayload) bool {
+	if f == nil {
+		return true
+	}
+
+	if len(f.FilterRequestID) > 0 && !containsString(f.FilterRequestID, payload.RequestID) {
+		return false
+	}
+
+	if len(f.FilterErrorCode) > 0 && !containsFold(f.FilterErrorCode, payload.Error.Code) {
+		return false
+	}
+
+	if len(f.FilterDeclineCode) > 0 && !containsFold(f.FilterDeclineCode, payload.Error.DeclineCode) {
+		return false
+	}
+
+	if (len(f.FilterStatusCategory) > 0 || len(f.FilterStatusText) > 0) && !f.matchesStatusName(payload.Status) {
+		return false
+	}
+
+	if len(f.FilterStatusCodeType) > 0 && !f.matchesStatusCodeType(payload.Status) {
+		return false
+	}
+
+	if f.MinStatus != 0 && payload.Status < f.MinStatus {
+		return false
+	}
+
+	if f.MaxStatus != 0 && payload.Status > f.MaxStatus {
+		return false
+	}
+
+	return true
+}
+
+// matchesStatusCodeType reports whether any value of FilterStatusCodeType
+// covers the status.
+func (f *LogFilters) matchesStatusCodeType(status int) bool {
+	for _, value := range f.FilterStatusCodeType {
+		if statusCodeTypeCovers(value, status) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// statusCodeTypeCovers reports whether a FilterStatusCodeType value covers
+// the status. Values are accepted both as given on the command line, e.g.
+// "4XX", and in the "400" form they're converted to for Stripe.
+func statusCodeTypeCovers(value string, status int) bool {
+	return strings.ReplaceAll(strings.ToUpper(value), "X", "0") == fmt.Sprintf("%d00", status/100)
+}
+
+// matched returns the names of the client-side filters' values that the
+// payload matched, e.g. "status-category=error", for debugging overly broad
+// filters. Overlapping values are all reported.
+func (f *LogFilters) matched(payload *EventPayload) []string {
+	if f == nil {
+		return nil
+	}
+
+	var names []string
+
+	for _, id := range f.FilterRequestID {
+		if id == payload.RequestID {
+			names = append(names, "request-id="+id)
+		}
+	}
+
+	for _, code := range f.FilterErrorCode {
+		if payload.Error.Code != "" && strings.EqualFold(code, payload.Error.Code) {
+			names = append(names, "error-code="+code)
+		}
+	}
+
+	for _, code := range f.FilterDeclineCode {
+		if payload.Error.DeclineCode != "" && strings.EqualFold(code, payload.Error.DeclineCode) {
+			names = append(names, "decline-code="+code)
+		}
+	}
+
+	for _, name := range f.FilterStatusCategory {
+		if statusCategories[strings.ToLower(name)].contains(payload.Status) {
+			names = append(names, "status-category="+name)
+		}
+	}
+
+	for _, name := range f.FilterStatusText {
+		if statusTexts[strings.ToLower(name)] == payload.Status {
+			names = append(names, "status-text="+name)
+		}
+	}
+
+	for _, value := range f.FilterStatusCodeType {
+		if statusCodeTypeCovers(value, payload.Status) {
+			names = append(names, "status-code-type="+value)
+		}
+	}
+
+	if f.MinStatus != 0 && payload.Status >= f.MinStatus {
+		names = append(names, fmt.Sprintf("min-status=%d", f.MinStatus))
+	}
+
+	if f.MaxStatus != 0 && payload.Status <= f.MaxStatus {
+		names = append(names, fmt.Sprintf("max-status=%d", f.MaxStatus))
+	}
+
+	return names
+}
+
+// hasError reports whether the request log failed, either with an error or
+// with a status of 400 and above.
+func hasError(payload *EventPayload) bool {
+	return payload.Error != (RedactedError{}) || payload.Status >= 400
+}
+
+// summary returns a concise description of the active filters, e.g.
+// "methods=[POST], status_type=[5XX]", leaving out the empty ones. It's empty
+// when no filter is set.
+func (f *LogFilters) summary() string {
+	if f == nil {
+		return ""
+	}
+
+	lists := []struct {
+		name   string
+		values []string
+	}{
+		{"accounts", f.FilterAccount},
+		{"ip_addresses", f.FilterIPAddress},
+		{"methods", f.FilterHTTPMethod},
+		{"paths", f.FilterRequestPath},
+		{"request_status", f.FilterRequestStatus},
+		{"sources", f.FilterSource},
+		{"status", f.FilterStatusCode},
+		{"status_type", f.FilterStatusCodeType},
+		{"request_ids", f.FilterRequestID},
+		{"error_codes", f.FilterErrorCode},
+		{"decline_codes", f.FilterDeclineCode},
+		{"status_category", f.FilterStatusCategory},
+		{"status_text", f.FilterStatusText},
+	}
+
+	var parts []string
+
+	for _, list := range lists {
+		if len(list.values) > 0 {
+			parts = append(parts, fmt.Sprintf("%s=[%s]", list.name, strings.Join(list.values, ", ")))
+		}
+	}
+
+	if f.MinStatus > 0 {
+		parts = append(parts, fmt.Sprintf("min_status=%d", f.MinStatus))
+	}
+
+	if f.MaxStatus > 0 {
+		parts = append(parts, fmt.Sprintf("max_status=%d", f.MaxStatus))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// printFilterSummary prints the active filters on Log.Out when ShowFilters
+// is set, so that an unexpectedly quiet or noisy tail can be explained.
+func (t *Tailer) printFilterSummary() {
+	if !t.cfg.ShowFilters || t.cfg.StrictJSON {
+		return
+	}
+
+	summary := t.cfg.Filters.summary()
+	if summary == "" {
+		summary = "none, all request logs are shown"
+	}
+
+	fmt.Fprintf(t.log.Out, "Filters: %s\n", summary)
+}
+
+// applyReconnectFilters replaces the filters with the ones returned by
+// OnReconnect, if any. Invalid filters are reported to OnError and the
+// current ones are kept.
+func (t *Tailer) applyReconnectFilters() {
+	if t.cfg.OnReconnect == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var current *LogFilters
+	if t.cfg.Filters != nil {
+		filters := *t.cfg.Filters
+		current = &filters
+	}
+
+	filters := t.cfg.OnReconnect(current)
+	if filters == nil {
+		return
+	}
+
+	if err := filters.validate(); err != nil {
+		t.onError(fmt.Errorf("keeping the current filters, OnReconnect returned invalid ones: %v", err))
+		return
+	}
+
+	t.cfg.Filters = filters
+}
+
+// mutating reports whether requests with the given method can change data.
+func mutating(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// stale reports whether the request log was created more than MaxAge ago.
+// Request logs without a creation time aren't stale.
+func (t *Tailer) stale(payload *EventPayload) bool {
+	if t.cfg.MaxAge <= 0 || payload.CreatedAt == 0 {
+		return false
+	}
+
+	return time.Unix(int64(payload.CreatedAt), 0).Before(t.cfg.Now().Add(-t.cfg.MaxAge))
+}
+
+// validateForOutput returns an error if the filters can't be applied to the
+// request logs of a single output, which only has the request log payload to
+// go on.
+func (f *LogFilters) validateForOutput() error {
+	if f == nil {
+		return nil
+	}
+
+	switch {
+	case len(f.FilterAccount) > 0:
+		return fmt.Errorf("the account filter can't be applied per output")
+	case len(f.FilterIPAddress) > 0:
+		return fmt.Errorf("the IP address filter can't be applied per output")
+	case len(f.FilterSource) > 0:
+		return fmt.Errorf("the source filter can't be applied per output")
+	}
+
+	return f.validate()
+}
+
+// matchesPayload reports whether the payload passes all of the filters that
+// can be checked against a request log payload, including the ones that are
+// otherwise sent to Stripe.
+func (f *LogFilters) matchesPayload(payload *EventPayload) bool {
+	if f == nil {
+		return true
+	}
+
+	if len(f.FilterHTTPMethod) > 0 && !containsFold(f.FilterHTTPMethod, payload.Method) {
+		return false
+	}
+
+	if len(f.FilterRequestPath) > 0 && !containsString(f.FilterRequestPath, stripQuery(payload.URL)) {
+		return false
+	}
+
+	if len(f.FilterRequestStatus) > 0 && !containsFold(f.FilterRequestStatus, requestStatus(payload.Status)) {
+		return false
+	}
+
+	if len(f.FilterStatusCode) > 0 && !containsString(f.FilterStatusCode, strconv.Itoa(payload.Status)) {
+		return false
+	}
+
+	return f.matches(payload)
+}
+
+// requestStatus returns the value of FilterRequestStatus that covers the
+// status.
+func requestStatus(status int) string {
+	if status >= 200 && status <= 202 {
+		return "SUCCEEDED"
+	}
+
+	return "FAILED"
+}
+
+// matchesStatusName reports whether the status is covered by any of the
+// named status categories or texts.
+func (f *LogFilters) matchesStatusName(status int) bool {
+	for _, name := range f.FilterStatusCategory {
+		if statusCategories[strings.ToLower(name)].contains(status) {
+			return true
+		}
+	}
+
+	for _, name := range f.FilterStatusText {
+		if statusTexts[strings.ToLower(name)] == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sortedKeys(m map[string]statusRange) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
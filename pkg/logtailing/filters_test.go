@@ -0,0 +1,549 @@
+package logtailing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/stripeauth"
+)
+
+func TestMatchesNilFilters(t *testing.T) {
+	var filters *LogFilters
+	require.True(t, filters.matches(&EventPayload{RequestID: "req_123"}))
+}
+
+func TestMatchesFilterRequestID(t *testing.T) {
+	filters := &LogFilters{
+		FilterRequestID: []string{"req_123", "req_456"},
+	}
+
+	require.True(t, filters.matches(&EventPayload{RequestID: "req_123"}))
+	require.True(t, filters.matches(&EventPayload{RequestID: "req_456"}))
+	require.False(t, filters.matches(&EventPayload{RequestID: "req_789"}))
+	require.False(t, filters.matches(&EventPayload{RequestID: "req_1234"}))
+}
+
+func TestJsonifyFiltersOmitsClientSideFilters(t *testing.T) {
+	filters := &LogFilters{
+		FilterHTTPMethod: []string{"POST"},
+		FilterRequestID:  []string{"req_123"},
+	}
+	filtersStr, err := jsonifyFilters(filters)
+	require.NoError(t, err)
+	require.Equal(t, `{"filter_http_method":["POST"]}`, filtersStr)
+}
+
+func TestStatusTextNames(t *testing.T) {
+	require.Equal(t, 404, statusTexts["not-found"])
+	require.Equal(t, 402, statusTexts["payment-required"])
+	require.Equal(t, 429, statusTexts["too-many-requests"])
+	require.Equal(t, 500, statusTexts["internal-server-error"])
+	require.Equal(t, 418, statusTexts["im-a-teapot"])
+	require.Equal(t, 207, statusTexts["multi-status"])
+}
+
+func TestMatchesFilterStatusCategory(t *testing.T) {
+	filters := &LogFilters{
+		FilterStatusCategory: []string{"server-error", "Redirect"},
+	}
+	require.NoError(t, filters.validate())
+
+	require.True(t, filters.matches(&EventPayload{Status: 500}))
+	require.True(t, filters.matches(&EventPayload{Status: 503}))
+	require.True(t, filters.matches(&EventPayload{Status: 302}))
+	require.False(t, filters.matches(&EventPayload{Status: 200}))
+	require.False(t, filters.matches(&EventPayload{Status: 404}))
+}
+
+func TestMatchesFilterStatusText(t *testing.T) {
+	filters := &LogFilters{
+		FilterStatusText: []string{"not-found", "too-many-requests"},
+	}
+	require.NoError(t, filters.validate())
+
+	require.True(t, filters.matches(&EventPayload{Status: 404}))
+	require.True(t, filters.matches(&EventPayload{Status: 429}))
+	require.False(t, filters.matches(&EventPayload{Status: 400}))
+}
+
+func TestMatchesFilterStatusCategoryAndText(t *testing.T) {
+	filters := &LogFilters{
+		FilterStatusCategory: []string{"success"},
+		FilterStatusText:     []string{"not-found"},
+	}
+
+	require.True(t, filters.matches(&EventPayload{Status: 201}))
+	require.True(t, filters.matches(&EventPayload{Status: 404}))
+	require.False(t, filters.matches(&EventPayload{Status: 500}))
+}
+
+func TestMatchesFilterDeclineCode(t *testing.T) {
+	filters := &LogFilters{
+		FilterDeclineCode: []string{"insufficient_funds", "lost_card"},
+	}
+
+	require.True(t, filters.matches(&EventPayload{Status: 402, Error: RedactedError{Code: "card_declined", DeclineCode: "insufficient_funds"}}))
+	require.True(t, filters.matches(&EventPayload{Status: 402, Error: RedactedError{Code: "card_declined", DeclineCode: "LOST_CARD"}}))
+	require.False(t, filters.matches(&EventPayload{Status: 402, Error: RedactedError{Code: "card_declined", DeclineCode: "do_not_honor"}}))
+	require.False(t, filters.matches(&EventPayload{Status: 400, Error: RedactedError{Code: "parameter_missing"}}))
+	require.False(t, filters.matches(&EventPayload{Status: 200}))
+}
+
+func TestMatchesFilterErrorCode(t *testing.T) {
+	filters := &LogFilters{
+		FilterErrorCode:   []string{"card_declined", "expired_card"},
+		FilterDeclineCode: []string{"insufficient_funds"},
+	}
+
+	require.True(t, filters.matches(&EventPayload{Status: 402, Error: RedactedError{Code: "card_declined", DeclineCode: "insufficient_funds"}}))
+	require.False(t, filters.matches(&EventPayload{Status: 402, Error: RedactedError{Code: "expired_card"}}))
+	require.False(t, filters.matches(&EventPayload{Status: 400, Error: RedactedError{Code: "parameter_missing", DeclineCode: "insufficient_funds"}}))
+
+	filters.FilterDeclineCode = nil
+	require.True(t, filters.matches(&EventPayload{Status: 402, Error: RedactedError{Code: "expired_card"}}))
+	require.Equal(t, []string{"error-code=expired_card"}, filters.matched(&EventPayload{Status: 402, Error: RedactedError{Code: "expired_card"}}))
+}
+
+func TestValidateUnknownStatusNames(t *testing.T) {
+	filters := &LogFilters{FilterStatusCategory: []string{"server-errors"}}
+	require.EqualError(t, filters.validate(), `unknown status category "server-errors". Expected one of: client-error, error, informational, redirect, server-error, success`)
+
+	filters = &LogFilters{FilterStatusText: []string{"lost"}}
+	require.EqualError(t, filters.validate(), `unknown status "lost". Expected an HTTP status text such as not-found or too-many-requests`)
+}
+
+func TestRunRejectsUnknownStatusNames(t *testing.T) {
+	tailer := New(&Config{Filters: &LogFilters{FilterStatusCategory: []string{"bogus"}}})
+
+	err := tailer.Run(context.Background())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `unknown status category "bogus"`)
+}
+
+func TestMatchesPayload(t *testing.T) {
+	payload := &EventPayload{Method: "POST", Status: 402, URL: "/v1/charges?expand[]=customer"}
+
+	tests := []struct {
+		filters *LogFilters
+		match   bool
+	}{
+		{nil, true},
+		{&LogFilters{FilterHTTPMethod: []string{"post"}}, true},
+		{&LogFilters{FilterHTTPMethod: []string{"GET"}}, false},
+		{&LogFilters{FilterRequestPath: []string{"/v1/charges"}}, true},
+		{&LogFilters{FilterRequestPath: []string{"/v1/customers"}}, false},
+		{&LogFilters{FilterRequestStatus: []string{"FAILED"}}, true},
+		{&LogFilters{FilterRequestStatus: []string{"SUCCEEDED"}}, false},
+		{&LogFilters{FilterStatusCode: []string{"400", "402"}}, true},
+		{&LogFilters{FilterStatusCode: []string{"500"}}, false},
+		{&LogFilters{FilterStatusCodeType: []string{"4XX"}}, true},
+		{&LogFilters{FilterStatusCodeType: []string{"2XX"}}, false},
+		{&LogFilters{FilterStatusCodeType: []string{"400"}}, true},
+		{&LogFilters{FilterStatusCodeType: []string{"200"}}, false},
+		{&LogFilters{FilterStatusText: []string{"payment-required"}}, true},
+		{&LogFilters{FilterHTTPMethod: []string{"POST"}, FilterStatusCategory: []string{"server-error"}}, false},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.match, test.filters.matchesPayload(payload), "%+v", test.filters)
+	}
+}
+
+func TestExcludeExactPathsDefault(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{NoColor: true, Out: &buf})
+	require.Equal(t, DefaultExcludeExactPaths, tailer.cfg.ExcludeExactPaths)
+
+	// The defaults are copied, so that changing the config leaves them as is
+	tailer.cfg.ExcludeExactPaths[0] = "/v1/tokens"
+	require.Equal(t, []string{"/v1/stripecli/sessions"}, DefaultExcludeExactPaths)
+	tailer.cfg.ExcludeExactPaths[0] = "/v1/stripecli/sessions"
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":200,"url":"/v1/stripecli/sessions"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":200,"url":"/v1/stripecli/sessions/abc"}`))
+
+	require.NotContains(t, buf.String(), "/v1/stripecli/sessions\n")
+	require.Contains(t, buf.String(), "/v1/stripecli/sessions/abc")
+}
+
+func TestExcludeExactPathsUserAdded(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{
+		ExcludeExactPaths: append([]string{"/v1/tokens"}, DefaultExcludeExactPaths...),
+		NoColor:           true,
+		Out:               &buf,
+	})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":200,"url":"/v1/tokens?card=tok_visa"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":200,"url":"/v1/stripecli/sessions"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":200,"url":"/v1/charges"}`))
+
+	require.Equal(t, 1, strings.Count(buf.String(), "\n"))
+	require.Contains(t, buf.String(), "/v1/charges")
+}
+
+func TestExcludeExactPathsCleared(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{ExcludeExactPaths: []string{}, NoColor: true, Out: &buf})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":200,"url":"/v1/stripecli/sessions"}`))
+
+	require.Contains(t, buf.String(), "/v1/stripecli/sessions")
+}
+
+func TestMatchesStatusBounds(t *testing.T) {
+	filters := &LogFilters{MinStatus: 400, MaxStatus: 499}
+
+	require.True(t, filters.matches(&EventPayload{Status: 400}))
+	require.True(t, filters.matches(&EventPayload{Status: 429}))
+	require.True(t, filters.matches(&EventPayload{Status: 499}))
+	require.False(t, filters.matches(&EventPayload{Status: 399}))
+	require.False(t, filters.matches(&EventPayload{Status: 500}))
+
+	minOnly := &LogFilters{MinStatus: 500}
+	require.True(t, minOnly.matches(&EventPayload{Status: 503}))
+	require.False(t, minOnly.matches(&EventPayload{Status: 200}))
+
+	maxOnly := &LogFilters{MaxStatus: 299}
+	require.True(t, maxOnly.matches(&EventPayload{Status: 201}))
+	require.False(t, maxOnly.matches(&EventPayload{Status: 302}))
+}
+
+func TestValidateStatusBounds(t *testing.T) {
+	require.NoError(t, (&LogFilters{MinStatus: 400, MaxStatus: 400}).validate())
+	require.NoError(t, (&LogFilters{MaxStatus: 599}).validate())
+
+	require.EqualError(t, (&LogFilters{MinStatus: 500, MaxStatus: 400}).validate(), "minimum status 500 is greater than maximum status 400")
+	require.EqualError(t, (&LogFilters{MinStatus: 99}).validate(), "invalid minimum status 99. Expected a status code between 100 and 599")
+	require.EqualError(t, (&LogFilters{MaxStatus: 600}).validate(), "invalid maximum status 600. Expected a status code between 100 and 599")
+}
+
+func TestRunRejectsInvalidStatusBounds(t *testing.T) {
+	tailer := New(&Config{Filters: &LogFilters{MinStatus: 499, MaxStatus: 400}})
+
+	err := tailer.Run(context.Background())
+	require.EqualError(t, err, "minimum status 499 is greater than maximum status 400")
+}
+
+func TestMatchedFilters(t *testing.T) {
+	filters := &LogFilters{
+		FilterStatusCategory: []string{"error", "client-error"},
+		FilterStatusText:     []string{"not-found"},
+		MinStatus:            400,
+	}
+
+	require.Equal(t, []string{"status-category=error", "status-category=client-error", "status-text=not-found", "min-status=400"}, filters.matched(&EventPayload{Status: 404}))
+	require.Equal(t, []string{"status-category=error", "min-status=400"}, filters.matched(&EventPayload{Status: 503}))
+	require.Nil(t, (*LogFilters)(nil).matched(&EventPayload{Status: 200}))
+
+	// Status code types are matched in either form
+	filters = &LogFilters{FilterStatusCodeType: []string{"4XX", "500"}}
+	require.Equal(t, []string{"status-code-type=4XX"}, filters.matched(&EventPayload{Status: 404}))
+	require.Equal(t, []string{"status-code-type=500"}, filters.matched(&EventPayload{Status: 503}))
+}
+
+func TestShowMatchedFilter(t *testing.T) {
+	var text, jsonBuf bytes.Buffer
+
+	tailer := New(&Config{
+		Filters:           &LogFilters{FilterStatusCategory: []string{"error", "server-error"}},
+		NoColor:           true,
+		Out:               &text,
+		Outputs:           []Output{{Out: &jsonBuf, Format: "JSON"}},
+		ShowMatchedFilter: true,
+	})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":402,"url":"/v1/charges","request_id":"req_1"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":500,"url":"/v1/charges","request_id":"req_2"}`))
+
+	lines := strings.Split(strings.TrimSpace(text.String()), "\n")
+	require.Len(t, lines, 2)
+	require.True(t, strings.HasSuffix(lines[0], "[req_1] (matched status-category=error)"), lines[0])
+	require.True(t, strings.HasSuffix(lines[1], "[req_2] (matched status-category=error, status-category=server-error)"), lines[1])
+
+	var envelopes []Envelope
+
+	for _, line := range strings.Split(strings.TrimSpace(jsonBuf.String()), "\n") {
+		var envelope Envelope
+		require.NoError(t, json.Unmarshal([]byte(line), &envelope))
+		envelopes = append(envelopes, envelope)
+	}
+
+	require.Equal(t, []string{"status-category=error"}, envelopes[0].MatchedFilters)
+	require.Equal(t, []string{"status-category=error", "status-category=server-error"}, envelopes[1].MatchedFilters)
+}
+
+func TestFilterStatusCodeTypeClientSide(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{
+		Filters: &LogFilters{FilterStatusCodeType: []string{"2XX", "4xx"}},
+		NoColor: true,
+		Out:     &buf,
+	})
+
+	for i, status := range []int{200, 302, 404, 500, 201} {
+		tailer.processRequestLogEvent(requestLogMessage(fmt.Sprintf(`{"method":"GET","status":%d,"url":"/v1/charges","request_id":"req_%d"}`, status, i)))
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	require.Contains(t, lines[0], "[200]")
+	require.Contains(t, lines[1], "[404]")
+	require.Contains(t, lines[2], "[201]")
+}
+
+func TestFilterStatusCodeTypeStillSentToStripe(t *testing.T) {
+	tailer := New(&Config{Filters: &LogFilters{FilterStatusCodeType: []string{"2XX"}}})
+
+	filters, err := tailer.FiltersJSON()
+	require.NoError(t, err)
+	require.JSONEq(t, `{"filter_status_code_type":["2XX"]}`, filters)
+
+	require.True(t, tailer.cfg.Filters.matches(&EventPayload{Status: 204}))
+	require.False(t, tailer.cfg.Filters.matches(&EventPayload{Status: 503}))
+}
+
+func TestErrorsOnly(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{ErrorsOnly: true, NoColor: true, Out: &buf})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_1"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":200,"url":"/v1/charges","request_id":"req_2","error":{"code":"card_declined"}}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":404,"url":"/v1/charges/ch_1","request_id":"req_3"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":302,"url":"/v1/checkout","request_id":"req_4"}`))
+
+	output := buf.String()
+	require.NotContains(t, output, "req_1")
+	require.Contains(t, output, "[req_2]")
+	require.Contains(t, output, "[req_3]")
+	require.NotContains(t, output, "req_4")
+}
+
+func TestErrorsOnlyWithStatusFilters(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{
+		ErrorsOnly: true,
+		Filters:    &LogFilters{FilterStatusCategory: []string{"success"}},
+		NoColor:    true,
+		Out:        &buf,
+	})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"request_id":"req_1"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":200,"request_id":"req_2","error":{"message":"Partially failed"}}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":500,"request_id":"req_3"}`))
+
+	require.NotContains(t, buf.String(), "req_1")
+	require.Contains(t, buf.String(), "[req_2]")
+	require.NotContains(t, buf.String(), "req_3")
+}
+
+func TestHasError(t *testing.T) {
+	require.False(t, hasError(&EventPayload{Status: 201}))
+	require.True(t, hasError(&EventPayload{Status: 201, Error: RedactedError{Param: "amount"}}))
+	require.True(t, hasError(&EventPayload{Status: 429}))
+}
+
+func TestMaxAge(t *testing.T) {
+	clock := newFakeClock()
+
+	var buf bytes.Buffer
+
+	tailer := New(&Config{MaxAge: time.Hour, NoColor: true, Now: clock.Now, Out: &buf, RecentSize: 10})
+
+	now := clock.Now().Unix()
+
+	tailer.processRequestLogEvent(requestLogMessage(fmt.Sprintf(`{"created_at":%d,"method":"GET","status":200,"url":"/v1/customers","request_id":"req_old"}`, now-7200)))
+	tailer.processRequestLogEvent(requestLogMessage(fmt.Sprintf(`{"created_at":%d,"method":"GET","status":200,"url":"/v1/customers","request_id":"req_recent"}`, now-60)))
+	tailer.processRequestLogEvent(requestLogMessage(fmt.Sprintf(`{"created_at":%d,"method":"GET","status":200,"url":"/v1/customers","request_id":"req_new"}`, now)))
+
+	require.Equal(t, []string{"req_recent", "req_new"}, recentRequestIDs(tailer.Recent()))
+	require.NotContains(t, buf.String(), "req_old")
+
+	// The cutoff follows the clock
+	clock.Advance(time.Hour)
+
+	tailer.processRequestLogEvent(requestLogMessage(fmt.Sprintf(`{"created_at":%d,"method":"GET","status":200,"url":"/v1/customers","request_id":"req_later"}`, now-60)))
+	require.Equal(t, []string{"req_recent", "req_new"}, recentRequestIDs(tailer.Recent()))
+
+	// Request logs without a creation time are kept
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_undated"}`))
+	require.Equal(t, []string{"req_recent", "req_new", "req_undated"}, recentRequestIDs(tailer.Recent()))
+
+	// Disabled by default
+	tailer = New(&Config{NoColor: true, Now: clock.Now, Out: &buf, RecentSize: 10})
+	tailer.processRequestLogEvent(requestLogMessage(`{"created_at":1,"method":"GET","status":200,"url":"/v1/customers","request_id":"req_ancient"}`))
+	require.Equal(t, []string{"req_ancient"}, recentRequestIDs(tailer.Recent()))
+}
+
+func TestMutatingOnly(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{MutatingOnly: true, NoColor: true, Out: &buf, RecentSize: 10})
+
+	for i, method := range []string{"GET", "POST", "HEAD", "put", "OPTIONS", "PATCH", "DELETE"} {
+		tailer.processRequestLogEvent(requestLogMessage(fmt.Sprintf(`{"method":"%s","status":200,"url":"/v1/customers","request_id":"req_%d"}`, method, i)))
+	}
+
+	require.Equal(t, []string{"req_1", "req_3", "req_5", "req_6"}, recentRequestIDs(tailer.Recent()))
+	require.NotContains(t, buf.String(), "req_0")
+
+	// Disabled by default
+	tailer = New(&Config{NoColor: true, Out: &buf, RecentSize: 10})
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_get"}`))
+	require.Equal(t, []string{"req_get"}, recentRequestIDs(tailer.Recent()))
+}
+
+func TestOnReconnectFilters(t *testing.T) {
+	sent := make(chan string, 1)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		sent <- r.PostForm.Get("filters")
+
+		json.NewEncoder(w).Encode(stripeauth.StripeCLISession{WebSocketID: "websocket-123"})
+	}))
+	defer ts.Close()
+
+	var received *LogFilters
+
+	tailer := New(&Config{
+		APIBaseURL: ts.URL,
+		Filters:    &LogFilters{FilterHTTPMethod: []string{"GET", "POST"}},
+		Key:        "sk_test_123",
+		OnReconnect: func(filters *LogFilters) *LogFilters {
+			received = filters
+			return &LogFilters{FilterHTTPMethod: []string{"POST"}, FilterStatusCategory: []string{"error"}}
+		},
+	})
+
+	tailer.applyReconnectFilters()
+
+	// The callback gets a copy of the current filters
+	require.Equal(t, []string{"GET", "POST"}, received.FilterHTTPMethod)
+	require.NotSame(t, received, tailer.cfg.Filters)
+
+	_, err := tailer.createSession(context.Background(), "request_logs")
+	require.NoError(t, err)
+
+	var filters LogFilters
+	require.NoError(t, json.Unmarshal([]byte(<-sent), &filters))
+	require.Equal(t, []string{"POST"}, filters.FilterHTTPMethod)
+
+	// Client-side filters apply as well
+	require.Equal(t, []string{"error"}, tailer.cfg.Filters.FilterStatusCategory)
+}
+
+func TestOnReconnectKeepsFilters(t *testing.T) {
+	current := &LogFilters{FilterHTTPMethod: []string{"GET"}}
+
+	var reported []error
+
+	tailer := New(&Config{
+		Filters:     current,
+		OnError:     func(err error) { reported = append(reported, err) },
+		OnReconnect: func(*LogFilters) *LogFilters { return nil },
+	})
+
+	// Returning nil keeps the current filters
+	tailer.applyReconnectFilters()
+	require.Same(t, current, tailer.cfg.Filters)
+	require.Empty(t, reported)
+
+	// So do invalid filters
+	tailer.cfg.OnReconnect = func(*LogFilters) *LogFilters {
+		return &LogFilters{FilterStatusCategory: []string{"teapot"}}
+	}
+
+	tailer.applyReconnectFilters()
+	require.Same(t, current, tailer.cfg.Filters)
+	require.Len(t, reported, 1)
+	require.Contains(t, reported[0].Error(), `unknown status category "teapot"`)
+}
+
+func TestFiltersJSONDuringReconnect(t *testing.T) {
+	tailer := New(&Config{
+		Filters: &LogFilters{FilterHTTPMethod: []string{"GET"}},
+		OnReconnect: func(*LogFilters) *LogFilters {
+			return &LogFilters{FilterHTTPMethod: []string{"POST"}}
+		},
+	})
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 100; i++ {
+			tailer.applyReconnectFilters()
+		}
+	}()
+
+	// Run with -race to catch unsynchronized reads of the filters
+	for i := 0; i < 100; i++ {
+		filters, err := tailer.FiltersJSON()
+		require.NoError(t, err)
+		require.Contains(t, filters, "filter_http_method")
+	}
+
+	<-done
+}
+
+func TestFilterSummary(t *testing.T) {
+	filters := &LogFilters{
+		FilterHTTPMethod:     []string{"POST"},
+		FilterIPAddress:      []string{},
+		FilterStatusCodeType: []string{"5XX"},
+		FilterStatusCategory: []string{"error"},
+		MinStatus:            500,
+	}
+
+	require.Equal(t, "methods=[POST], status_type=[5XX], status_category=[error], min_status=500", filters.summary())
+	require.Empty(t, (&LogFilters{}).summary())
+	require.Empty(t, (*LogFilters)(nil).summary())
+}
+
+func TestPrintFilterSummary(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{
+		Filters:     &LogFilters{FilterHTTPMethod: []string{"GET", "POST"}, FilterRequestPath: []string{"/v1/charges"}},
+		Log:         &log.Logger{Out: &buf},
+		ShowFilters: true,
+	})
+
+	tailer.printFilterSummary()
+	require.Equal(t, "Filters: methods=[GET, POST], paths=[/v1/charges]\n", buf.String())
+
+	buf.Reset()
+
+	tailer = New(&Config{Log: &log.Logger{Out: &buf}, ShowFilters: true})
+	tailer.printFilterSummary()
+	require.Equal(t, "Filters: none, all request logs are shown\n", buf.String())
+
+	buf.Reset()
+
+	// Suppressed in strict JSON mode and by default
+	tailer = New(&Config{Log: &log.Logger{Out: &buf}, OutputFormat: outputFormatJSON, ShowFilters: true, StrictJSON: true})
+	tailer.printFilterSummary()
+
+	tailer = New(&Config{Filters: &LogFilters{FilterHTTPMethod: []string{"GET"}}, Log: &log.Logger{Out: &buf}})
+	tailer.printFilterSummary()
+
+	require.Empty(t, buf.String())
+}
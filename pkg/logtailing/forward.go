@@ -0,0 +1,153 @@
+package logtailing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	forwardSinkBufferSize   = 1000
+	forwardSinkFlushTimeout = 5 * time.Second
+)
+
+// forwardSink POSTs events to an HTTP endpoint as JSON arrays. Events are
+// buffered until either the batch size or the flush interval is reached, and
+// whatever is left is flushed on shutdown.
+type forwardSink struct {
+	url           string
+	batchSize     int
+	flushInterval time.Duration
+
+	client *http.Client
+	log    *log.Logger
+
+	events chan json.RawMessage
+	done   chan struct{}
+}
+
+func newForwardSink(url string, batchSize int, flushInterval time.Duration, logger *log.Logger) *forwardSink {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+
+	return &forwardSink{
+		url:           url,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		client:        &http.Client{Timeout: forwardSinkFlushTimeout},
+		log:           logger,
+		events:        make(chan json.RawMessage, forwardSinkBufferSize),
+		done:          make(chan struct{}),
+	}
+}
+
+// write queues a JSON event for forwarding. The event is dropped if the
+// queue is full.
+func (f *forwardSink) write(payload string) {
+	if !json.Valid([]byte(payload)) {
+		f.log.WithFields(log.Fields{
+			"prefix": "logtailing.forwardSink.write",
+		}).Debug("Not forwarding malformed payload")
+
+		return
+	}
+
+	select {
+	case f.events <- json.RawMessage(payload):
+	default:
+		f.log.WithFields(log.Fields{
+			"prefix": "logtailing.forwardSink.write",
+		}).Debug("Forwarding queue is full, dropping event")
+	}
+}
+
+// run batches and forwards queued events until ctx is canceled, then flushes
+// the remaining events and closes the done channel.
+func (f *forwardSink) run(ctx context.Context) {
+	defer close(f.done)
+
+	var ticks <-chan time.Time
+
+	if f.flushInterval > 0 {
+		ticker := time.NewTicker(f.flushInterval)
+		defer ticker.Stop()
+
+		ticks = ticker.C
+	}
+
+	batch := make([]json.RawMessage, 0, f.batchSize)
+
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case evt := <-f.events:
+					batch = append(batch, evt)
+				default:
+					f.flush(batch)
+					return
+				}
+			}
+		case evt := <-f.events:
+			batch = append(batch, evt)
+			if len(batch) >= f.batchSize {
+				f.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticks:
+			f.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+// wait blocks until run has returned and the final batch has been flushed.
+func (f *forwardSink) wait() {
+	<-f.done
+}
+
+func (f *forwardSink) flush(batch []json.RawMessage) {
+	if len(batch) == 0 {
+		return
+	}
+
+	if err := f.post(batch); err != nil {
+		f.log.WithFields(log.Fields{
+			"prefix": "logtailing.forwardSink.flush",
+			"url":    f.url,
+		}).Debug("Failed to forward events: ", err)
+	}
+}
+
+func (f *forwardSink) post(batch []json.RawMessage) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, f.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package logtailing
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newForwardServer(t *testing.T) (*httptest.Server, chan []json.RawMessage) {
+	batches := make(chan []json.RawMessage, 10)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var batch []json.RawMessage
+		require.NoError(t, json.Unmarshal(body, &batch))
+
+		batches <- batch
+	}))
+
+	return ts, batches
+}
+
+func receiveBatch(t *testing.T, batches chan []json.RawMessage) []json.RawMessage {
+	select {
+	case batch := <-batches:
+		return batch
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "Timed out waiting for forwarded events")
+	}
+
+	return nil
+}
+
+func TestForwardBatchesBySize(t *testing.T) {
+	ts, batches := newForwardServer(t)
+	defer ts.Close()
+
+	tailer := New(&Config{ForwardURL: ts.URL, ForwardBatchSize: 2, Out: ioutil.Discard})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go tailer.forwarder.run(ctx)
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"request_id":"req_1"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"request_id":"req_2"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"request_id":"req_3"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"request_id":"req_4"}`))
+
+	batch := receiveBatch(t, batches)
+	require.Len(t, batch, 2)
+	require.JSONEq(t, `{"request_id":"req_1"}`, string(batch[0]))
+	require.JSONEq(t, `{"request_id":"req_2"}`, string(batch[1]))
+
+	batch = receiveBatch(t, batches)
+	require.Len(t, batch, 2)
+	require.JSONEq(t, `{"request_id":"req_3"}`, string(batch[0]))
+	require.JSONEq(t, `{"request_id":"req_4"}`, string(batch[1]))
+}
+
+func TestForwardBatchesByInterval(t *testing.T) {
+	ts, batches := newForwardServer(t)
+	defer ts.Close()
+
+	tailer := New(&Config{
+		ForwardURL:           ts.URL,
+		ForwardBatchSize:     100,
+		ForwardFlushInterval: 50 * time.Millisecond,
+		Out:                  ioutil.Discard,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go tailer.forwarder.run(ctx)
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"request_id":"req_1"}`))
+
+	batch := receiveBatch(t, batches)
+	require.Len(t, batch, 1)
+	require.JSONEq(t, `{"request_id":"req_1"}`, string(batch[0]))
+}
+
+func TestForwardFlushesOnShutdown(t *testing.T) {
+	ts, batches := newForwardServer(t)
+	defer ts.Close()
+
+	tailer := New(&Config{ForwardURL: ts.URL, ForwardBatchSize: 100, Out: ioutil.Discard})
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go tailer.forwarder.run(ctx)
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"request_id":"req_1"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"request_id":"req_2"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"request_id":"req_3"}`))
+
+	cancel()
+	tailer.forwarder.wait()
+
+	batch := receiveBatch(t, batches)
+	require.Len(t, batch, 3)
+}
@@ -0,0 +1,39 @@
+package logtailing
+
+import (
+	"fmt"
+	"time"
+)
+
+// checkGap compares the creation time of a request log with the latest one
+// seen so far, and reports a possible loss of request logs when it's older
+// by more than GapThreshold, or when it's newer by more than GapThreshold
+// beyond the time that elapsed between their arrivals. t.mu must be held.
+func (t *Tailer) checkGap(payload *EventPayload) {
+	if t.cfg.GapThreshold <= 0 || payload.CreatedAt == 0 {
+		return
+	}
+
+	now := t.cfg.Now()
+
+	if t.maxCreatedAt == 0 {
+		t.maxCreatedAt = payload.CreatedAt
+		t.maxCreatedArrival = now
+
+		return
+	}
+
+	jump := time.Duration(payload.CreatedAt-t.maxCreatedAt) * time.Second
+
+	switch {
+	case jump < -t.cfg.GapThreshold:
+		t.warn(fmt.Errorf("request log %s was created %v before the latest one received, request logs may be delayed or out of order", payload.RequestID, -jump))
+	case jump-now.Sub(t.maxCreatedArrival) > t.cfg.GapThreshold:
+		t.warn(fmt.Errorf("request log %s was created %v after the latest one received, request logs may have been lost", payload.RequestID, jump))
+	}
+
+	if jump > 0 {
+		t.maxCreatedAt = payload.CreatedAt
+		t.maxCreatedArrival = now
+	}
+}
@@ -0,0 +1,96 @@
+package logtailing
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newGapTailer(clock *fakeClock, errs *[]error) *Tailer {
+	return New(&Config{
+		GapThreshold: 30 * time.Second,
+		Now:          clock.Now,
+		OnError:      func(err error) { *errs = append(*errs, err) },
+		Out:          &bytes.Buffer{},
+	})
+}
+
+func gapMessage(requestID string, createdAt int64) string {
+	return fmt.Sprintf(`{"created_at":%d,"request_id":"%s","status":200}`, createdAt, requestID)
+}
+
+func TestGapTimeOrdered(t *testing.T) {
+	clock := newFakeClock()
+	created := clock.Now().Unix()
+
+	var errs []error
+	tailer := newGapTailer(clock, &errs)
+
+	for i := 0; i < 5; i++ {
+		tailer.ProcessMessage(requestLogMessage(gapMessage(fmt.Sprintf("req_%d", i), created)))
+
+		clock.Advance(10 * time.Second)
+		created += 10
+	}
+
+	// Out of order by less than the threshold
+	tailer.ProcessMessage(requestLogMessage(gapMessage("req_late", created-20)))
+
+	// Idle for a while
+	clock.Advance(time.Hour)
+	tailer.ProcessMessage(requestLogMessage(gapMessage("req_idle", created+3600)))
+
+	require.Empty(t, errs)
+}
+
+func TestGapBackwardJump(t *testing.T) {
+	clock := newFakeClock()
+	created := clock.Now().Unix()
+
+	var errs []error
+	tailer := newGapTailer(clock, &errs)
+
+	tailer.ProcessMessage(requestLogMessage(gapMessage("req_1", created)))
+	tailer.ProcessMessage(requestLogMessage(gapMessage("req_2", created-120)))
+
+	require.Len(t, errs, 1)
+	require.Contains(t, errs[0].Error(), "req_2 was created 2m0s before the latest one")
+}
+
+func TestGapForwardJump(t *testing.T) {
+	clock := newFakeClock()
+	created := clock.Now().Unix()
+
+	var errs []error
+	tailer := newGapTailer(clock, &errs)
+
+	tailer.ProcessMessage(requestLogMessage(gapMessage("req_1", created)))
+
+	clock.Advance(5 * time.Second)
+	tailer.ProcessMessage(requestLogMessage(gapMessage("req_2", created+300)))
+
+	require.Len(t, errs, 1)
+	require.Contains(t, errs[0].Error(), "req_2 was created 5m0s after the latest one")
+
+	// The jump moved the latest request log forward
+	clock.Advance(5 * time.Second)
+	tailer.ProcessMessage(requestLogMessage(gapMessage("req_3", created+305)))
+
+	require.Len(t, errs, 1)
+}
+
+func TestGapDisabled(t *testing.T) {
+	clock := newFakeClock()
+	created := clock.Now().Unix()
+
+	var errs []error
+	tailer := New(&Config{Now: clock.Now, OnError: func(err error) { errs = append(errs, err) }, Out: &bytes.Buffer{}})
+
+	tailer.ProcessMessage(requestLogMessage(gapMessage("req_1", created)))
+	tailer.ProcessMessage(requestLogMessage(gapMessage("req_2", created-3600)))
+
+	require.Empty(t, errs)
+}
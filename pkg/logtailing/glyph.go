@@ -0,0 +1,22 @@
+package logtailing
+
+import (
+	"fmt"
+
+	"github.com/logrusorgru/aurora"
+)
+
+const statusGlyph = "●"
+
+// formatStatusGlyph returns the glyph standing for the status class of a
+// request log when StatusGlyph is set. Only errors keep their code.
+func formatStatusGlyph(color aurora.Aurora, status int) string {
+	switch {
+	case status >= 400:
+		return fmt.Sprintf("%s (%d)", color.Red(statusGlyph), color.Red(status).Bold())
+	case status >= 300:
+		return color.Cyan(statusGlyph).String()
+	default:
+		return color.Green(statusGlyph).String()
+	}
+}
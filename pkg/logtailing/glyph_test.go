@@ -0,0 +1,40 @@
+package logtailing
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/logrusorgru/aurora"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatStatusGlyph(t *testing.T) {
+	plain := aurora.NewAurora(false)
+
+	require.Equal(t, "●", formatStatusGlyph(plain, 200))
+	require.Equal(t, "●", formatStatusGlyph(plain, 302))
+	require.Equal(t, "● (404)", formatStatusGlyph(plain, 404))
+	require.Equal(t, "● (503)", formatStatusGlyph(plain, 503))
+
+	color := aurora.NewAurora(true)
+
+	require.Equal(t, color.Green(statusGlyph).String(), formatStatusGlyph(color, 201))
+	require.Equal(t, color.Cyan(statusGlyph).String(), formatStatusGlyph(color, 304))
+	require.Equal(t, fmt.Sprintf("%s (%d)", color.Red(statusGlyph), color.Red(402).Bold()), formatStatusGlyph(color, 402))
+}
+
+func TestStatusGlyph(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{NoColor: true, Out: &buf, StatusGlyph: true})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_1"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":402,"url":"/v1/charges","request_id":"req_2"}`))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Contains(t, lines[0], " ● GET /v1/customers [req_1]")
+	require.NotContains(t, lines[0], "200")
+	require.Contains(t, lines[1], " ● (402) POST /v1/charges [req_2]")
+}
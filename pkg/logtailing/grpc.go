@@ -0,0 +1,270 @@
+package logtailing
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/http2"
+)
+
+const (
+	// grpcStreamMethod is the client-streaming RPC that request logs are
+	// sent on. The collector replies once the stream is closed.
+	grpcStreamMethod = "/stripe.cli.logtailing.v1.EventCollector/StreamEvents"
+
+	grpcSinkBufferSize   = 1000
+	grpcSinkMinBackoff   = 100 * time.Millisecond
+	grpcSinkMaxBackoff   = 5 * time.Second
+	grpcSinkCloseTimeout = 5 * time.Second
+)
+
+// grpcSink streams request logs to a gRPC collector as protobuf Event
+// messages. Events are queued so that a slow or unavailable collector never
+// blocks the console output, and the stream is reopened with backoff if it
+// fails.
+type grpcSink struct {
+	url    string
+	client *http.Client
+	log    *log.Logger
+
+	events chan []byte
+	done   chan struct{}
+
+	// stream is the open RPC, only used by run
+	stream *grpcStream
+}
+
+// grpcStream is an open streaming RPC.
+type grpcStream struct {
+	body *io.PipeWriter
+
+	// closed is closed once the RPC has completed
+	closed chan struct{}
+}
+
+func newGRPCSink(target string, logger *log.Logger) *grpcSink {
+	url := target
+	if !strings.Contains(target, "://") {
+		url = "http://" + target
+	}
+
+	transport := &http2.Transport{}
+
+	if strings.HasPrefix(url, "http://") {
+		// Plaintext HTTP/2 without TLS (h2c)
+		transport.AllowHTTP = true
+		transport.DialTLS = func(network, addr string, cfg *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		}
+	}
+
+	return &grpcSink{
+		url:    strings.TrimSuffix(url, "/") + grpcStreamMethod,
+		client: &http.Client{Transport: transport},
+		log:    logger,
+		events: make(chan []byte, grpcSinkBufferSize),
+		done:   make(chan struct{}),
+	}
+}
+
+// write queues a request log for delivery. The event is dropped if the
+// queue is full.
+func (g *grpcSink) write(payload *EventPayload) {
+	select {
+	case g.events <- encodeEvent(payload):
+	default:
+		g.log.WithFields(log.Fields{
+			"prefix": "logtailing.grpcSink.write",
+		}).Debug("gRPC queue is full, dropping event")
+	}
+}
+
+// run delivers queued events until ctx is canceled, then sends the events
+// left in the queue, closes the stream and closes the done channel once the
+// collector has replied.
+func (g *grpcSink) run(ctx context.Context) {
+	defer close(g.done)
+
+	defer func() {
+		if g.stream != nil {
+			g.stream.close()
+		}
+	}()
+
+	backoff := grpcSinkMinBackoff
+
+	for {
+		var message []byte
+
+		select {
+		case <-ctx.Done():
+			g.drain()
+			return
+		case message = <-g.events:
+		}
+
+		for {
+			err := g.send(message)
+			if err == nil {
+				backoff = grpcSinkMinBackoff
+				break
+			}
+
+			g.log.WithFields(log.Fields{
+				"prefix": "logtailing.grpcSink.run",
+				"url":    g.url,
+			}).Debug("gRPC stream error, reconnecting: ", err)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+
+			backoff *= 2
+			if backoff > grpcSinkMaxBackoff {
+				backoff = grpcSinkMaxBackoff
+			}
+		}
+	}
+}
+
+// drain sends the events left in the queue, without retrying: the rest are
+// dropped as soon as sending fails.
+func (g *grpcSink) drain() {
+	for {
+		select {
+		case message := <-g.events:
+			if err := g.send(message); err != nil {
+				g.log.WithFields(log.Fields{
+					"prefix": "logtailing.grpcSink.drain",
+					"url":    g.url,
+				}).Debug("gRPC stream error, dropping remaining events: ", err)
+
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// send sends the message on the stream, opening a new stream first if there
+// is none. The stream is discarded if sending fails.
+func (g *grpcSink) send(message []byte) error {
+	if g.stream == nil {
+		stream, err := g.start()
+		if err != nil {
+			return err
+		}
+
+		g.stream = stream
+	}
+
+	if err := g.stream.send(message); err != nil {
+		g.stream.body.CloseWithError(err) // #nosec G104
+		g.stream = nil
+
+		return err
+	}
+
+	return nil
+}
+
+// wait blocks until run has returned and the stream has been closed.
+func (g *grpcSink) wait() {
+	<-g.done
+}
+
+// start sends the request of a new streaming RPC. The request body is
+// streamed as messages are sent, and the RPC's response is handled in the
+// background.
+func (g *grpcSink) start() (*grpcStream, error) {
+	body, writer := io.Pipe()
+
+	req, err := http.NewRequest(http.MethodPost, g.url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/grpc+proto")
+	req.Header.Set("TE", "trailers")
+
+	stream := &grpcStream{body: writer, closed: make(chan struct{})}
+
+	go func() {
+		defer close(stream.closed)
+
+		err := g.roundTrip(req)
+		if err != nil {
+			g.log.WithFields(log.Fields{
+				"prefix": "logtailing.grpcSink.start",
+				"url":    g.url,
+			}).Debug("gRPC stream failed: ", err)
+		}
+
+		// Fail subsequent sends so that the stream is reopened
+		body.CloseWithError(fmt.Errorf("gRPC stream closed: %v", err)) // #nosec G104
+	}()
+
+	return stream, nil
+}
+
+// roundTrip performs the RPC and returns an error if its status isn't OK.
+func (g *grpcSink) roundTrip(req *http.Request) error {
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// The status is sent in the trailers, after the body
+	if _, err := io.Copy(ioutil.Discard, resp.Body); err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	status := resp.Trailer.Get("Grpc-Status")
+	if status == "" {
+		status = resp.Header.Get("Grpc-Status")
+	}
+
+	if status != "0" {
+		return fmt.Errorf("gRPC status %s: %s", status, resp.Trailer.Get("Grpc-Message"))
+	}
+
+	return nil
+}
+
+// send writes a message to the stream as a length-prefixed gRPC frame.
+func (s *grpcStream) send(message []byte) error {
+	frame := make([]byte, 5+len(message))
+	binary.BigEndian.PutUint32(frame[1:5], uint32(len(message)))
+	copy(frame[5:], message)
+
+	_, err := s.body.Write(frame)
+
+	return err
+}
+
+// close ends the stream and waits for the collector to reply.
+func (s *grpcStream) close() {
+	s.body.Close() // #nosec G104
+
+	select {
+	case <-s.closed:
+	case <-time.After(grpcSinkCloseTimeout):
+	}
+}
@@ -0,0 +1,176 @@
+package logtailing
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// newGRPCCollector starts an in-process gRPC collector that decodes the
+// streamed events and sends them to the returned channel. If perStream is
+// positive, the collector ends each stream after that many events.
+func newGRPCCollector(t *testing.T, streams *int32, perStream int) (*httptest.Server, chan EventPayload) {
+	events := make(chan EventPayload, 10)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, grpcStreamMethod, r.URL.Path)
+		require.Equal(t, "application/grpc+proto", r.Header.Get("Content-Type"))
+
+		atomic.AddInt32(streams, 1)
+
+		w.Header().Set("Content-Type", "application/grpc+proto")
+		w.Header().Set("Trailer", "Grpc-Status")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+
+		body := bufio.NewReader(r.Body)
+
+		for n := 0; perStream <= 0 || n < perStream; n++ {
+			header := make([]byte, 5)
+			if _, err := io.ReadFull(body, header); err != nil {
+				break
+			}
+
+			message := make([]byte, binary.BigEndian.Uint32(header[1:]))
+			if _, err := io.ReadFull(body, message); err != nil {
+				break
+			}
+
+			payload, err := decodeEvent(message)
+			require.NoError(t, err)
+
+			events <- payload
+		}
+
+		w.Header().Set("Grpc-Status", "0")
+	})
+
+	return httptest.NewServer(h2c.NewHandler(handler, &http2.Server{})), events
+}
+
+func TestGRPCSink(t *testing.T) {
+	var streams int32
+
+	ts, events := newGRPCCollector(t, &streams, 0)
+	defer ts.Close()
+
+	tailer := New(&Config{GRPCTarget: ts.Listener.Addr().String(), Out: ioutil.Discard})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tailer.startSinks(ctx)
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"created_at":1704207600,"method":"POST","status":200,"url":"/v1/charges","request_id":"req_1"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"created_at":1704207601,"method":"GET","status":404,"url":"/v1/customers","request_id":"req_2","error":{"code":"resource_missing"}}`))
+
+	for _, expected := range []EventPayload{
+		{CreatedAt: 1704207600, Method: "POST", Status: 200, URL: "/v1/charges", RequestID: "req_1"},
+		{CreatedAt: 1704207601, Method: "GET", Status: 404, URL: "/v1/customers", RequestID: "req_2", Error: RedactedError{Code: "resource_missing"}},
+	} {
+		select {
+		case payload := <-events:
+			require.Equal(t, expected, payload)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for event")
+		}
+	}
+
+	cancel()
+	tailer.finish()
+
+	// Both events were sent on a single stream
+	require.Equal(t, int32(1), atomic.LoadInt32(&streams))
+}
+
+func TestGRPCSinkReconnects(t *testing.T) {
+	var streams int32
+
+	// The collector ends every stream after a single event
+	ts, events := newGRPCCollector(t, &streams, 1)
+	defer ts.Close()
+
+	sink := newGRPCSink("http://"+ts.Listener.Addr().String(), &log.Logger{Out: ioutil.Discard})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go sink.run(ctx)
+
+	sink.write(&EventPayload{RequestID: "req_1"})
+	require.Equal(t, "req_1", (<-events).RequestID)
+
+	require.Eventually(t, func() bool {
+		sink.write(&EventPayload{RequestID: "req_2"})
+
+		select {
+		case payload := <-events:
+			return payload.RequestID == "req_2"
+		case <-time.After(50 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.GreaterOrEqual(t, atomic.LoadInt32(&streams), int32(2))
+}
+
+func TestGRPCSinkDrainsOnShutdown(t *testing.T) {
+	var streams int32
+
+	ts, events := newGRPCCollector(t, &streams, 0)
+	defer ts.Close()
+
+	sink := newGRPCSink("http://"+ts.Listener.Addr().String(), &log.Logger{Out: ioutil.Discard})
+
+	for _, id := range []string{"req_1", "req_2", "req_3"} {
+		sink.write(&EventPayload{RequestID: id})
+	}
+
+	// The events are queued when the tail stops
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	go sink.run(ctx)
+	sink.wait()
+
+	require.Len(t, events, 3)
+
+	for _, id := range []string{"req_1", "req_2", "req_3"} {
+		require.Equal(t, id, (<-events).RequestID)
+	}
+}
+
+func TestGRPCSinkDoesNotBlockWhenUnavailable(t *testing.T) {
+	tailer := New(&Config{GRPCTarget: "127.0.0.1:1", Out: ioutil.Discard})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tailer.startSinks(ctx)
+
+	done := make(chan struct{})
+
+	go func() {
+		for i := 0; i < 2*grpcSinkBufferSize; i++ {
+			tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":200}`))
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("processing blocked on the gRPC sink")
+	}
+
+	cancel()
+	tailer.finish()
+}
@@ -0,0 +1,51 @@
+package logtailing
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// runHeartbeat prints a line to Out whenever no event has been received for
+// the heartbeat interval, until ctx is canceled.
+func (t *Tailer) runHeartbeat(ctx context.Context) {
+	t.mu.Lock()
+	t.lastActivity = t.cfg.Now()
+	t.mu.Unlock()
+
+	wait := t.cfg.Heartbeat
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		t.mu.Lock()
+
+		idle := t.cfg.Now().Sub(t.lastActivity)
+		if idle >= t.cfg.Heartbeat {
+			t.printHeartbeat()
+			t.lastActivity = t.cfg.Now()
+			idle = 0
+		}
+
+		t.mu.Unlock()
+
+		wait = t.cfg.Heartbeat - idle
+	}
+}
+
+// heartbeatEnabled reports whether heartbeat lines should be printed. They
+// are never mixed into machine-readable output.
+func (t *Tailer) heartbeatEnabled() bool {
+	return t.cfg.Heartbeat > 0 && !t.cfg.CountOnly && !machineReadable(t.cfg.OutputFormat)
+}
+
+func (t *Tailer) printHeartbeat() {
+	msg := t.heartbeatMessage()
+	t.quieted = 0
+	t.clearHighlight()
+	fmt.Fprintln(t.cfg.Out, t.color(t.cfg.Out).Faint(msg))
+}
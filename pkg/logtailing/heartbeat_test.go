@@ -0,0 +1,78 @@
+package logtailing
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer is a bytes.Buffer that is safe to read while a background
+// goroutine writes to it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func TestHeartbeatDuringSilence(t *testing.T) {
+	var buf syncBuffer
+
+	tailer := New(&Config{Heartbeat: 20 * time.Millisecond, Out: &buf})
+	require.True(t, tailer.heartbeatEnabled())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go tailer.runHeartbeat(ctx)
+
+	require.Eventually(t, func() bool {
+		return strings.Contains(buf.String(), "...still tailing (no events) [")
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestHeartbeatResetByEvents(t *testing.T) {
+	var buf syncBuffer
+
+	tailer := New(&Config{Heartbeat: 100 * time.Millisecond, Out: &buf})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go tailer.runHeartbeat(ctx)
+
+	for i := 0; i < 6; i++ {
+		time.Sleep(25 * time.Millisecond)
+		tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers"}`))
+	}
+
+	require.NotContains(t, buf.String(), "still tailing")
+}
+
+func TestHeartbeatSuppressedInJSON(t *testing.T) {
+	tailer := New(&Config{Heartbeat: time.Second, OutputFormat: "JSON"})
+	require.False(t, tailer.heartbeatEnabled())
+
+	tailer = New(&Config{Heartbeat: time.Second, CountOnly: true})
+	require.False(t, tailer.heartbeatEnabled())
+
+	tailer = New(&Config{})
+	require.False(t, tailer.heartbeatEnabled())
+}
@@ -0,0 +1,73 @@
+package logtailing
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	reverseVideo = "\033[7m"
+	resetStyle   = "\033[0m"
+)
+
+// highlightedLine is the request log line last printed in reverse video,
+// and the number of lines printed beneath it since.
+type highlightedLine struct {
+	text  string
+	below int
+}
+
+// highlightEnabled reports whether the latest request log line written to
+// output is highlighted. The line is redrawn when the next one arrives, so
+// this requires a terminal supporting colors, and isn't compatible with
+// collapsing which rewrites lines itself. Throughput, transitions and new
+// paths also print lines on Out that the cursor math doesn't account for.
+func (t *Tailer) highlightEnabled(output Output) bool {
+	return t.cfg.HighlightLatest &&
+		!t.cfg.CountOnly &&
+		!t.cfg.NewPathsOnly &&
+		!t.cfg.Transitions &&
+		!t.colorsDisabled() &&
+		output.Out == t.cfg.Out &&
+		!machineReadable(output.Format) &&
+		!t.collapses(output) &&
+		isTerminal(t.console)
+}
+
+// writeHighlighted writes a request log in the default format with its line
+// in reverse video, after restoring the previously highlighted line. The
+// caller must hold t.mu.
+func (t *Tailer) writeHighlighted(w io.Writer, evt *event) {
+	t.clearHighlight()
+
+	line := t.formatLine(w, evt, 1)
+	details := t.formatDetails(w, evt)
+
+	fmt.Fprintln(w, reverse(line))
+	fmt.Fprint(w, details)
+
+	evt.rendered = line + "\n" + details
+
+	t.highlighted = &highlightedLine{text: line, below: strings.Count(details, "\n")}
+}
+
+// clearHighlight redraws the highlighted line, if any, without reverse video
+// and moves the cursor back to where it was. It must be called before
+// anything else is printed to Out. The caller must hold t.mu.
+func (t *Tailer) clearHighlight() {
+	if t.highlighted == nil {
+		return
+	}
+
+	up := t.highlighted.below + 1
+	fmt.Fprintf(t.cfg.Out, "\033[%dA\r%s\033[K\033[%dB\r", up, t.highlighted.text, up)
+
+	t.highlighted = nil
+}
+
+// reverse renders line in reverse video, including after the resets of any
+// colors it contains.
+func reverse(line string) string {
+	return reverseVideo + strings.ReplaceAll(line, resetStyle, resetStyle+reverseVideo) + resetStyle
+}
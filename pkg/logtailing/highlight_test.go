@@ -0,0 +1,81 @@
+package logtailing
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/ansi"
+)
+
+func TestHighlightLatest(t *testing.T) {
+	defer func() { isTerminal = ansi.IsTerminal }()
+	isTerminal = func(io.Writer) bool { return true }
+
+	var buf bytes.Buffer
+
+	tailer := New(&Config{HighlightLatest: true, Out: &buf})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_1"}`))
+
+	first := strings.TrimSuffix(buf.String(), "\n")
+	require.True(t, strings.HasPrefix(first, "\033[7m"), first)
+	require.True(t, strings.HasSuffix(first, "[req_1]\033[0m"), first)
+
+	buf.Reset()
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":402,"url":"/v1/charges","request_id":"req_2","error":{"code":"card_declined"}}`))
+
+	// The previous line is redrawn without reverse video
+	restored := "\033[1A\r" + strings.TrimSuffix(strings.TrimPrefix(first, "\033[7m"), "\033[0m") + "\033[K\033[1B\r"
+	require.True(t, strings.HasPrefix(buf.String(), restored), buf.String())
+
+	rest := strings.TrimPrefix(buf.String(), restored)
+	require.Regexp(t, "^\033\\[7m.*\\[req_2\\]\033\\[0m\nCode: card_declined\n$", rest)
+
+	// Lines printed beneath the highlighted one are skipped over
+	buf.Reset()
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_3"}`))
+	require.True(t, strings.HasPrefix(buf.String(), "\033[2A\r"), buf.String())
+	require.Contains(t, buf.String(), "\033[K\033[2B\r")
+}
+
+func TestHighlightLatestDisabled(t *testing.T) {
+	message := requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_1"}`)
+
+	var buf bytes.Buffer
+
+	// Not a terminal
+	tailer := New(&Config{HighlightLatest: true, Out: &buf})
+	tailer.processRequestLogEvent(message)
+	require.NotContains(t, buf.String(), "\033[7m")
+
+	defer func() { isTerminal = ansi.IsTerminal }()
+	isTerminal = func(io.Writer) bool { return true }
+
+	buf.Reset()
+
+	tailer = New(&Config{HighlightLatest: true, NoColor: true, Out: &buf})
+	tailer.processRequestLogEvent(message)
+	require.NotContains(t, buf.String(), "\033[7m")
+
+	// Modes printing other lines on Out
+	for _, cfg := range []*Config{
+		{CountOnly: true, ThroughputInterval: time.Second},
+		{NewPathsOnly: true},
+		{Transitions: true},
+	} {
+		cfg.HighlightLatest = true
+		cfg.Out = &buf
+
+		tailer = New(cfg)
+		require.False(t, tailer.highlightEnabled(tailer.outputs[0]))
+	}
+}
+
+func TestReverse(t *testing.T) {
+	require.Equal(t, "\033[7mGET \033[32m200\033[0m\033[7m /v1\033[0m", reverse("GET \033[32m200\033[0m /v1"))
+}
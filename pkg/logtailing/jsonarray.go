@@ -0,0 +1,71 @@
+package logtailing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// validateJSONArray checks that JSONArray is only used for a bounded
+// capture, whose array can be closed once every request log is written.
+func (t *Tailer) validateJSONArray() error {
+	if !t.cfg.JSONArray {
+		return nil
+	}
+
+	if !t.replaying() {
+		return errors.New("a JSON array can only be written for a bounded capture, such as a replay")
+	}
+
+	for _, output := range t.outputs {
+		if strings.EqualFold(output.Format, outputFormatJSON) {
+			return nil
+		}
+	}
+
+	return errors.New("a JSON array requires the JSON format")
+}
+
+// writeArrayElement writes a JSON line to the array of the i-th output,
+// opening the array before its first element. Malformed payloads are dropped
+// as they would make the whole array invalid. The caller must hold t.mu.
+func (t *Tailer) writeArrayElement(i int, jsonLine string) {
+	if !json.Valid([]byte(jsonLine)) {
+		t.onError(fmt.Errorf("not writing malformed JSON to the array: %s", jsonLine))
+		return
+	}
+
+	w := t.outputs[i].Out
+
+	separator := ",\n"
+	if !t.arraysOpen[i] {
+		separator = "[\n"
+		t.arraysOpen[i] = true
+	}
+
+	fmt.Fprint(w, separator+t.colorizeJSON(jsonLine, w))
+}
+
+// closeArrays closes the array of each JSON output, writing an empty one
+// when no request log was written to it.
+func (t *Tailer) closeArrays() {
+	if !t.cfg.JSONArray {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i, output := range t.outputs {
+		if !strings.EqualFold(output.Format, outputFormatJSON) {
+			continue
+		}
+
+		if t.arraysOpen[i] {
+			fmt.Fprintln(output.Out, "\n]")
+		} else {
+			fmt.Fprintln(output.Out, "[]")
+		}
+	}
+}
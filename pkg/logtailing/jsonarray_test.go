@@ -0,0 +1,61 @@
+package logtailing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONArray(t *testing.T) {
+	input := strings.Join([]string{
+		`{"created_at":1600000000,"method":"POST","status":200,"url":"/v1/charges","request_id":"req_1"}`,
+		`{"created_at":1600000100,"method":"GET","status":404,"url":"/v1/customers","request_id":"req_2"}`,
+		`{"created_at":1600000200,"method":"DELETE","status":200,"url":"/v1/customers/cus_123","request_id":"req_3"}`,
+	}, "\n")
+
+	var buf, text bytes.Buffer
+
+	tailer := New(&Config{
+		Input:        strings.NewReader(input),
+		JSONArray:    true,
+		NoColor:      true,
+		Out:          &buf,
+		OutputFormat: "JSON",
+		Outputs:      []Output{{Out: &text}},
+	})
+	require.NoError(t, tailer.Run(context.Background()))
+
+	var payloads []EventPayload
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &payloads))
+	require.Equal(t, []string{"req_1", "req_2", "req_3"}, recentRequestIDs(payloads))
+
+	require.True(t, strings.HasPrefix(buf.String(), "[\n{"))
+	require.True(t, strings.HasSuffix(buf.String(), "}\n]\n"))
+
+	// Other formats are left alone
+	require.Len(t, strings.Split(strings.TrimSpace(text.String()), "\n"), 3)
+}
+
+func TestJSONArrayEmpty(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{Input: strings.NewReader(""), JSONArray: true, Out: &buf, OutputFormat: "JSON"})
+	require.NoError(t, tailer.Run(context.Background()))
+
+	require.Equal(t, "[]\n", buf.String())
+}
+
+func TestJSONArrayRequiresBoundedCapture(t *testing.T) {
+	tailer := New(&Config{JSONArray: true, Out: &bytes.Buffer{}, OutputFormat: "JSON"})
+
+	err := tailer.validateConfig()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "bounded capture")
+
+	tailer = New(&Config{Input: strings.NewReader(""), JSONArray: true, Out: &bytes.Buffer{}})
+	require.EqualError(t, tailer.validateConfig(), "a JSON array requires the JSON format")
+}
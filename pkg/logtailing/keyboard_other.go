@@ -0,0 +1,13 @@
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package logtailing
+
+import (
+	"errors"
+)
+
+// enableCbreak is not supported on this platform, where key presses need to
+// be followed by Enter.
+func enableCbreak(fd int) (func(), error) {
+	return nil, errors.New("unbuffered terminal input is not supported on this platform")
+}
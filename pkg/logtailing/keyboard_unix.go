@@ -0,0 +1,31 @@
+// +build linux darwin dragonfly freebsd netbsd openbsd
+
+package logtailing
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// enableCbreak disables line buffering and echoing of the terminal's input,
+// leaving output processing untouched, and returns a function restoring the
+// previous mode.
+func enableCbreak(fd int) (func(), error) {
+	termios, err := unix.IoctlGetTermios(fd, ioctlReadTermios)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := *termios
+
+	termios.Lflag &^= unix.ICANON | unix.ECHO
+	termios.Cc[unix.VMIN] = 1
+	termios.Cc[unix.VTIME] = 0
+
+	if err := unix.IoctlSetTermios(fd, ioctlWriteTermios, termios); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		unix.IoctlSetTermios(fd, ioctlWriteTermios, &previous) // #nosec G104
+	}, nil
+}
@@ -0,0 +1,93 @@
+package logtailing
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// latencyReservoirSize bounds the number of latencies kept to estimate the
+// quantiles, whatever the length of the session.
+const latencyReservoirSize = 1000
+
+// LatencyQuantiles are the estimated latency quantiles of the request logs
+// received, in milliseconds.
+type LatencyQuantiles struct {
+	P50 float64 `json:"p50"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
+}
+
+// latencyReservoir keeps a uniform random sample of the latencies seen so
+// far (reservoir sampling), from which the quantiles are estimated. The
+// samples are kept sorted, since the quantiles are read as often as every
+// request log to update the count-only gauge.
+type latencyReservoir struct {
+	samples []float64
+	seen    int
+	rand    *rand.Rand
+}
+
+func newLatencyReservoir() *latencyReservoir {
+	return &latencyReservoir{
+		samples: make([]float64, 0, latencyReservoirSize),
+		rand:    rand.New(rand.NewSource(time.Now().UnixNano())), // #nosec G404
+	}
+}
+
+// add records a latency in milliseconds.
+func (r *latencyReservoir) add(ms float64) {
+	r.seen++
+
+	if len(r.samples) < latencyReservoirSize {
+		r.insert(ms)
+		return
+	}
+
+	// Keep the new latency with probability size/seen, in place of a random
+	// sample
+	if i := r.rand.Intn(r.seen); i < latencyReservoirSize {
+		r.samples = append(r.samples[:i], r.samples[i+1:]...)
+		r.insert(ms)
+	}
+}
+
+// insert adds a latency to the samples, keeping them sorted.
+func (r *latencyReservoir) insert(ms float64) {
+	i := sort.SearchFloat64s(r.samples, ms)
+
+	r.samples = append(r.samples, 0)
+	copy(r.samples[i+1:], r.samples[i:])
+	r.samples[i] = ms
+}
+
+// quantiles returns the estimated quantiles, or nil if no latency was
+// recorded.
+func (r *latencyReservoir) quantiles() *LatencyQuantiles {
+	if len(r.samples) == 0 {
+		return nil
+	}
+
+	// Nearest-rank method
+	quantile := func(q float64) float64 {
+		rank := int(math.Ceil(q*float64(len(r.samples)))) - 1
+		if rank < 0 {
+			rank = 0
+		}
+
+		return r.samples[rank]
+	}
+
+	return &LatencyQuantiles{
+		P50: quantile(0.50),
+		P95: quantile(0.95),
+		P99: quantile(0.99),
+	}
+}
+
+// String formats the quantiles for the count-only gauge and summary.
+func (q *LatencyQuantiles) String() string {
+	return fmt.Sprintf("p50 %gms, p95 %gms, p99 %gms", q.P50, q.P95, q.P99)
+}
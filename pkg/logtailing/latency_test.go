@@ -0,0 +1,68 @@
+package logtailing
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencyQuantiles(t *testing.T) {
+	r := newLatencyReservoir()
+	require.Nil(t, r.quantiles())
+
+	for _, i := range rand.Perm(100) {
+		r.add(float64(i + 1))
+	}
+
+	require.Equal(t, &LatencyQuantiles{P50: 50, P95: 95, P99: 99}, r.quantiles())
+}
+
+func TestLatencyQuantilesBounded(t *testing.T) {
+	r := newLatencyReservoir()
+	r.rand = rand.New(rand.NewSource(1)) // #nosec G404
+
+	// Uniform between 1 and 10000ms, with a fixed seed so the sample is the
+	// same on every run
+	for _, i := range r.rand.Perm(10000) {
+		r.add(float64(i + 1))
+	}
+
+	require.Len(t, r.samples, latencyReservoirSize)
+	require.True(t, sort.Float64sAreSorted(r.samples))
+
+	q := r.quantiles()
+	require.InDelta(t, 5000, q.P50, 500)
+	require.InDelta(t, 9500, q.P95, 200)
+	require.InDelta(t, 9900, q.P99, 100)
+}
+
+func TestLatencySummary(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{CountOnly: true, NoColor: true, Out: &buf})
+
+	for i := 1; i <= 20; i++ {
+		tailer.processRequestLogEvent(requestLogMessage(fmt.Sprintf(`{"duration_ms":%d,"method":"POST","status":200,"url":"/v1/charges"}`, i*10)))
+	}
+
+	// Request logs without a duration are left out of the estimate
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":200,"url":"/v1/charges"}`))
+
+	buf.Reset()
+	tailer.printSummary()
+
+	require.Equal(t, "\nTotal: 21 request logs received\nLatency: p50 100ms, p95 190ms, p99 200ms\n", buf.String())
+	require.Equal(t, &LatencyQuantiles{P50: 100, P95: 190, P99: 200}, tailer.report().Latency)
+}
+
+func TestLatencyOmittedWithoutDurations(t *testing.T) {
+	tailer := New(&Config{Out: &bytes.Buffer{}})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":200,"url":"/v1/charges"}`))
+
+	require.Nil(t, tailer.report().Latency)
+}
@@ -0,0 +1,105 @@
+package logtailing
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/signal"
+	"sync/atomic"
+)
+
+// lineLayout is the set of columns shown by the default format.
+type lineLayout int
+
+const (
+	// layoutStandard shows the usual columns
+	layoutStandard lineLayout = iota
+
+	// layoutNarrow only shows the time, status and method
+	layoutNarrow
+
+	// layoutWide adds the latency to the usual columns
+	layoutWide
+)
+
+const (
+	// Terminals narrower than layoutNarrowWidth get the narrow layout, and
+	// the ones at least layoutWideWidth wide get the wide layout
+	layoutNarrowWidth = 60
+	layoutWideWidth   = 120
+
+	// fallbackLayoutWidth is the width assumed when the output isn't a
+	// terminal, which selects the standard layout
+	fallbackLayoutWidth = 80
+)
+
+// layoutForWidth returns the layout used for a terminal width columns wide.
+func layoutForWidth(width int) lineLayout {
+	switch {
+	case width < layoutNarrowWidth:
+		return layoutNarrow
+	case width >= layoutWideWidth:
+		return layoutWide
+	default:
+		return layoutStandard
+	}
+}
+
+// layout returns the layout of request log lines written to w: the standard
+// one, unless Responsive is set in which case it depends on the width of w.
+func (t *Tailer) layout(w io.Writer) lineLayout {
+	if !t.cfg.Responsive {
+		return layoutStandard
+	}
+
+	return layoutForWidth(t.layoutWidth(w))
+}
+
+// layoutWidth returns the width of w, or fallbackLayoutWidth if it isn't a
+// terminal. The width of Out is only looked up again when the terminal is
+// resized, see watchResize.
+func (t *Tailer) layoutWidth(w io.Writer) int {
+	if w != t.cfg.Out {
+		return widthOrFallback(terminalWidth(t.probed(w)))
+	}
+
+	if width := atomic.LoadInt32(&t.outWidth); width > 0 {
+		return int(width)
+	}
+
+	return t.refreshWidth()
+}
+
+// refreshWidth looks up and caches the width of Out.
+func (t *Tailer) refreshWidth() int {
+	width := widthOrFallback(terminalWidth(t.console))
+	atomic.StoreInt32(&t.outWidth, int32(width))
+
+	return width
+}
+
+func widthOrFallback(width int) int {
+	if width <= 0 {
+		return fallbackLayoutWidth
+	}
+
+	return width
+}
+
+// watchResize refreshes the width of Out every time the terminal is resized,
+// until ctx is canceled.
+func (t *Tailer) watchResize(ctx context.Context) {
+	resized := make(chan os.Signal, 1)
+	notifyResize(resized)
+
+	defer signal.Stop(resized)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-resized:
+			t.refreshWidth()
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package logtailing
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const layoutTestPayload = `{"created_at":1704207845,"method":"POST","status":402,"url":"/v1/charges","request_id":"req_1","account":"acct_1","duration_ms":231.6}`
+
+func TestLayoutForWidth(t *testing.T) {
+	require.Equal(t, layoutNarrow, layoutForWidth(40))
+	require.Equal(t, layoutStandard, layoutForWidth(60))
+	require.Equal(t, layoutStandard, layoutForWidth(119))
+	require.Equal(t, layoutWide, layoutForWidth(120))
+}
+
+func TestResponsiveLayout(t *testing.T) {
+	width := 40
+
+	terminalWidth = func(io.Writer) int { return width }
+	defer func() { terminalWidth = defaultTerminalWidth }()
+
+	var buf bytes.Buffer
+
+	tailer := New(&Config{NoColor: true, Out: &buf, Responsive: true})
+
+	line := func() string {
+		buf.Reset()
+		tailer.processRequestLogEvent(requestLogMessage(layoutTestPayload))
+
+		return buf.String()
+	}
+
+	narrow := line()
+	require.Regexp(t, `^[-\d: ]+ \[402\] POST\n`, narrow)
+
+	// The width of Out is cached until the terminal is resized
+	width = 160
+	require.Equal(t, narrow, line())
+
+	tailer.refreshWidth()
+	require.Regexp(t, `^[-\d: ]+ \[acct_1\] \[402\] POST /v1/charges \[req_1\] \(232ms\)\n`, line())
+
+	width = 100
+	tailer.refreshWidth()
+	require.Regexp(t, `^[-\d: ]+ \[acct_1\] \[402\] POST /v1/charges \[req_1\]\n`, line())
+}
+
+func TestResponsiveLayoutFallback(t *testing.T) {
+	terminalWidth = func(io.Writer) int { return 0 }
+	defer func() { terminalWidth = defaultTerminalWidth }()
+
+	var buf bytes.Buffer
+
+	// Outputs that aren't terminals get the standard layout
+	tailer := New(&Config{NoColor: true, Out: &buf, Responsive: true})
+	require.Equal(t, fallbackLayoutWidth, tailer.layoutWidth(&buf))
+	require.Equal(t, layoutStandard, tailer.layout(&buf))
+}
+
+func TestResponsiveLayoutDisabled(t *testing.T) {
+	terminalWidth = func(io.Writer) int { return 40 }
+	defer func() { terminalWidth = defaultTerminalWidth }()
+
+	var buf bytes.Buffer
+
+	tailer := New(&Config{NoColor: true, Out: &buf})
+	require.Equal(t, layoutStandard, tailer.layout(&buf))
+}
@@ -0,0 +1,115 @@
+package logtailing
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// formatLogfmt renders a request log as a line of logfmt key=value pairs.
+// Error fields are only included when set.
+func (t *Tailer) formatLogfmt(evt *event) string {
+	payload := &evt.payload
+
+	var b strings.Builder
+
+	add := func(key, value string) {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(logfmtValue(value))
+	}
+
+	if t.cfg.ShowSeq {
+		add("seq", strconv.Itoa(evt.seq))
+	}
+
+	if evt.feature != "" {
+		add("feature", evt.feature)
+	}
+
+	add("ts", logfmtTime(payload.CreatedAt))
+	add("status", strconv.Itoa(payload.Status))
+	add("method", payload.Method)
+	add("url", payload.URL)
+
+	if payload.Protocol != "" {
+		add("protocol", payload.Protocol)
+	}
+
+	if payload.APIVersion != "" {
+		add("api_version", payload.APIVersion)
+	}
+
+	add("request_id", payload.RequestID)
+
+	if t.cfg.ShowSize {
+		add("payload_bytes", strconv.Itoa(evt.size))
+	}
+
+	if len(evt.matchedFilters) > 0 {
+		add("matched_filters", strings.Join(evt.matchedFilters, ","))
+	}
+
+	errorFields := []struct {
+		key   string
+		value string
+	}{
+		{"error_type", payload.Error.Type},
+		{"error_code", payload.Error.Code},
+		{"decline_code", payload.Error.DeclineCode},
+		{"error_charge", payload.Error.Charge},
+		{"error_param", payload.Error.Param},
+		{"error_message", payload.Error.Message},
+	}
+
+	for _, field := range errorFields {
+		if field.value != "" {
+			add(field.key, field.value)
+		}
+	}
+
+	return b.String()
+}
+
+// logfmtLine renders alternating keys and values as a line of logfmt
+// key=value pairs.
+func logfmtLine(pairs ...string) string {
+	var b strings.Builder
+
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+
+		b.WriteString(pairs[i])
+		b.WriteByte('=')
+		b.WriteString(logfmtValue(pairs[i+1]))
+	}
+
+	return b.String()
+}
+
+// logfmtTime formats a request log time for logfmt lines.
+func logfmtTime(createdAt int) string {
+	return time.Unix(int64(createdAt), 0).UTC().Format(time.RFC3339)
+}
+
+// logfmtValue quotes the value if it is empty or contains spaces, quotes,
+// equal signs or control characters.
+func logfmtValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " =\"\\") || strings.IndexFunc(value, func(r rune) bool { return r < ' ' }) >= 0 {
+		return strconv.Quote(value)
+	}
+
+	return value
+}
+
+// writeLogfmt writes the request log to the output as logfmt.
+func (t *Tailer) writeLogfmt(output Output, evt *event) {
+	fmt.Fprintln(output.Out, t.formatLogfmt(evt))
+}
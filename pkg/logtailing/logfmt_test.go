@@ -0,0 +1,43 @@
+package logtailing
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogfmtFormat(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{
+		Filters:      &LogFilters{FilterStatusCategory: []string{"error"}},
+		Out:          &buf,
+		OutputFormat: "LOGFMT",
+	})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"created_at":1704207600,"method":"POST","status":402,"url":"/v1/charges","request_id":"req_1","error":{"type":"card_error","code":"card_declined","decline_code":"insufficient_funds","message":"Your card has insufficient funds."}}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"created_at":1704207601,"method":"GET","status":200,"url":"/v1/customers","request_id":"req_2"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"created_at":1704207602,"method":"POST","status":500,"url":"/v1/stripecli/sessions","request_id":"req_3"}`))
+
+	require.Equal(t,
+		`ts=2024-01-02T15:00:00Z status=402 method=POST url=/v1/charges request_id=req_1 error_type=card_error error_code=card_declined decline_code=insufficient_funds error_message="Your card has insufficient funds."`+"\n",
+		buf.String(),
+	)
+}
+
+func TestLogfmtShowSeq(t *testing.T) {
+	tailer := New(&Config{ShowSeq: true})
+
+	line := tailer.formatLogfmt(&event{payload: EventPayload{CreatedAt: 1704207600, Method: "GET", Status: 200, URL: "/v1/charges?limit=3"}, seq: 4})
+	require.Equal(t, `seq=4 ts=2024-01-02T15:00:00Z status=200 method=GET url="/v1/charges?limit=3" request_id=""`, line)
+}
+
+func TestLogfmtValue(t *testing.T) {
+	require.Equal(t, "card_declined", logfmtValue("card_declined"))
+	require.Equal(t, `""`, logfmtValue(""))
+	require.Equal(t, `"two words"`, logfmtValue("two words"))
+	require.Equal(t, `"a=b"`, logfmtValue("a=b"))
+	require.Equal(t, `"say \"hi\""`, logfmtValue(`say "hi"`))
+	require.Equal(t, `"line\nbreak"`, logfmtValue("line\nbreak"))
+}
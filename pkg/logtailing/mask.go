@@ -0,0 +1,63 @@
+package logtailing
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// maskAccountID masks all but the last 4 characters of an account ID, e.g.
+// acct_***a1b2.
+func maskAccountID(id string) string {
+	suffix := strings.TrimPrefix(id, "acct_")
+	if len(suffix) <= 4 {
+		return "acct_***"
+	}
+
+	return "acct_***" + suffix[len(suffix)-4:]
+}
+
+// maskAccount returns a copy of evt for display with its account ID masked,
+// or evt itself if MaskAccount isn't set or there's nothing to mask. Only
+// the console outputs are masked: the sinks receive the raw request logs.
+func (t *Tailer) maskAccount(evt *event) *event {
+	if !t.cfg.MaskAccount || evt.payload.Account == "" {
+		return evt
+	}
+
+	account := maskAccountID(evt.payload.Account)
+	if account == evt.payload.Account {
+		return evt
+	}
+
+	masked := *evt
+	masked.payload.Account = account
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(evt.raw), &fields); err != nil {
+		t.onError(err)
+		return &masked
+	}
+
+	// The raw payload is only encoded again if it has an account to mask
+	if _, ok := fields["account"]; !ok {
+		return &masked
+	}
+
+	encoded, err := json.Marshal(account)
+	if err != nil {
+		t.onError(err)
+		return &masked
+	}
+
+	fields["account"] = encoded
+
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		t.onError(err)
+		return &masked
+	}
+
+	masked.raw = string(raw)
+
+	return &masked
+}
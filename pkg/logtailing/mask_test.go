@@ -0,0 +1,86 @@
+package logtailing
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaskAccountID(t *testing.T) {
+	require.Equal(t, "acct_***a1b2", maskAccountID("acct_1Hd9xQa1b2"))
+	require.Equal(t, "acct_***", maskAccountID("acct_12"))
+}
+
+func TestMaskAccount(t *testing.T) {
+	var text, jsonBuf bytes.Buffer
+
+	tailer := New(&Config{
+		MaskAccount: true,
+		NoColor:     true,
+		Out:         &text,
+		Outputs:     []Output{{Out: &jsonBuf, Format: "JSON"}},
+	})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"account":"acct_1Hd9xQa1b2","method":"POST","request_id":"req_1","status":200,"url":"/v1/charges"}`))
+
+	require.True(t, strings.HasSuffix(strings.TrimSpace(text.String()), "[acct_***a1b2] [200] POST /v1/charges [req_1]"))
+	require.JSONEq(t, `{"account":"acct_***a1b2","method":"POST","request_id":"req_1","status":200,"url":"/v1/charges"}`, jsonBuf.String())
+	require.NotContains(t, text.String()+jsonBuf.String(), "acct_1Hd9xQa1b2")
+}
+
+func TestMaskAccountNothingToMask(t *testing.T) {
+	tailer := New(&Config{MaskAccount: true, Out: &bytes.Buffer{}})
+
+	// Already masked
+	evt := &event{
+		payload: EventPayload{Account: "acct_***a1b2"},
+		raw:     `{"account":"acct_***a1b2"}`,
+	}
+	require.Same(t, evt, tailer.maskAccount(evt))
+
+	// The raw payload is left as is when it has no account
+	evt = &event{
+		payload: EventPayload{Account: "acct_1Hd9xQa1b2"},
+		raw:     `{ "method": "POST" }`,
+	}
+
+	masked := tailer.maskAccount(evt)
+	require.Equal(t, "acct_***a1b2", masked.payload.Account)
+	require.Equal(t, evt.raw, masked.raw)
+}
+
+func TestMaskAccountDisabled(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{NoColor: true, Out: &buf})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"account":"acct_1Hd9xQa1b2","method":"POST","request_id":"req_1","status":200,"url":"/v1/charges"}`))
+
+	require.Contains(t, buf.String(), "[acct_1Hd9xQa1b2] [200] POST /v1/charges [req_1]")
+}
+
+func TestMaskAccountSinksReceiveRawPayload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mask")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	payload := `{"account":"acct_1Hd9xQa1b2","method":"POST","request_id":"req_1","status":200,"url":"/v1/charges"}`
+
+	var buf bytes.Buffer
+
+	tailer := New(&Config{MaskAccount: true, Out: &buf, OutFile: filepath.Join(dir, "traffic.ndjson"), OutputFormat: "JSON"})
+
+	tailer.processRequestLogEvent(requestLogMessage(payload))
+	tailer.finish()
+
+	written, err := ioutil.ReadFile(filepath.Join(dir, "traffic.ndjson"))
+	require.NoError(t, err)
+	require.Equal(t, payload+"\n", string(written))
+
+	require.Contains(t, buf.String(), "acct_***a1b2")
+}
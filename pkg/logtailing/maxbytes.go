@@ -0,0 +1,72 @@
+package logtailing
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// validateMaxBytes returns an error if MaxBytes is set without a sink whose
+// writes count towards it.
+func (t *Tailer) validateMaxBytes() error {
+	if t.cfg.MaxBytes <= 0 {
+		return nil
+	}
+
+	if t.eventSocket == nil && t.forwarder == nil && t.fileSink == nil && t.grpcSink == nil && t.otlpSink == nil && t.syslogSink == nil && len(t.cfg.Sinks) == 0 {
+		return errors.New("MaxBytes requires a sink to count the bytes written to, such as OutFile")
+	}
+
+	return nil
+}
+
+// countBytes adds the size of an event written to the given sink, and
+// signals Run to stop once more than MaxBytes have been written in total.
+// Every sink counts the event's JSON line and its newline, whatever it
+// actually writes, so that the limit doesn't depend on the sinks used. The
+// caller must hold t.mu.
+func (t *Tailer) countBytes(sink string, jsonLine string) {
+	if t.cfg.MaxBytes <= 0 {
+		return
+	}
+
+	n := len(jsonLine) + 1
+
+	t.sinkBytes[sink] += int64(n)
+	t.totalBytes += int64(n)
+
+	if t.totalBytes > t.cfg.MaxBytes && !t.maxBytesReached {
+		t.maxBytesReached = true
+		t.maxBytesSink = sink
+
+		select {
+		case t.maxBytesHit <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// printMaxBytesReached notes on Log.Out that the session was stopped because
+// MaxBytes was reached, if it was, along with the sink whose write reached it
+// and the bytes written to each sink.
+func (t *Tailer) printMaxBytesReached() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.maxBytesReached {
+		return
+	}
+
+	fmt.Fprintf(t.log.Out, "Stopped after writing %d bytes to sinks, the maximum is %d bytes (reached writing to %s)\n", t.totalBytes, t.cfg.MaxBytes, t.maxBytesSink)
+
+	sinks := make([]string, 0, len(t.sinkBytes))
+	for sink := range t.sinkBytes {
+		sinks = append(sinks, sink)
+	}
+
+	sort.Strings(sinks)
+
+	for _, sink := range sinks {
+		fmt.Fprintf(t.log.Out, "  %s: %d bytes\n", sink, t.sinkBytes[sink])
+	}
+}
@@ -0,0 +1,114 @@
+package logtailing
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxBytes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logtailing")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	outFile := filepath.Join(dir, "capture.ndjson")
+	line := `{"method":"GET","status":200,"url":"/v1/charges"}`
+
+	var logOut bytes.Buffer
+
+	tailer := New(&Config{
+		Input:    strings.NewReader(strings.Repeat(line+"\n", 10)),
+		Log:      &log.Logger{Out: &logOut},
+		MaxBytes: int64(2*len(line) + 1),
+		Out:      &bytes.Buffer{},
+		OutFile:  outFile,
+	})
+
+	require.NoError(t, tailer.Run(context.Background()))
+
+	report := tailer.report()
+	require.True(t, report.MaxBytesReached)
+	require.Equal(t, 2, report.Total)
+	require.Equal(t, map[string]int64{"out_file": int64(2 * (len(line) + 1))}, report.SinkBytes)
+
+	written, err := ioutil.ReadFile(outFile)
+	require.NoError(t, err)
+	require.Equal(t, strings.Repeat(line+"\n", 2), string(written))
+
+	require.Contains(t, logOut.String(), "Stopped after writing 100 bytes to sinks, the maximum is 99 bytes (reached writing to out_file)\n  out_file: 100 bytes\n")
+}
+
+func TestMaxBytesCountsEverySinkAlike(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	dir, err := ioutil.TempDir("", "logtailing")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	line := `{"method":"GET","status":200,"url":"/v1/charges"}`
+
+	tailer := New(&Config{
+		EventSocket: ln.Addr().String(),
+		MaxBytes:    1 << 20,
+		Out:         &bytes.Buffer{},
+		OutFile:     filepath.Join(dir, "capture.ndjson"),
+	})
+
+	tailer.ProcessMessage(requestLogMessage(line))
+
+	report := tailer.report()
+	require.Equal(t, report.SinkBytes["out_file"], report.SinkBytes["event_socket"])
+	require.NotZero(t, report.SinkBytes["out_file"])
+}
+
+func TestMaxBytesRequiresSink(t *testing.T) {
+	tailer := New(&Config{MaxBytes: 100, Out: &bytes.Buffer{}})
+	require.EqualError(t, tailer.validateConfig(), "MaxBytes requires a sink to count the bytes written to, such as OutFile")
+
+	tailer = New(&Config{MaxBytes: 100, Out: &bytes.Buffer{}, EventSocket: "127.0.0.1:1"})
+	require.NoError(t, tailer.validateConfig())
+
+	tailer = New(&Config{MaxBytes: 100, Out: &bytes.Buffer{}, Sinks: []Sink{&fakeSink{}}})
+	require.NoError(t, tailer.validateConfig())
+}
+
+func TestMaxBytesCountsUserSinks(t *testing.T) {
+	line := `{"method":"GET","status":200,"url":"/v1/charges"}`
+	sink := &fakeSink{}
+
+	tailer := New(&Config{
+		Input:    strings.NewReader(strings.Repeat(line+"\n", 10)),
+		MaxBytes: int64(2*len(line) + 1),
+		Out:      &bytes.Buffer{},
+		Sinks:    []Sink{sink},
+	})
+
+	require.NoError(t, tailer.Run(context.Background()))
+
+	report := tailer.report()
+	require.True(t, report.MaxBytesReached)
+	require.Equal(t, map[string]int64{"sinks[0]": int64(2 * (len(line) + 1))}, report.SinkBytes)
+	require.Len(t, sink.payloads, 2)
+}
+
+func TestMaxBytesUnlimited(t *testing.T) {
+	tailer := New(&Config{Out: &bytes.Buffer{}, OutputFormat: "JSON"})
+
+	for i := 0; i < 3; i++ {
+		tailer.ProcessMessage(requestLogMessage(`{"method":"GET","status":200}`))
+	}
+
+	report := tailer.report()
+	require.False(t, report.MaxBytesReached)
+	require.Nil(t, report.SinkBytes)
+}
@@ -0,0 +1,14 @@
+package logtailing
+
+// applyMiddleware runs the payload through the Middleware chain in order. It
+// returns false as soon as a middleware drops the event.
+func (t *Tailer) applyMiddleware(payload EventPayload) (EventPayload, bool) {
+	for _, middleware := range t.cfg.Middleware {
+		var keep bool
+		if payload, keep = middleware(payload); !keep {
+			return payload, false
+		}
+	}
+
+	return payload, true
+}
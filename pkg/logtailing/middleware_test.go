@@ -0,0 +1,83 @@
+package logtailing
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddlewareChain(t *testing.T) {
+	var buf bytes.Buffer
+
+	var calls []string
+
+	tailer := New(&Config{
+		Middleware: []func(EventPayload) (EventPayload, bool){
+			func(payload EventPayload) (EventPayload, bool) {
+				calls = append(calls, "annotate")
+				payload.URL = "/annotated" + payload.URL
+				return payload, true
+			},
+			func(payload EventPayload) (EventPayload, bool) {
+				calls = append(calls, "drop")
+				return payload, payload.Status < 500
+			},
+		},
+		OutputFormat: "JSON",
+		NoColor:      true,
+		Out:          &buf,
+	})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/charges"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":500,"url":"/v1/customers"}`))
+
+	require.Equal(t, []string{"annotate", "drop", "annotate", "drop"}, calls)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 1)
+	require.Contains(t, lines[0], `"url":"/annotated/v1/charges"`)
+	require.Equal(t, 1, tailer.count)
+}
+
+func TestMiddlewareStopsAtDrop(t *testing.T) {
+	called := false
+
+	tailer := New(&Config{
+		Middleware: []func(EventPayload) (EventPayload, bool){
+			func(payload EventPayload) (EventPayload, bool) {
+				return payload, false
+			},
+			func(payload EventPayload) (EventPayload, bool) {
+				called = true
+				return payload, true
+			},
+		},
+	})
+
+	_, keep := tailer.applyMiddleware(EventPayload{})
+	require.False(t, keep)
+	require.False(t, called)
+}
+
+func TestMiddlewareRunsAfterFilters(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{
+		Filters: &LogFilters{FilterStatusCategory: []string{"success"}},
+		Middleware: []func(EventPayload) (EventPayload, bool){
+			func(payload EventPayload) (EventPayload, bool) {
+				// Reclassifying the event doesn't affect the filters
+				payload.Status = 404
+				return payload, true
+			},
+		},
+		NoColor: true,
+		Out:     &buf,
+	})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/charges"}`))
+
+	require.Contains(t, buf.String(), "[404] GET /v1/charges")
+}
@@ -0,0 +1,88 @@
+package logtailing
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/stripe/stripe-cli/pkg/ansi"
+)
+
+// newPath is the first request log seen for an endpoint.
+type newPath struct {
+	CreatedAt int    `json:"created_at"`
+	Method    string `json:"method"`
+	Path      string `json:"path"`
+	Status    int    `json:"status"`
+	RequestID string `json:"request_id"`
+}
+
+// recordPath records the endpoint of the event, its method and normalized
+// path, and reports whether it's the first time it's seen. The caller must
+// hold t.mu.
+func (t *Tailer) recordPath(payload *EventPayload) (newPath, bool) {
+	path := normalizePath(payload.URL)
+	key := payload.Method + " " + path
+
+	if _, seen := t.seenPaths[key]; seen {
+		return newPath{}, false
+	}
+
+	t.seenPaths[key] = struct{}{}
+
+	return newPath{
+		CreatedAt: payload.CreatedAt,
+		Method:    payload.Method,
+		Path:      path,
+		Status:    payload.Status,
+		RequestID: payload.RequestID,
+	}, true
+}
+
+// displayNewPath writes the event to the outputs that accept it if it's the
+// first request log for its endpoint. The caller must hold t.mu.
+func (t *Tailer) displayNewPath(evt *event) {
+	np, ok := t.recordPath(&evt.payload)
+	if !ok {
+		return
+	}
+
+	for _, output := range t.outputs {
+		if !output.accepts(&evt.payload) || t.quietsOutput(&output, &evt.payload) {
+			continue
+		}
+
+		switch {
+		case strings.EqualFold(output.Format, outputFormatProtobuf):
+			// There's no message for new paths, see validateProtobuf
+			continue
+		case strings.EqualFold(output.Format, outputFormatLogfmt):
+			fmt.Fprintln(output.Out, logfmtLine(
+				"ts", logfmtTime(np.CreatedAt),
+				"method", np.Method,
+				"path", np.Path,
+				"status", strconv.Itoa(np.Status),
+				"request_id", np.RequestID,
+			))
+
+			continue
+		case machineReadable(output.Format):
+			line, err := json.Marshal(np)
+			if err != nil {
+				t.onError(err)
+				continue
+			}
+
+			fmt.Fprintln(output.Out, string(line))
+
+			continue
+		}
+
+		color := t.color(output.Out)
+		localTime := t.createdAt(np.CreatedAt).Format(dateTimeLayout)
+
+		fmt.Fprintf(output.Out, "%s %s %s [%d] [%s]\n",
+			color.Faint(localTime), np.Method, np.Path, ansi.ColorizeStatusWith(color, np.Status), np.RequestID)
+	}
+}
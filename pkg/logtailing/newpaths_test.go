@@ -0,0 +1,67 @@
+package logtailing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPathsOnly(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{NewPathsOnly: true, NoColor: true, Out: &buf})
+
+	sequence := []struct {
+		method string
+		url    string
+		status int
+	}{
+		{"GET", "/v1/customers/cus_1", 200},
+		{"GET", "/v1/customers/cus_2?expand[]=sources", 404},
+		{"POST", "/v1/charges", 200},
+		{"POST", "/v1/charges", 500},
+		{"POST", "/v1/customers/cus_1", 200},
+		{"GET", "/v1/customers/cus_3", 200},
+	}
+
+	for i, req := range sequence {
+		tailer.processRequestLogEvent(requestLogMessage(fmt.Sprintf(`{"method":"%s","request_id":"req_%d","status":%d,"url":"%s"}`, req.method, i, req.status, req.url)))
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	require.True(t, strings.HasSuffix(lines[0], "GET /v1/customers/:id [200] [req_0]"), lines[0])
+	require.True(t, strings.HasSuffix(lines[1], "POST /v1/charges [200] [req_2]"), lines[1])
+	require.True(t, strings.HasSuffix(lines[2], "POST /v1/customers/:id [200] [req_4]"), lines[2])
+
+	// Silent request logs are still counted
+	require.Equal(t, map[string]int{"2xx": 4, "4xx": 1, "5xx": 1}, tailer.report().StatusClasses)
+}
+
+func TestNewPathsOnlyJSON(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{NewPathsOnly: true, Out: &buf, OutputFormat: outputFormatJSON})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"created_at":1704207845,"method":"GET","request_id":"req_1","status":200,"url":"/v1/balance"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"created_at":1704207846,"method":"GET","request_id":"req_2","status":429,"url":"/v1/balance"}`))
+
+	var np newPath
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &np))
+	require.Equal(t, newPath{CreatedAt: 1704207845, Method: "GET", Path: "/v1/balance", Status: 200, RequestID: "req_1"}, np)
+}
+
+func TestNewPathsOnlyLogfmt(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{NewPathsOnly: true, Out: &buf, OutputFormat: outputFormatLogfmt})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"created_at":1704207845,"method":"GET","request_id":"req_1","status":200,"url":"/v1/customers/cus_123"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"created_at":1704207846,"method":"GET","request_id":"req_2","status":429,"url":"/v1/customers/cus_456"}`))
+
+	require.Equal(t, "ts=2024-01-02T15:04:05Z method=GET path=/v1/customers/:id status=200 request_id=req_1\n", buf.String())
+}
@@ -0,0 +1,38 @@
+package logtailing
+
+import (
+	"strings"
+)
+
+// DefaultNoisePaths are the health check and similar paths dropped when
+// Config.FilterNoise is set. They match request paths ending with them, e.g.
+// /healthz matches both /healthz and /v1/healthz.
+var DefaultNoisePaths = []string{
+	"/favicon.ico",
+	"/health",
+	"/healthcheck",
+	"/healthz",
+	"/livez",
+	"/ping",
+	"/readyz",
+	"/robots.txt",
+}
+
+// noisy reports whether request logs for the URL are dropped by FilterNoise.
+func (t *Tailer) noisy(url string) bool {
+	if !t.cfg.FilterNoise {
+		return false
+	}
+
+	path := stripQuery(url)
+
+	for _, noise := range [][]string{DefaultNoisePaths, t.cfg.NoisePaths} {
+		for _, suffix := range noise {
+			if suffix != "" && strings.HasSuffix(path, suffix) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
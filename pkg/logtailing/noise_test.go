@@ -0,0 +1,45 @@
+package logtailing
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterNoise(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{FilterNoise: true, NoColor: true, NoisePaths: []string{"/internal/metrics"}, Out: &buf})
+
+	for _, url := range []string{"/healthz", "/v1/ping?ts=1", "/internal/metrics", "/v1/charges", "/v1/pingers"} {
+		tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"` + url + `"}`))
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], "/v1/charges")
+	require.Contains(t, lines[1], "/v1/pingers")
+}
+
+func TestFilterNoiseDisabled(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{NoColor: true, NoisePaths: []string{"/internal/metrics"}, Out: &buf})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/healthz"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/internal/metrics"}`))
+
+	require.Equal(t, 2, strings.Count(buf.String(), "\n"))
+}
+
+func TestFilterNoiseWithExcludedPaths(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{FilterNoise: true, NoColor: true, Out: &buf})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":200,"url":"/v1/stripecli/sessions"}`))
+
+	require.Empty(t, buf.String())
+}
@@ -0,0 +1,82 @@
+package logtailing
+
+import (
+	"io"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultWebSocketFeature is the websocket feature used by NewTailer to
+// receive request logs.
+const DefaultWebSocketFeature = "request_logs"
+
+// Option configures a Tailer created with NewTailer.
+type Option func(*Config)
+
+// NewTailer creates a new Tailer that authenticates with key, configured by
+// the given options. It is equivalent to calling New with the resulting
+// Config.
+func NewTailer(key string, opts ...Option) *Tailer {
+	cfg := &Config{
+		Key:              key,
+		WebSocketFeature: DefaultWebSocketFeature,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return New(cfg)
+}
+
+// WithFilters sets the filters for API request logs.
+func WithFilters(filters *LogFilters) Option {
+	return func(cfg *Config) {
+		cfg.Filters = filters
+	}
+}
+
+// WithOutput sets where request logs are written.
+func WithOutput(w io.Writer) Option {
+	return func(cfg *Config) {
+		cfg.Out = w
+	}
+}
+
+// WithFormat sets the output format of request logs.
+func WithFormat(format string) Option {
+	return func(cfg *Config) {
+		cfg.OutputFormat = format
+	}
+}
+
+// WithLogger sets the logger for info and error messages unrelated to API
+// request logs.
+func WithLogger(logger *log.Logger) Option {
+	return func(cfg *Config) {
+		cfg.Log = logger
+	}
+}
+
+// WithColorMode sets when colors and other ANSI sequences are used in
+// request logs: auto, always or never.
+func WithColorMode(mode string) Option {
+	return func(cfg *Config) {
+		cfg.ColorMode = mode
+	}
+}
+
+// WithNoColor disables colors and other ANSI sequences in request logs.
+func WithNoColor() Option {
+	return func(cfg *Config) {
+		cfg.NoColor = true
+	}
+}
+
+// WithRecent keeps the last size request logs in memory, for Recent to
+// return.
+func WithRecent(size int) Option {
+	return func(cfg *Config) {
+		cfg.RecentSize = size
+	}
+}
@@ -0,0 +1,47 @@
+package logtailing
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTailerWithOptions(t *testing.T) {
+	var buf bytes.Buffer
+
+	filters := &LogFilters{FilterHTTPMethod: []string{"POST"}}
+	logger := log.New()
+
+	tailer := NewTailer("sk_test_123",
+		WithFilters(filters),
+		WithOutput(&buf),
+		WithFormat("JSON"),
+		WithLogger(logger),
+		WithNoColor(),
+	)
+
+	require.Equal(t, "sk_test_123", tailer.cfg.Key)
+	require.Equal(t, DefaultWebSocketFeature, tailer.cfg.WebSocketFeature)
+	require.Same(t, filters, tailer.cfg.Filters)
+	require.Same(t, &buf, tailer.cfg.Out)
+	require.Equal(t, "JSON", tailer.cfg.OutputFormat)
+	require.Same(t, logger, tailer.cfg.Log)
+	require.True(t, tailer.cfg.NoColor)
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":200}`))
+	require.Equal(t, "{\"method\":\"POST\",\"status\":200}\n", buf.String())
+}
+
+func TestNewTailerDefaults(t *testing.T) {
+	tailer := NewTailer("sk_test_123")
+
+	require.Equal(t, "sk_test_123", tailer.cfg.Key)
+	require.Equal(t, DefaultWebSocketFeature, tailer.cfg.WebSocketFeature)
+	require.Nil(t, tailer.cfg.Filters)
+	require.Equal(t, os.Stdout, tailer.cfg.Out)
+	require.Empty(t, tailer.cfg.OutputFormat)
+	require.False(t, tailer.cfg.NoColor)
+}
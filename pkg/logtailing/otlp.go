@@ -0,0 +1,242 @@
+package logtailing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	otlpSinkBufferSize    = 1000
+	otlpSinkBatchSize     = 100
+	otlpSinkFlushInterval = time.Second
+	otlpSinkFlushTimeout  = 5 * time.Second
+
+	otlpScopeName   = "github.com/stripe/stripe-cli/pkg/logtailing"
+	otlpServiceName = "stripe-cli"
+
+	// Severity numbers from the OpenTelemetry logs data model
+	otlpSeverityInfo  = 9
+	otlpSeverityWarn  = 13
+	otlpSeverityError = 17
+)
+
+// The types below are the subset of the OTLP/HTTP JSON encoding of
+// ExportLogsServiceRequest used by the exporter. See
+// https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+type otlpLogsRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano   string         `json:"timeUnixNano"`
+	SeverityNumber int            `json:"severityNumber"`
+	SeverityText   string         `json:"severityText"`
+	Body           otlpAnyValue   `json:"body"`
+	Attributes     []otlpKeyValue `json:"attributes"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+// otlpAnyValue holds either a string or an integer. Integers are encoded as
+// strings, as required for 64 bits integers in the JSON encoding.
+type otlpAnyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+}
+
+func otlpString(key, value string) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: &value}}
+}
+
+func otlpInt(key string, value int) otlpKeyValue {
+	encoded := strconv.Itoa(value)
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{IntValue: &encoded}}
+}
+
+// otlpRecord returns the OTel log record of a request log. The attributes
+// follow the OpenTelemetry HTTP semantic conventions, with the Stripe
+// specific ones under stripe.*.
+func otlpRecord(payload *EventPayload) otlpLogRecord {
+	severity, severityText := otlpSeverityInfo, "INFO"
+
+	switch {
+	case payload.Status >= 500:
+		severity, severityText = otlpSeverityError, "ERROR"
+	case payload.Status >= 400:
+		severity, severityText = otlpSeverityWarn, "WARN"
+	}
+
+	attributes := []otlpKeyValue{
+		otlpInt("http.response.status_code", payload.Status),
+		otlpString("http.request.method", payload.Method),
+		otlpString("url.path", payload.URL),
+		otlpString("stripe.request_id", payload.RequestID),
+	}
+
+	if payload.Account != "" {
+		attributes = append(attributes, otlpString("stripe.account", payload.Account))
+	}
+
+	body := fmt.Sprintf("%d %s %s", payload.Status, payload.Method, payload.URL)
+
+	return otlpLogRecord{
+		TimeUnixNano:   strconv.FormatInt(time.Unix(int64(payload.CreatedAt), 0).UnixNano(), 10),
+		SeverityNumber: severity,
+		SeverityText:   severityText,
+		Body:           otlpAnyValue{StringValue: &body},
+		Attributes:     attributes,
+	}
+}
+
+// otlpSink exports events as OpenTelemetry log records to an OTLP/HTTP
+// endpoint using the JSON encoding. Like forwardSink, records are queued and
+// batched so that a slow or unavailable collector never blocks the console
+// output, and whatever is left is flushed on shutdown.
+type otlpSink struct {
+	endpoint string
+
+	client *http.Client
+	log    *log.Logger
+
+	records chan otlpLogRecord
+	done    chan struct{}
+}
+
+func newOTLPSink(endpoint string, logger *log.Logger) *otlpSink {
+	return &otlpSink{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: otlpSinkFlushTimeout},
+		log:      logger,
+		records:  make(chan otlpLogRecord, otlpSinkBufferSize),
+		done:     make(chan struct{}),
+	}
+}
+
+// write queues the log record of an event for export. The record is dropped
+// if the queue is full.
+func (o *otlpSink) write(payload *EventPayload) {
+	select {
+	case o.records <- otlpRecord(payload):
+	default:
+		o.log.WithFields(log.Fields{
+			"prefix": "logtailing.otlpSink.write",
+		}).Debug("OTLP export queue is full, dropping event")
+	}
+}
+
+// run batches and exports queued records until ctx is canceled, then flushes
+// the remaining records and closes the done channel.
+func (o *otlpSink) run(ctx context.Context) {
+	defer close(o.done)
+
+	ticker := time.NewTicker(otlpSinkFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]otlpLogRecord, 0, otlpSinkBatchSize)
+
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case record := <-o.records:
+					batch = append(batch, record)
+				default:
+					o.flush(batch)
+					return
+				}
+			}
+		case record := <-o.records:
+			batch = append(batch, record)
+			if len(batch) >= otlpSinkBatchSize {
+				o.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			o.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+// wait blocks until run has returned and the final batch has been exported.
+func (o *otlpSink) wait() {
+	<-o.done
+}
+
+func (o *otlpSink) flush(batch []otlpLogRecord) {
+	if len(batch) == 0 {
+		return
+	}
+
+	if err := o.post(batch); err != nil {
+		o.log.WithFields(log.Fields{
+			"prefix":   "logtailing.otlpSink.flush",
+			"endpoint": o.endpoint,
+		}).Debug("Failed to export events: ", err)
+	}
+}
+
+func (o *otlpSink) post(batch []otlpLogRecord) error {
+	body, err := json.Marshal(otlpLogsRequest{
+		ResourceLogs: []otlpResourceLogs{{
+			Resource: otlpResource{
+				Attributes: []otlpKeyValue{otlpString("service.name", otlpServiceName)},
+			},
+			ScopeLogs: []otlpScopeLogs{{
+				Scope:      otlpScope{Name: otlpScopeName},
+				LogRecords: batch,
+			}},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, o.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package logtailing
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newOTLPReceiver returns a stub OTLP/HTTP logs receiver sending the
+// requests it receives on the returned channel.
+func newOTLPReceiver(t *testing.T, status int) (*httptest.Server, chan otlpLogsRequest) {
+	requests := make(chan otlpLogsRequest, 10)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "/v1/logs", r.URL.Path)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var req otlpLogsRequest
+		require.NoError(t, json.Unmarshal(body, &req))
+
+		select {
+		case requests <- req:
+		default:
+		}
+
+		w.WriteHeader(status)
+	}))
+
+	return ts, requests
+}
+
+// otlpAttributes returns the attributes of a record as a map of their string
+// or integer values.
+func otlpAttributes(record otlpLogRecord) map[string]string {
+	attributes := make(map[string]string, len(record.Attributes))
+
+	for _, kv := range record.Attributes {
+		if kv.Value.StringValue != nil {
+			attributes[kv.Key] = *kv.Value.StringValue
+		} else {
+			attributes[kv.Key] = *kv.Value.IntValue
+		}
+	}
+
+	return attributes
+}
+
+func TestOTLPExport(t *testing.T) {
+	ts, requests := newOTLPReceiver(t, http.StatusOK)
+	defer ts.Close()
+
+	tailer := New(&Config{OTLPEndpoint: ts.URL + "/v1/logs", Out: ioutil.Discard})
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go tailer.otlpSink.run(ctx)
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"account":"acct_123","created_at":1704207600,"method":"POST","request_id":"req_1","status":402,"url":"/v1/charges"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"created_at":1704207601,"method":"GET","request_id":"req_2","status":200,"url":"/v1/customers"}`))
+
+	// The batch is flushed on shutdown
+	cancel()
+	tailer.finish()
+
+	var req otlpLogsRequest
+	select {
+	case req = <-requests:
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "Timed out waiting for exported records")
+	}
+
+	require.Len(t, req.ResourceLogs, 1)
+	require.Equal(t, map[string]string{"service.name": "stripe-cli"}, otlpAttributes(otlpLogRecord{Attributes: req.ResourceLogs[0].Resource.Attributes}))
+
+	records := req.ResourceLogs[0].ScopeLogs[0].LogRecords
+	require.Len(t, records, 2)
+
+	require.Equal(t, "1704207600000000000", records[0].TimeUnixNano)
+	require.Equal(t, otlpSeverityWarn, records[0].SeverityNumber)
+	require.Equal(t, "402 POST /v1/charges", *records[0].Body.StringValue)
+	require.Equal(t, map[string]string{
+		"http.response.status_code": "402",
+		"http.request.method":       "POST",
+		"url.path":                  "/v1/charges",
+		"stripe.request_id":         "req_1",
+		"stripe.account":            "acct_123",
+	}, otlpAttributes(records[0]))
+
+	require.Equal(t, otlpSeverityInfo, records[1].SeverityNumber)
+	require.NotContains(t, otlpAttributes(records[1]), "stripe.account")
+}
+
+func TestOTLPExportFailureDoesNotBlock(t *testing.T) {
+	ts, requests := newOTLPReceiver(t, http.StatusServiceUnavailable)
+	defer ts.Close()
+
+	tailer := New(&Config{OTLPEndpoint: ts.URL + "/v1/logs", Out: ioutil.Discard})
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go tailer.otlpSink.run(ctx)
+
+	// More records than the queue holds while the receiver is failing
+	for i := 0; i < otlpSinkBufferSize+otlpSinkBatchSize*2; i++ {
+		tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","request_id":"req_1","status":500,"url":"/v1/charges"}`))
+	}
+
+	require.Equal(t, otlpSinkBufferSize+otlpSinkBatchSize*2, tailer.report().Total)
+
+	cancel()
+	tailer.finish()
+
+	require.NotEmpty(t, requests)
+}
@@ -0,0 +1,209 @@
+package logtailing
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	partitionByHour = "hour"
+	partitionByDay  = "day"
+)
+
+var partitionLayouts = map[string]string{
+	partitionByHour: "2006-01-02-15",
+	partitionByDay:  "2006-01-02",
+}
+
+const (
+	flushPolicyLine     = "line"
+	flushPolicyInterval = "interval"
+	flushPolicySize     = "size"
+
+	defaultFlushInterval = time.Second
+	defaultFlushSize     = 64 * 1024
+)
+
+// fileSink appends NDJSON events to OutFile or, when partitioned, to one file
+// per hour or day of the events' creation time. Only the file of the current
+// partition is kept open. Writes are buffered and flushed according to the
+// flush policy: after every line, every flush interval (see runFileFlush), or
+// once flushSize bytes are buffered.
+type fileSink struct {
+	path        string
+	partitionBy string
+	flushPolicy string
+	flushSize   int
+	log         *log.Logger
+
+	name   string
+	file   *os.File
+	writer *bufio.Writer
+}
+
+func newFileSink(path, partitionBy, flushPolicy string, flushSize int, logger *log.Logger) *fileSink {
+	if flushPolicy == "" {
+		flushPolicy = flushPolicyLine
+	}
+
+	if flushSize <= 0 {
+		flushSize = defaultFlushSize
+	}
+
+	return &fileSink{
+		path:        path,
+		partitionBy: strings.ToLower(partitionBy),
+		flushPolicy: strings.ToLower(flushPolicy),
+		flushSize:   flushSize,
+		log:         logger,
+	}
+}
+
+// validatePartitionBy returns an error if partitionBy isn't a supported
+// partitioning or is set without an out file.
+func validatePartitionBy(partitionBy, outFile string) error {
+	if partitionBy == "" {
+		return nil
+	}
+
+	if _, ok := partitionLayouts[strings.ToLower(partitionBy)]; !ok {
+		return fmt.Errorf("unknown partitioning %q. Expected %s or %s", partitionBy, partitionByHour, partitionByDay)
+	}
+
+	if outFile == "" {
+		return fmt.Errorf("partitioning by %s requires an out file", partitionBy)
+	}
+
+	return nil
+}
+
+// validateFlushPolicy returns an error if flushPolicy isn't a supported
+// policy.
+func validateFlushPolicy(flushPolicy string) error {
+	switch strings.ToLower(flushPolicy) {
+	case "", flushPolicyLine, flushPolicyInterval, flushPolicySize:
+		return nil
+	default:
+		return fmt.Errorf("unknown flush policy %q. Expected %s, %s or %s", flushPolicy, flushPolicyLine, flushPolicyInterval, flushPolicySize)
+	}
+}
+
+// fileName returns the name of the file events created at createdAt are
+// written to.
+func (f *fileSink) fileName(createdAt int) string {
+	layout, ok := partitionLayouts[f.partitionBy]
+	if !ok {
+		return f.path
+	}
+
+	ext := filepath.Ext(f.path)
+	if ext == "" {
+		ext = ".ndjson"
+	}
+
+	bucket := time.Unix(int64(createdAt), 0).UTC().Format(layout)
+
+	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(f.path, filepath.Ext(f.path)), bucket, ext)
+}
+
+// write appends the JSON line of an event created at createdAt to its file,
+// switching files when the event belongs to a different partition.
+func (f *fileSink) write(createdAt int, line string) {
+	name := f.fileName(createdAt)
+
+	if name != f.name {
+		f.close()
+
+		file, err := os.OpenFile(name, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			f.log.WithFields(log.Fields{
+				"prefix": "logtailing.fileSink.write",
+				"path":   name,
+			}).Error("Unable to open out file: ", err)
+
+			return
+		}
+
+		f.name = name
+		f.file = file
+		// Leave room so that reaching flushSize flushes whole lines
+		f.writer = bufio.NewWriterSize(file, 2*f.flushSize)
+	}
+
+	if _, err := fmt.Fprintln(f.writer, line); err != nil {
+		f.log.WithFields(log.Fields{
+			"prefix": "logtailing.fileSink.write",
+			"path":   f.name,
+		}).Error("Unable to write to out file: ", err)
+
+		return
+	}
+
+	switch f.flushPolicy {
+	case flushPolicyLine:
+		f.flush()
+	case flushPolicySize:
+		if f.writer.Buffered() >= f.flushSize {
+			f.flush()
+		}
+	}
+}
+
+// flush writes the buffered lines to the file of the current partition.
+func (f *fileSink) flush() {
+	if f.writer == nil {
+		return
+	}
+
+	if err := f.writer.Flush(); err != nil {
+		f.log.WithFields(log.Fields{
+			"prefix": "logtailing.fileSink.flush",
+			"path":   f.name,
+		}).Error("Unable to write to out file: ", err)
+	}
+}
+
+// close flushes and closes the file of the current partition, if any.
+func (f *fileSink) close() {
+	if f.file == nil {
+		return
+	}
+
+	f.flush()
+
+	if err := f.file.Close(); err != nil {
+		f.log.WithFields(log.Fields{
+			"prefix": "logtailing.fileSink.close",
+			"path":   f.name,
+		}).Error("Unable to close out file: ", err)
+	}
+
+	f.name = ""
+	f.file = nil
+	f.writer = nil
+}
+
+// runFileFlush periodically flushes the out file with the interval flush
+// policy.
+func (t *Tailer) runFileFlush(ctx context.Context) {
+	ticker := time.NewTicker(t.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.mu.Lock()
+			t.fileSink.flush()
+			t.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package logtailing
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutFilePartitionByHour(t *testing.T) {
+	dir, err := ioutil.TempDir("", "partition")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tailer := New(&Config{
+		Out:         ioutil.Discard,
+		OutFile:     filepath.Join(dir, "traffic"),
+		PartitionBy: "hour",
+		// 2024-01-02 14:59:58, 14:59:59 and 15:00:00 UTC
+		ReplayFile: writeReplayFile(t,
+			`{"created_at":1704207598,"method":"POST","status":200,"request_id":"req_1"}`,
+			`{"created_at":1704207599,"method":"POST","status":200,"request_id":"req_2"}`,
+			`{"created_at":1704207600,"method":"POST","status":200,"request_id":"req_3"}`,
+		),
+	})
+	require.NoError(t, tailer.Run(context.Background()))
+	require.Nil(t, tailer.fileSink.file)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		filepath.Join(dir, "traffic-2024-01-02-14.ndjson"),
+		filepath.Join(dir, "traffic-2024-01-02-15.ndjson"),
+	}, files)
+
+	first, err := ioutil.ReadFile(files[0])
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(first)), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], "req_1")
+	require.Contains(t, lines[1], "req_2")
+
+	second, err := ioutil.ReadFile(files[1])
+	require.NoError(t, err)
+	require.Equal(t, `{"created_at":1704207600,"method":"POST","status":200,"request_id":"req_3"}`+"\n", string(second))
+}
+
+func TestOutFileName(t *testing.T) {
+	require.Equal(t, "traffic.ndjson", newFileSink("traffic.ndjson", "", "", 0, nil).fileName(1704207600))
+	require.Equal(t, "traffic-2024-01-02.ndjson", newFileSink("traffic", "day", "", 0, nil).fileName(1704207600))
+	require.Equal(t, "logs/traffic-2024-01-02-15.json", newFileSink("logs/traffic.json", "HOUR", "", 0, nil).fileName(1704207600))
+}
+
+func TestPartitionByValidation(t *testing.T) {
+	err := New(&Config{PartitionBy: "minute", OutFile: "traffic"}).Run(context.Background())
+	require.EqualError(t, err, `unknown partitioning "minute". Expected hour or day`)
+
+	err = New(&Config{PartitionBy: "hour"}).Run(context.Background())
+	require.EqualError(t, err, "partitioning by hour requires an out file")
+}
+
+// newFlushTailer returns a tailer writing to an out file in a temporary
+// directory, and a function returning the out file's content.
+func newFlushTailer(t *testing.T, cfg *Config) (*Tailer, func() string) {
+	dir, err := ioutil.TempDir("", "flush")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	cfg.Out = ioutil.Discard
+	cfg.OutFile = filepath.Join(dir, "traffic.ndjson")
+
+	read := func() string {
+		content, err := ioutil.ReadFile(cfg.OutFile)
+		require.NoError(t, err)
+
+		return string(content)
+	}
+
+	return New(cfg), read
+}
+
+func TestFlushPolicyLine(t *testing.T) {
+	tailer, read := newFlushTailer(t, &Config{})
+
+	tailer.ProcessMessage(requestLogMessage(`{"request_id":"req_1"}`))
+	require.Equal(t, "{\"request_id\":\"req_1\"}\n", read())
+
+	tailer.ProcessMessage(requestLogMessage(`{"request_id":"req_2"}`))
+	require.Equal(t, "{\"request_id\":\"req_1\"}\n{\"request_id\":\"req_2\"}\n", read())
+}
+
+func TestFlushPolicySize(t *testing.T) {
+	tailer, read := newFlushTailer(t, &Config{FlushPolicy: "size", FlushSize: 40})
+
+	// Each line is 23 bytes
+	tailer.ProcessMessage(requestLogMessage(`{"request_id":"req_1"}`))
+	require.Empty(t, read())
+
+	tailer.ProcessMessage(requestLogMessage(`{"request_id":"req_2"}`))
+	require.Equal(t, 2, strings.Count(read(), "\n"))
+
+	tailer.ProcessMessage(requestLogMessage(`{"request_id":"req_3"}`))
+	require.Equal(t, 2, strings.Count(read(), "\n"))
+
+	tailer.finish()
+	require.Equal(t, 3, strings.Count(read(), "\n"))
+}
+
+func TestFlushPolicyInterval(t *testing.T) {
+	tailer, read := newFlushTailer(t, &Config{FlushPolicy: "interval", FlushInterval: 10 * time.Millisecond})
+
+	tailer.ProcessMessage(requestLogMessage(`{"request_id":"req_1"}`))
+	require.Empty(t, read())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go tailer.runFileFlush(ctx)
+
+	require.Eventually(t, func() bool {
+		return read() == "{\"request_id\":\"req_1\"}\n"
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestFlushPolicyIntervalFlushedOnShutdown(t *testing.T) {
+	tailer, read := newFlushTailer(t, &Config{FlushPolicy: "interval", FlushInterval: time.Hour})
+
+	tailer.ProcessMessage(requestLogMessage(`{"request_id":"req_1"}`))
+	require.Empty(t, read())
+
+	tailer.finish()
+	require.Equal(t, "{\"request_id\":\"req_1\"}\n", read())
+}
+
+func TestFlushPolicyValidation(t *testing.T) {
+	err := New(&Config{FlushPolicy: "never", OutFile: "traffic"}).Run(context.Background())
+	require.EqualError(t, err, `unknown flush policy "never". Expected line, interval or size`)
+}
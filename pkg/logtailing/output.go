@@ -0,0 +1,391 @@
+package logtailing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/logrusorgru/aurora"
+
+	"github.com/stripe/stripe-cli/pkg/ansi"
+)
+
+// Output is a destination for request logs.
+type Output struct {
+	// Out is where request logs are written
+	Out io.Writer
+
+	// Format is the output format of request logs. Empty for the default
+	// format.
+	Format string
+
+	// Filters narrow down the request logs written to this output, on top of
+	// the Config filters sent to Stripe. Filters on fields that aren't part
+	// of the request log payload (account, IP address and source) can't be
+	// applied per output.
+	Filters *LogFilters
+
+	// Predicate, if set, is called with every request log that passes the
+	// output's Filters and returns whether to write it to this output
+	Predicate func(EventPayload) bool
+
+	// Plain strips colors and other ANSI sequences from the default format,
+	// e.g. for a file next to a colored console. The request log is written
+	// as rendered for the previous outputs rather than formatted again.
+	Plain bool
+}
+
+func (o *Output) filtered() bool {
+	return o.Filters != nil || o.Predicate != nil
+}
+
+// accepts reports whether the request log should be written to the output.
+func (o *Output) accepts(payload *EventPayload) bool {
+	if !o.Filters.matchesPayload(payload) {
+		return false
+	}
+
+	return o.Predicate == nil || o.Predicate(*payload)
+}
+
+// validateOutputs returns an error if the same writer is configured more than
+// once with the same format without filters, which would print every event
+// twice, or if an output's filters can't be applied.
+func validateOutputs(outputs []Output) error {
+	for i, a := range outputs {
+		if a.Out == nil {
+			return fmt.Errorf("output %d has no writer", i)
+		}
+
+		if err := a.Filters.validateForOutput(); err != nil {
+			return fmt.Errorf("output %d: %v", i, err)
+		}
+
+		for _, b := range outputs[:i] {
+			if sameWriter(a.Out, b.Out) && strings.EqualFold(a.Format, b.Format) && !a.filtered() && !b.filtered() {
+				return fmt.Errorf("output %d duplicates another output with the same writer and format (%s)", i, formatName(a.Format))
+			}
+		}
+	}
+
+	return nil
+}
+
+func sameWriter(a, b io.Writer) bool {
+	if reflect.TypeOf(a) != reflect.TypeOf(b) || !reflect.TypeOf(a).Comparable() {
+		return false
+	}
+
+	return a == b
+}
+
+// machineReadable reports whether the format is meant to be consumed by
+// other programs, in which case every event is written as is.
+func machineReadable(format string) bool {
+	return strings.EqualFold(format, outputFormatJSON) || strings.EqualFold(format, outputFormatLogfmt) || strings.EqualFold(format, outputFormatProtobuf)
+}
+
+func formatName(format string) string {
+	if format == "" {
+		return "default"
+	}
+
+	return strings.ToUpper(format)
+}
+
+// writeEvent renders a request log to the output in its format. jsonLine is
+// the event's encoding for the JSON format.
+func (t *Tailer) writeEvent(output Output, evt *event, jsonLine string) {
+	w := output.Out
+
+	if strings.EqualFold(output.Format, outputFormatJSON) {
+		if t.cfg.StrictJSON {
+			t.writeStrictJSON(w, jsonLine)
+			return
+		}
+
+		if t.cfg.ForceColorJSON && !t.colorsDisabled() {
+			fmt.Fprintln(w, t.forceColorizeJSON(jsonLine))
+			return
+		}
+
+		fmt.Fprintln(w, t.colorizeJSON(jsonLine, w))
+		return
+	}
+
+	if strings.EqualFold(output.Format, outputFormatLogfmt) {
+		t.writeLogfmt(output, evt)
+		return
+	}
+
+	if strings.EqualFold(output.Format, outputFormatProtobuf) {
+		t.writeProtobuf(w, evt)
+		return
+	}
+
+	if t.watched(evt) {
+		t.writeWatched(output, evt)
+		return
+	}
+
+	if t.highlightEnabled(output) {
+		t.writeHighlighted(w, evt)
+		return
+	}
+
+	t.writeLine(output, evt, 1)
+}
+
+// writeLine renders a request log in the default format. count is the number
+// of identical consecutive events the line stands for when collapsing.
+func (t *Tailer) writeLine(output Output, evt *event, count int) {
+	w := output.Out
+
+	if output.Plain && count == 1 && evt.rendered != "" {
+		fmt.Fprint(w, ansi.Strip(evt.rendered))
+		return
+	}
+
+	text := t.formatLine(w, evt, count) + "\n" + t.formatDetails(w, evt)
+
+	if output.Plain {
+		text = ansi.Strip(text)
+	} else if count == 1 {
+		evt.rendered = text
+	}
+
+	fmt.Fprint(w, text)
+}
+
+// formatLine returns the line of a request log in the default format, for
+// writing to w.
+func (t *Tailer) formatLine(w io.Writer, evt *event, count int) string {
+	payload := &evt.payload
+
+	color := t.color(w)
+
+	// Tokens aren't colored individually when the whole line is
+	lineColor := color
+	if t.cfg.LineColorByStatus {
+		color = aurora.NewAurora(false)
+	}
+
+	status := fmt.Sprintf("[%d]", ansi.ColorizeStatusWith(color, payload.Status))
+	if t.cfg.StatusGlyph {
+		status = formatStatusGlyph(color, payload.Status)
+	}
+
+	url := urlForRequestID(payload)
+	requestLink := t.linkify(payload.RequestID, url, w)
+
+	path := payload.URL
+	if t.cfg.StripQuery {
+		path = stripQuery(path)
+	}
+
+	if path == "" && !t.cfg.OmitEmptyURL {
+		path = t.cfg.EmptyURLText
+	}
+
+	if payload.Protocol != "" {
+		path = strings.TrimSpace(fmt.Sprintf("%s %s", path, color.Faint(payload.Protocol)))
+	}
+
+	if payload.APIVersion != "" {
+		path = strings.TrimSpace(fmt.Sprintf("%s %s", path, color.Faint("["+payload.APIVersion+"]")))
+	}
+
+	// The path may be omitted altogether
+	request := strings.TrimSpace(fmt.Sprintf("%s %s", payload.Method, path))
+
+	localTime := t.createdAt(payload.CreatedAt).Format(t.lineTimeLayout())
+
+	layout := t.layout(w)
+
+	outputStr := fmt.Sprintf("%s %s %s [%s]", color.Faint(localTime), status, request, requestLink)
+	switch {
+	case layout == layoutNarrow:
+		outputStr = fmt.Sprintf("%s %s %s", color.Faint(localTime), status, payload.Method)
+	case payload.Account != "":
+		outputStr = fmt.Sprintf("%s [%s] %s %s [%s]", color.Faint(localTime), payload.Account, status, request, requestLink)
+	}
+	if layout == layoutWide && payload.DurationMs > 0 {
+		outputStr = fmt.Sprintf("%s %s", outputStr, color.Faint(fmt.Sprintf("(%.0fms)", payload.DurationMs)))
+	}
+	if evt.feature != "" {
+		outputStr = fmt.Sprintf("[%s] %s", evt.feature, outputStr)
+	}
+	if t.cfg.ShowSeq {
+		outputStr = fmt.Sprintf("#%d %s", evt.seq, outputStr)
+	}
+	if t.cfg.ShowSize {
+		outputStr = fmt.Sprintf("%s %s", outputStr, color.Faint(fmt.Sprintf("[%dB]", evt.size)))
+	}
+	if len(evt.matchedFilters) > 0 {
+		outputStr = fmt.Sprintf("%s %s", outputStr, color.Faint(fmt.Sprintf("(matched %s)", strings.Join(evt.matchedFilters, ", "))))
+	}
+	if count > 1 {
+		outputStr = fmt.Sprintf("%s %s", outputStr, color.Bold(fmt.Sprintf("(x%d)", count)))
+	}
+	if t.cfg.LineColorByStatus {
+		outputStr = colorLineByStatus(lineColor, payload.Status, outputStr).String()
+	}
+
+	return outputStr
+}
+
+// formatDetails returns the lines printed beneath the line of a request log
+// in the default format, such as its error fields, for writing to w.
+func (t *Tailer) formatDetails(w io.Writer, evt *event) string {
+	payload := &evt.payload
+
+	var details strings.Builder
+
+	errorValues := reflect.ValueOf(&payload.Error).Elem()
+	errType := errorValues.Type()
+
+	var compact []string
+
+	for i := 0; i < errorValues.NumField(); i++ {
+		fieldValue := errorValues.Field(i).String()
+		if fieldValue == "" {
+			continue
+		}
+
+		if errType.Field(i).Name == "Message" {
+			fieldValue = truncate(fieldValue, t.cfg.MaxErrorMessageLen)
+		}
+
+		if t.cfg.CompactErrors {
+			compact = append(compact, fmt.Sprintf("%s=%s", errType.Field(i).Tag.Get("json"), logfmtValue(fieldValue)))
+			continue
+		}
+
+		fmt.Fprintf(&details, "%s: %s\n", errType.Field(i).Name, fieldValue)
+	}
+
+	if len(compact) > 0 {
+		fmt.Fprintf(&details, "  error: %s\n", strings.Join(compact, " "))
+	}
+
+	if (t.cfg.ExpandErrors || t.cfg.QuietUntilError) && payload.Status >= 400 {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, []byte(evt.raw), "", "  "); err != nil {
+			t.log.Debug("Unable to expand malformed payload: ", err)
+			return details.String()
+		}
+
+		fmt.Fprintln(&details, wrapText(t.colorizeJSON(indented.String(), w), t.wrapWidth(w)))
+	}
+
+	return details.String()
+}
+
+// colorLineByStatus tints a whole request log line by its status class:
+// faint for successes, yellow for redirects and client errors, and bold red
+// for server errors.
+func colorLineByStatus(color aurora.Aurora, status int, line string) aurora.Value {
+	switch {
+	case status >= 500:
+		return color.Red(line).Bold()
+	case status >= 300:
+		return color.Yellow(line)
+	default:
+		return color.Faint(line)
+	}
+}
+
+// truncate shortens s to max characters, ending with an ellipsis. Zero means
+// no truncation.
+func truncate(s string, max int) string {
+	if max <= 0 {
+		return s
+	}
+
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+
+	return string(runes[:max]) + "…"
+}
+
+// stripQuery removes the query string from a request path.
+func stripQuery(path string) string {
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		return path[:i]
+	}
+
+	return path
+}
+
+// color returns the aurora instance used to format request logs written to
+// w, following ColorMode and NoColor.
+func (t *Tailer) color(w io.Writer) aurora.Aurora {
+	return aurora.NewAurora(t.useColors(w))
+}
+
+// colorizeJSON returns a colorized version of the JSON if w supports colors.
+func (t *Tailer) colorizeJSON(payload string, w io.Writer) string {
+	if !t.useColors(w) {
+		return payload
+	}
+
+	return t.forceColorizeJSON(payload)
+}
+
+// forceColorizeJSON returns a colorized version of the JSON regardless of the
+// output. Payloads that can't be colorized are returned as is so that the
+// event is never lost.
+func (t *Tailer) forceColorizeJSON(payload string) string {
+	if !json.Valid([]byte(payload)) {
+		t.onError(fmt.Errorf("unable to colorize malformed JSON payload: %s", payload))
+		return payload
+	}
+
+	colorized := ansi.ForceColorizeJSON(payload, false)
+	if colorized == "" && payload != "" {
+		t.onError(fmt.Errorf("colorizing JSON payload returned no output: %s", payload))
+		return payload
+	}
+
+	return colorized
+}
+
+// linkify returns text as a hyperlink to url if w supports it.
+func (t *Tailer) linkify(text, url string, w io.Writer) string {
+	if !t.useColors(w) {
+		return text
+	}
+
+	return ansi.ForceLinkify(text, url)
+}
+
+// printCount updates the in-place count of received request logs shown in
+// count-only mode.
+func (t *Tailer) printCount() {
+	fmt.Fprintf(t.cfg.Out, "\r%d request logs received", t.color(t.cfg.Out).Bold(t.count))
+}
+
+// printSummary prints the total number of request logs received during the
+// session.
+func (t *Tailer) printSummary() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.cfg.CountOnly && t.countInPlace() {
+		// Move past the in-place count line
+		fmt.Fprintln(t.cfg.Out)
+	}
+
+	out := t.statusOut()
+
+	fmt.Fprintf(out, "Total: %d request logs received\n", t.color(out).Bold(t.count))
+
+	if latency := t.latencies.quantiles(); latency != nil {
+		fmt.Fprintf(out, "Latency: %s\n", latency)
+	}
+}
@@ -0,0 +1,424 @@
+package logtailing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/ansi"
+)
+
+func TestCountOnly(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{CountOnly: true, NoColor: true, Out: &buf})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":200,"url":"/v1/charges"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":404,"url":"/v1/customers","error":{"code":"resource_missing"}}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/stripecli/sessions"}`))
+
+	require.NotContains(t, buf.String(), "/v1/charges")
+	require.NotContains(t, buf.String(), "resource_missing")
+	require.NotContains(t, buf.String(), "\n")
+	require.Equal(t, "\r1 request logs received\r2 request logs received", buf.String())
+
+	buf.Reset()
+	tailer.printSummary()
+
+	require.Equal(t, "\nTotal: 2 request logs received\n", buf.String())
+}
+
+func TestOutputsRenderEachFormat(t *testing.T) {
+	var text, jsonBuf bytes.Buffer
+
+	tailer := New(&Config{
+		Out:     &text,
+		Outputs: []Output{{Out: &jsonBuf, Format: "JSON"}},
+	})
+	require.NoError(t, tailer.validateConfig())
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":200,"url":"/v1/charges"}`))
+
+	require.Contains(t, text.String(), "[200] POST /v1/charges")
+	require.Equal(t, "{\"method\":\"POST\",\"status\":200,\"url\":\"/v1/charges\"}\n", jsonBuf.String())
+}
+
+func TestOutputsRejectDuplicates(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{
+		Out:          &buf,
+		OutputFormat: "JSON",
+		Outputs:      []Output{{Out: &buf, Format: "json"}},
+	})
+
+	err := tailer.Run(context.Background())
+	require.EqualError(t, err, "output 1 duplicates another output with the same writer and format (JSON)")
+	require.Empty(t, buf.String())
+}
+
+func TestOutputsAllowSameWriterWithDifferentFormats(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{
+		Out:     &buf,
+		Outputs: []Output{{Out: &buf, Format: "JSON"}, {Out: ioutil.Discard}},
+	})
+	require.NoError(t, tailer.validateConfig())
+}
+
+func TestStripQuery(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{StripQuery: true, Out: &buf})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers?email=jenny@example.com"}`))
+
+	require.Contains(t, buf.String(), "[200] GET /v1/customers [")
+	require.NotContains(t, buf.String(), "email")
+}
+
+func TestProtocol(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{NoColor: true, Out: &buf})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","protocol":"HTTP/2","request_id":"req_1","status":200,"url":"/v1/customers"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","request_id":"req_2","status":200,"url":"/v1/customers"}`))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], "[200] GET /v1/customers HTTP/2 [req_1]")
+	require.Contains(t, lines[1], "[200] GET /v1/customers [req_2]")
+}
+
+func TestProtocolJSONRoundTrip(t *testing.T) {
+	var payload EventPayload
+	require.NoError(t, json.Unmarshal([]byte(`{"method":"GET","protocol":"HTTP/1.1","status":200}`), &payload))
+	require.Equal(t, "HTTP/1.1", payload.Protocol)
+
+	encoded, err := json.Marshal(payload)
+	require.NoError(t, err)
+	require.Contains(t, string(encoded), `"protocol":"HTTP/1.1"`)
+
+	encoded, err = json.Marshal(EventPayload{Method: "GET"})
+	require.NoError(t, err)
+	require.NotContains(t, string(encoded), "protocol")
+}
+
+func TestAPIVersion(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{NoColor: true, Out: &buf})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"api_version":"2023-10-16","method":"GET","request_id":"req_1","status":200,"url":"/v1/customers"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","request_id":"req_2","status":200,"url":"/v1/customers"}`))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], "[200] GET /v1/customers [2023-10-16] [req_1]")
+	require.Contains(t, lines[1], "[200] GET /v1/customers [req_2]")
+}
+
+func TestAPIVersionJSONRoundTrip(t *testing.T) {
+	var payload EventPayload
+	require.NoError(t, json.Unmarshal([]byte(`{"api_version":"2023-10-16","method":"GET","status":200}`), &payload))
+	require.Equal(t, "2023-10-16", payload.APIVersion)
+
+	encoded, err := json.Marshal(payload)
+	require.NoError(t, err)
+	require.Contains(t, string(encoded), `"api_version":"2023-10-16"`)
+
+	encoded, err = json.Marshal(EventPayload{Method: "GET"})
+	require.NoError(t, err)
+	require.NotContains(t, string(encoded), "api_version")
+}
+
+func TestEmptyURLText(t *testing.T) {
+	message := requestLogMessage(`{"method":"GET","request_id":"req_1","status":200}`)
+
+	var buf bytes.Buffer
+
+	tailer := New(&Config{NoColor: true, Out: &buf})
+	tailer.processRequestLogEvent(message)
+	require.Contains(t, buf.String(), "[200] GET [View path in dashboard] [req_1]")
+
+	buf.Reset()
+
+	tailer = New(&Config{EmptyURLText: "(no path)", NoColor: true, Out: &buf})
+	tailer.processRequestLogEvent(message)
+	require.Contains(t, buf.String(), "[200] GET (no path) [req_1]")
+
+	buf.Reset()
+
+	tailer = New(&Config{NoColor: true, OmitEmptyURL: true, Out: &buf})
+	tailer.processRequestLogEvent(message)
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","protocol":"HTTP/2","request_id":"req_2","status":200}`))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], "[200] GET [req_1]")
+	require.Contains(t, lines[1], "[200] GET HTTP/2 [req_2]")
+	require.NotContains(t, buf.String(), "View path in dashboard")
+}
+
+func TestStripQueryPreservedInJSON(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{StripQuery: true, Out: &buf, OutputFormat: "JSON"})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers?email=jenny@example.com"}`))
+
+	require.Contains(t, buf.String(), `"url":"/v1/customers?email=jenny@example.com"`)
+}
+
+func TestOutputsWithIndependentFilters(t *testing.T) {
+	var all, serverErrors, posts bytes.Buffer
+
+	tailer := New(&Config{
+		NoColor: true,
+		Out:     &all,
+		Outputs: []Output{
+			{Out: &serverErrors, Filters: &LogFilters{FilterStatusCategory: []string{"server-error"}}},
+			{Out: &posts, Predicate: func(payload EventPayload) bool { return payload.Method == "POST" }},
+		},
+	})
+	require.NoError(t, tailer.validateConfig())
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":200,"url":"/v1/charges"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":503,"url":"/v1/customers"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":500,"url":"/v1/refunds"}`))
+
+	require.Equal(t, 3, strings.Count(all.String(), "\n"))
+
+	require.Equal(t, 2, strings.Count(serverErrors.String(), "\n"))
+	require.Contains(t, serverErrors.String(), "[503] GET /v1/customers")
+	require.Contains(t, serverErrors.String(), "[500] POST /v1/refunds")
+
+	require.Equal(t, 2, strings.Count(posts.String(), "\n"))
+	require.Contains(t, posts.String(), "[200] POST /v1/charges")
+	require.Contains(t, posts.String(), "[500] POST /v1/refunds")
+}
+
+func TestOutputsAllowSameWriterWithFilters(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{
+		Out:     &buf,
+		Outputs: []Output{{Out: &buf, Filters: &LogFilters{FilterStatusCode: []string{"500"}}}},
+	})
+
+	require.NoError(t, tailer.validateConfig())
+}
+
+func TestOutputsRejectUnsupportedFilters(t *testing.T) {
+	tailer := New(&Config{
+		Outputs: []Output{{Out: ioutil.Discard, Filters: &LogFilters{FilterIPAddress: []string{"127.0.0.1"}}}},
+	})
+
+	err := tailer.Run(context.Background())
+	require.EqualError(t, err, "output 1: the IP address filter can't be applied per output")
+}
+
+func TestMaxErrorMessageLen(t *testing.T) {
+	var buf, jsonBuf bytes.Buffer
+
+	tailer := New(&Config{
+		MaxErrorMessageLen: 10,
+		NoColor:            true,
+		Out:                &buf,
+		Outputs:            []Output{{Out: &jsonBuf, Format: "JSON"}},
+	})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":400,"url":"/v1/charges","error":{"code":"parameter_missing","message":"Missing required param: amount."}}`))
+
+	require.Contains(t, buf.String(), "Message: Missing re…\n")
+	require.Contains(t, buf.String(), "Code: parameter_missing\n")
+	require.Contains(t, jsonBuf.String(), "Missing required param: amount.")
+}
+
+func TestCompactErrors(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{CompactErrors: true, NoColor: true, Out: &buf})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":402,"url":"/v1/charges","request_id":"req_1","error":{"type":"card_error","code":"card_declined","decline_code":"generic_decline","message":"Your card was declined."}}`))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], "[402] POST /v1/charges [req_1]")
+	require.Equal(t, `  error: type=card_error code=card_declined decline_code=generic_decline message="Your card was declined."`, lines[1])
+}
+
+func TestErrorsOnSeparateLinesByDefault(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{NoColor: true, Out: &buf})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":402,"url":"/v1/charges","request_id":"req_1","error":{"type":"card_error","code":"card_declined","message":"Your card was declined."}}`))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Equal(t, []string{"Type: card_error", "Code: card_declined", "Message: Your card was declined."}, lines[1:])
+}
+
+func TestMaxErrorMessageLenDefault(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{NoColor: true, Out: &buf})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":400,"url":"/v1/charges","error":{"message":"Missing required param: amount."}}`))
+
+	require.Contains(t, buf.String(), "Message: Missing required param: amount.\n")
+}
+
+func TestTruncate(t *testing.T) {
+	require.Equal(t, "héllo", truncate("héllo", 5))
+	require.Equal(t, "hé…", truncate("héllo", 2))
+	require.Equal(t, "héllo", truncate("héllo", 0))
+}
+
+func TestJSONFallsBackToRawPayload(t *testing.T) {
+	ansi.ForceColors = true
+	defer func() { ansi.ForceColors = false }()
+
+	var buf bytes.Buffer
+
+	var errs []error
+
+	tailer := New(&Config{
+		OnError:      func(err error) { errs = append(errs, err) },
+		Out:          &buf,
+		OutputFormat: "JSON",
+	})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":200,"url":`))
+
+	require.Equal(t, "{\"method\":\"POST\",\"status\":200,\"url\":\n", buf.String())
+	require.Len(t, errs, 2)
+	require.Contains(t, errs[0].Error(), "received malformed payload")
+	require.Contains(t, errs[1].Error(), "unable to colorize malformed JSON payload")
+}
+
+func TestJSONColorized(t *testing.T) {
+	ansi.ForceColors = true
+	defer func() { ansi.ForceColors = false }()
+
+	var buf bytes.Buffer
+
+	tailer := New(&Config{
+		OnError:      func(err error) { t.Fatal(err) },
+		Out:          &buf,
+		OutputFormat: "JSON",
+	})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":200}`))
+
+	require.Contains(t, buf.String(), "\x1b[")
+	require.Contains(t, buf.String(), "POST")
+}
+
+func TestLineColorByStatus(t *testing.T) {
+	ansi.ForceColors = true
+	defer func() { ansi.ForceColors = false }()
+
+	var buf bytes.Buffer
+
+	tailer := New(&Config{LineColorByStatus: true, Out: &buf})
+
+	tailer.writeLine(Output{Out: &buf}, &event{payload: EventPayload{Method: "GET", Status: 200, URL: "/v1/charges"}}, 1)
+
+	line := strings.TrimSuffix(buf.String(), "\n")
+	require.True(t, strings.HasPrefix(line, "\x1b[2m"), "%q", line)
+	require.True(t, strings.HasSuffix(line, "\x1b[0m"), "%q", line)
+	// The status isn't colored on its own inside the line
+	require.Equal(t, 1, strings.Count(line, "\x1b[0m"), "%q", line)
+	require.Contains(t, line, "[200] GET /v1/charges")
+
+	buf.Reset()
+	tailer.writeLine(Output{Out: &buf}, &event{payload: EventPayload{Method: "POST", Status: 500, URL: "/v1/charges"}}, 1)
+
+	line = strings.TrimSuffix(buf.String(), "\n")
+	require.True(t, strings.HasPrefix(line, "\x1b[1;31m"), "%q", line)
+	require.True(t, strings.HasSuffix(line, "\x1b[0m"), "%q", line)
+	require.Contains(t, line, "[500] POST /v1/charges")
+}
+
+func TestLineColorByStatusNoColor(t *testing.T) {
+	ansi.ForceColors = true
+	defer func() { ansi.ForceColors = false }()
+
+	var buf bytes.Buffer
+
+	tailer := New(&Config{LineColorByStatus: true, NoColor: true, Out: &buf})
+
+	tailer.writeLine(Output{Out: &buf}, &event{payload: EventPayload{Method: "POST", Status: 500, URL: "/v1/charges"}}, 1)
+
+	require.NotContains(t, buf.String(), "\x1b[")
+}
+
+func TestJSONWritesToOut(t *testing.T) {
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	var out, logOut bytes.Buffer
+
+	tailer := New(&Config{Log: &log.Logger{Out: &logOut}, Out: &out, OutputFormat: "JSON"})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":200}`))
+	tailer.warnConnectFilter()
+
+	w.Close() // #nosec G104
+	os.Stdout = stdout
+
+	captured, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+
+	require.Empty(t, captured)
+	require.Equal(t, "{\"method\":\"POST\",\"status\":200}\n", out.String())
+	require.Contains(t, logOut.String(), "you specified the 'account' filter")
+}
+
+func TestPlainOutput(t *testing.T) {
+	var console, file bytes.Buffer
+
+	tailer := New(&Config{
+		ColorMode: "always",
+		Out:       &console,
+		Outputs:   []Output{{Out: &file, Plain: true}},
+	})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":402,"url":"/v1/charges","request_id":"req_1","error":{"code":"card_declined"}}`))
+
+	require.Contains(t, console.String(), "\x1b[")
+	require.NotContains(t, file.String(), "\x1b")
+	require.Equal(t, ansi.Strip(console.String()), file.String())
+	require.Contains(t, file.String(), "POST /v1/charges [req_1]\nCode: card_declined\n")
+}
+
+func TestPlainOutputFormatsWhenNotRendered(t *testing.T) {
+	var console, file bytes.Buffer
+
+	tailer := New(&Config{
+		ColorMode:    "always",
+		Out:          &console,
+		OutputFormat: outputFormatJSON,
+		Outputs:      []Output{{Out: &file, Plain: true}},
+	})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_1"}`))
+
+	require.Contains(t, console.String(), "\x1b[")
+	require.NotContains(t, file.String(), "\x1b")
+	require.Contains(t, file.String(), "[200] GET /v1/customers [req_1]\n")
+}
@@ -0,0 +1,157 @@
+package logtailing
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// outputQueue decouples writes to Out from the processing of request logs,
+// e.g. when Out is a laggy terminal over SSH. Writes are queued for a
+// dedicated goroutine and only block once the queue is full, in which case
+// the write is counted as a stall. Nothing is ever dropped. Once the queue
+// is closed, writes go straight to the underlying writer.
+type outputQueue struct {
+	w io.Writer
+
+	writes chan queuedWrite
+	done   chan struct{}
+	stalls int64
+
+	// mu guards closed, so that nothing is queued once writes is closed
+	mu     sync.RWMutex
+	closed bool
+}
+
+// queuedWrite is either data to write, or a flush marker closed once every
+// earlier write has been written.
+type queuedWrite struct {
+	data    []byte
+	flushed chan struct{}
+}
+
+func newOutputQueue(w io.Writer, size int) *outputQueue {
+	q := &outputQueue{
+		w:      w,
+		writes: make(chan queuedWrite, size),
+		done:   make(chan struct{}),
+	}
+
+	go q.run()
+
+	return q
+}
+
+// Write queues a copy of p, blocking while the queue is full. Errors of the
+// underlying writer aren't reported, as with the console output.
+func (q *outputQueue) Write(p []byte) (int, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return q.w.Write(p)
+	}
+
+	write := queuedWrite{data: append([]byte(nil), p...)}
+
+	select {
+	case q.writes <- write:
+	default:
+		atomic.AddInt64(&q.stalls, 1)
+		q.writes <- write
+	}
+
+	return len(p), nil
+}
+
+// Fd returns the file descriptor of the underlying writer, so that terminal
+// detection sees through the queue. It's invalid if the writer isn't a file.
+func (q *outputQueue) Fd() uintptr {
+	if f, ok := q.w.(interface{ Fd() uintptr }); ok {
+		return f.Fd()
+	}
+
+	return ^uintptr(0)
+}
+
+// flush blocks until every queued write has been written.
+func (q *outputQueue) flush() {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return
+	}
+
+	flushed := make(chan struct{})
+	q.writes <- queuedWrite{flushed: flushed}
+	<-flushed
+}
+
+// stallCount returns the number of writes that had to wait for the queue.
+func (q *outputQueue) stallCount() int {
+	return int(atomic.LoadInt64(&q.stalls))
+}
+
+// close waits for the queued writes to be written and stops the goroutine
+// writing them.
+func (q *outputQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return
+	}
+
+	q.closed = true
+	close(q.writes)
+	<-q.done
+}
+
+func (q *outputQueue) run() {
+	defer close(q.done)
+
+	for write := range q.writes {
+		if write.flushed != nil {
+			close(write.flushed)
+			continue
+		}
+
+		q.w.Write(write.data) // #nosec G104
+	}
+}
+
+// flushOutput waits for the writes queued for Out, if any, to be written.
+func (t *Tailer) flushOutput() {
+	if t.outQueue != nil {
+		t.outQueue.flush()
+	}
+}
+
+// closeOutput writes what's left in the queue of Out, if any, and stops
+// it. Later writes to Out aren't queued.
+func (t *Tailer) closeOutput() {
+	if t.outQueue != nil {
+		t.outQueue.close()
+	}
+}
+
+// probed returns the writer to look at to tell whether w is a terminal and
+// its width: the console rather than the queue wrapping it.
+func (t *Tailer) probed(w io.Writer) io.Writer {
+	if t.outQueue != nil && w == io.Writer(t.outQueue) {
+		return t.console
+	}
+
+	return w
+}
+
+// outputStalls returns the number of writes to Out that waited for the
+// queue.
+func (t *Tailer) outputStalls() int {
+	if t.outQueue == nil {
+		return 0
+	}
+
+	return t.outQueue.stallCount()
+}
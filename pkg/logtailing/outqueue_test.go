@@ -0,0 +1,141 @@
+package logtailing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/ansi"
+)
+
+// slowWriter blocks every write until it's released.
+type slowWriter struct {
+	syncBuffer
+	release chan struct{}
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return w.syncBuffer.Write(p)
+}
+
+func queueMessage(i int) string {
+	return fmt.Sprintf(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_%d"}`, i)
+}
+
+func TestOutputQueueBuffersSlowWriter(t *testing.T) {
+	out := &slowWriter{release: make(chan struct{})}
+
+	tailer := New(&Config{OutputFormat: "JSON", OutputQueueSize: 5, Out: out})
+
+	// Up to the queue size, processing never waits for the writer
+	for i := 0; i < 5; i++ {
+		tailer.processRequestLogEvent(requestLogMessage(queueMessage(i)))
+	}
+
+	require.Zero(t, tailer.report().OutputStalls)
+	require.Empty(t, out.String())
+
+	close(out.release)
+	tailer.flushOutput()
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 5)
+
+	for i, line := range lines {
+		require.Contains(t, line, fmt.Sprintf(`"req_%d"`, i))
+	}
+}
+
+func TestOutputQueueStalls(t *testing.T) {
+	out := &slowWriter{release: make(chan struct{})}
+
+	tailer := New(&Config{OutputFormat: "JSON", OutputQueueSize: 2, Out: out})
+
+	processed := make(chan struct{})
+
+	go func() {
+		defer close(processed)
+
+		for i := 0; i < 10; i++ {
+			tailer.processRequestLogEvent(requestLogMessage(queueMessage(i)))
+		}
+	}()
+
+	// Processing waits for the queue once it's full, instead of dropping
+	require.Eventually(t, func() bool { return tailer.outputStalls() > 0 }, time.Second, time.Millisecond)
+
+	select {
+	case <-processed:
+		t.Fatal("processing didn't wait for the queue")
+	default:
+	}
+
+	close(out.release)
+	<-processed
+	tailer.flushOutput()
+
+	require.Equal(t, tailer.outputStalls(), tailer.report().OutputStalls)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 10)
+
+	for i, line := range lines {
+		require.Contains(t, line, fmt.Sprintf(`"req_%d"`, i))
+	}
+}
+
+func TestOutputQueueClosedByRun(t *testing.T) {
+	var out syncBuffer
+
+	tailer := New(&Config{
+		OutputFormat:    "JSON",
+		OutputQueueSize: 5,
+		Out:             &out,
+		ReplayFile:      writeReplayFile(t, queueMessage(1), queueMessage(2)),
+	})
+	require.NoError(t, tailer.Run(context.Background()))
+
+	// The queue is drained and its goroutine stopped
+	require.Equal(t, 2, strings.Count(out.String(), "\n"))
+
+	select {
+	case <-tailer.outQueue.done:
+	default:
+		t.Fatal("expected the output queue to be stopped")
+	}
+
+	// Later writes go straight to Out
+	fmt.Fprintln(tailer.cfg.Out, "after")
+	require.True(t, strings.HasSuffix(out.String(), "after\n"))
+
+	tailer.flushOutput()
+}
+
+func TestOutputQueueKeepsConsole(t *testing.T) {
+	var out syncBuffer
+
+	defer func() { isTerminal = ansi.IsTerminal }()
+	isTerminal = func(w io.Writer) bool { return w == io.Writer(&out) }
+
+	tailer := New(&Config{NoColor: true, OutputQueueSize: 5, Out: &out, WatchBell: true, WatchRequestID: "req_1"})
+
+	tailer.processRequestLogEvent(requestLogMessage(queueMessage(1)))
+	tailer.flushOutput()
+
+	// Terminal checks look through the queue
+	require.True(t, strings.HasPrefix(out.String(), "\a"), out.String())
+
+	// Outputs sharing the console are recognized as the console
+	tailer = New(&Config{
+		OutputQueueSize: 5,
+		Out:             &out,
+		Outputs:         []Output{{Out: &out}},
+	})
+	require.EqualError(t, tailer.validateConfig(), "output 1 duplicates another output with the same writer and format (default)")
+}
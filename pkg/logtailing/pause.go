@@ -0,0 +1,126 @@
+package logtailing
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+)
+
+const (
+	defaultPauseBufferSize = 1000
+
+	pauseKey = ' '
+)
+
+// pausedEvent is an event received while the output was paused.
+type pausedEvent struct {
+	evt      *event
+	jsonLine string
+}
+
+// pauseEnabled reports whether the output can be paused from the keyboard.
+func (t *Tailer) pauseEnabled() bool {
+	return t.cfg.Pausable && !t.cfg.CountOnly && isTerminal(t.console)
+}
+
+// startKeyboard reads key presses from PauseInput in the background. When
+// reading from a terminal, it is switched to unbuffered input so that keys
+// don't need to be followed by Enter.
+func (t *Tailer) startKeyboard() {
+	if f, ok := t.cfg.PauseInput.(*os.File); ok && isTerminal(f) {
+		restore, err := enableCbreak(int(f.Fd()))
+		if err != nil {
+			t.log.Debug("Unable to read single key presses, press Enter after each key: ", err)
+		} else {
+			t.restoreInput = restore
+		}
+	}
+
+	go t.readKeys()
+}
+
+// stopKeyboard restores the terminal's input mode.
+func (t *Tailer) stopKeyboard() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.restoreInput != nil {
+		t.restoreInput()
+		t.restoreInput = nil
+	}
+}
+
+func (t *Tailer) readKeys() {
+	keys := bufio.NewReader(t.cfg.PauseInput)
+
+	for {
+		key, err := keys.ReadByte()
+		if err != nil {
+			return
+		}
+
+		t.handleKey(key)
+	}
+}
+
+// handleKey toggles between pausing and resuming the output when the pause
+// key is pressed. Other keys are ignored.
+func (t *Tailer) handleKey(key byte) {
+	if key != pauseKey {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.paused {
+		t.resume()
+	} else {
+		t.pause()
+	}
+}
+
+// pause stops displaying events. The caller must hold t.mu.
+func (t *Tailer) pause() {
+	t.paused = true
+
+	msg := fmt.Sprintf("Paused, press space to resume (buffering up to %d request logs)", t.cfg.PauseBufferSize)
+	t.clearHighlight()
+	fmt.Fprintln(t.statusOut(), t.color(t.statusOut()).Faint(msg))
+}
+
+// resume displays the events buffered while paused and resumes displaying
+// events as they are received. The caller must hold t.mu.
+func (t *Tailer) resume() {
+	t.paused = false
+
+	for _, paused := range t.pauseBuffer {
+		t.display(paused.evt, paused.jsonLine)
+	}
+
+	t.pauseBuffer = nil
+
+	if t.pauseDropped > 0 {
+		msg := fmt.Sprintf("%d request logs were dropped while paused because the buffer was full", t.pauseDropped)
+		t.clearHighlight()
+		fmt.Fprintln(t.statusOut(), t.color(t.statusOut()).Yellow(msg))
+
+		t.pauseDropped = 0
+	}
+}
+
+// bufferEvent holds an event received while paused, dropping it if the
+// buffer is full. The caller must hold t.mu.
+func (t *Tailer) bufferEvent(evt *event, jsonLine string) {
+	if len(t.pauseBuffer) >= t.cfg.PauseBufferSize {
+		if t.pauseDropped == 0 {
+			t.log.Warnf("Pause buffer is full, dropping request logs until resumed")
+		}
+
+		t.pauseDropped++
+
+		return
+	}
+
+	t.pauseBuffer = append(t.pauseBuffer, pausedEvent{evt: evt, jsonLine: jsonLine})
+}
@@ -0,0 +1,108 @@
+package logtailing
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/ansi"
+)
+
+func TestPauseAndResume(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{NoColor: true, Out: &buf, Pausable: true, PauseBufferSize: 10})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/charges","request_id":"req_1"}`))
+
+	tailer.handleKey('x')
+	require.False(t, tailer.paused)
+
+	tailer.handleKey(' ')
+	require.True(t, tailer.paused)
+
+	buf.Reset()
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/charges","request_id":"req_2"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":404,"url":"/v1/customers","request_id":"req_3"}`))
+
+	require.Empty(t, buf.String())
+	require.Len(t, tailer.pauseBuffer, 2)
+	require.Equal(t, 3, tailer.count)
+
+	tailer.handleKey(' ')
+	require.False(t, tailer.paused)
+	require.Empty(t, tailer.pauseBuffer)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], "[req_2]")
+	require.Contains(t, lines[1], "[req_3]")
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/charges","request_id":"req_4"}`))
+	require.Contains(t, buf.String(), "[req_4]")
+}
+
+func TestPauseBufferCap(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{NoColor: true, Out: &buf, Pausable: true, PauseBufferSize: 2})
+
+	tailer.handleKey(' ')
+
+	for i := 0; i < 5; i++ {
+		tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/charges"}`))
+	}
+
+	require.Len(t, tailer.pauseBuffer, 2)
+	require.Equal(t, 3, tailer.pauseDropped)
+
+	tailer.handleKey(' ')
+
+	require.Equal(t, 2, strings.Count(buf.String(), "GET /v1/charges"))
+	require.Contains(t, buf.String(), "3 request logs were dropped while paused because the buffer was full\n")
+	require.Equal(t, 0, tailer.pauseDropped)
+}
+
+func TestPauseFromInput(t *testing.T) {
+	defer func() { isTerminal = ansi.IsTerminal }()
+	isTerminal = func(io.Writer) bool { return true }
+
+	input, keys := io.Pipe()
+	defer keys.Close()
+
+	var buf syncBuffer
+
+	tailer := New(&Config{NoColor: true, Out: &buf, Pausable: true, PauseInput: input})
+	require.True(t, tailer.pauseEnabled())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tailer.startSinks(ctx)
+
+	keys.Write([]byte(" "))
+
+	require.Eventually(t, func() bool {
+		return strings.Contains(buf.String(), "Paused, press space to resume")
+	}, time.Second, 5*time.Millisecond)
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/charges"}`))
+	require.NotContains(t, buf.String(), "/v1/charges")
+
+	keys.Write([]byte(" "))
+
+	require.Eventually(t, func() bool {
+		return strings.Contains(buf.String(), "GET /v1/charges")
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestPauseDisabledWhenNotTerminal(t *testing.T) {
+	require.False(t, New(&Config{Pausable: true, Out: &bytes.Buffer{}}).pauseEnabled())
+	require.False(t, New(&Config{Out: &bytes.Buffer{}}).pauseEnabled())
+}
@@ -0,0 +1,151 @@
+package logtailing
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/stripe/stripe-cli/pkg/stripeauth"
+)
+
+// PreflightResult describes which of the checks run by Preflight passed.
+// When several websocket features are streamed, they're checked in turn and
+// the result describes the first one that failed, or the last one checked.
+type PreflightResult struct {
+	// Feature is the websocket feature the other fields relate to
+	Feature string
+
+	// FeatureAuthorized is set when Stripe authorized a session for the
+	// feature
+	FeatureAuthorized bool
+
+	// WebSocketURL is the URL of the websocket the session was authorized
+	// for, as dialed
+	WebSocketURL string
+
+	// DialOK is set when a TCP connection to the websocket host succeeded
+	DialOK bool
+
+	// HandshakeOK is set when the websocket handshake succeeded
+	HandshakeOK bool
+
+	// Latency is the time the websocket handshake took
+	Latency time.Duration
+}
+
+// OK reports whether every check passed.
+func (r *PreflightResult) OK() bool {
+	return r.FeatureAuthorized && r.DialOK && r.HandshakeOK
+}
+
+// Preflight checks that request logs can be tailed, without streaming any:
+// it authorizes a session for each websocket feature, connects to the
+// websocket host and completes a single handshake. Unlike Diagnose, nothing
+// is printed. The result tells which checks passed, and is returned along
+// with the error of the first failing check.
+func (t *Tailer) Preflight(ctx context.Context) (*PreflightResult, error) {
+	if err := t.validateConfig(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, diagnoseTimeout)
+	defer cancel()
+
+	return t.preflight(ctx, func(name string, check func() error) error {
+		if err := check(); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+
+		return nil
+	})
+}
+
+// preflight runs the checks shared by Preflight and Diagnose, each through
+// step, which is given its name and returns its error, if any. It stops at
+// the first failing check.
+func (t *Tailer) preflight(ctx context.Context, step func(name string, check func() error) error) (*PreflightResult, error) {
+	filters, err := t.FiltersJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	var result *PreflightResult
+
+	for _, feature := range t.features() {
+		result = &PreflightResult{Feature: feature}
+
+		var session *stripeauth.StripeCLISession
+
+		err := step(fmt.Sprintf("authorizing a %s session", feature), func() error {
+			var err error
+			if session, err = t.stripeAuthClient.Authorize(ctx, t.cfg.DeviceName, feature, &filters); err != nil {
+				return err
+			}
+
+			if session.WebSocketAuthorizedFeature != feature {
+				return fmt.Errorf("the session isn't authorized for the %s feature", feature)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return result, err
+		}
+
+		result.FeatureAuthorized = true
+
+		client := t.newWebSocketClient(session, feature)
+		result.WebSocketURL = client.DialURL()
+
+		err = step(fmt.Sprintf("connecting to the %s websocket host", feature), func() error {
+			return dialWebSocketHost(ctx, result.WebSocketURL)
+		})
+		if err != nil {
+			return result, err
+		}
+
+		result.DialOK = true
+
+		start := time.Now()
+
+		err = step(fmt.Sprintf("completing the %s websocket handshake", feature), func() error {
+			return client.Probe(ctx)
+		})
+		if err != nil {
+			return result, err
+		}
+
+		result.HandshakeOK = true
+		result.Latency = time.Since(start)
+	}
+
+	return result, nil
+}
+
+// dialWebSocketHost opens a TCP connection to the host of a websocket URL,
+// and closes it straight away.
+func dialWebSocketHost(ctx context.Context, wsURL string) error {
+	u, err := url.Parse(wsURL)
+	if err != nil {
+		return err
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "443"
+		if u.Scheme == "ws" {
+			port = "80"
+		}
+	}
+
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return err
+	}
+
+	return conn.Close()
+}
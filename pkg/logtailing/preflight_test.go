@@ -0,0 +1,107 @@
+package logtailing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/stripeauth"
+)
+
+func TestPreflight(t *testing.T) {
+	ts := newDiagnoseServer(t, "")
+	defer ts.Close()
+
+	var out bytes.Buffer
+
+	result, err := New(diagnoseConfig(ts.URL, &out)).Preflight(context.Background())
+	require.NoError(t, err)
+
+	require.True(t, result.OK())
+	require.Equal(t, "request_logs", result.Feature)
+	require.True(t, result.FeatureAuthorized)
+	require.Equal(t, "ws://"+ts.Listener.Addr().String()+"/subscribe?websocket_feature=request_logs", result.WebSocketURL)
+	require.True(t, result.DialOK)
+	require.True(t, result.HandshakeOK)
+	require.Greater(t, int64(result.Latency), int64(0))
+
+	// Nothing is printed
+	require.Empty(t, out.String())
+}
+
+func TestPreflightHandshakeFailure(t *testing.T) {
+	ts := newDiagnoseServer(t, "Unknown WebSocket ID.")
+	defer ts.Close()
+
+	result, err := New(diagnoseConfig(ts.URL, &bytes.Buffer{})).Preflight(context.Background())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Unknown WebSocket ID.")
+
+	require.False(t, result.OK())
+	require.True(t, result.FeatureAuthorized)
+	require.True(t, result.DialOK)
+	require.False(t, result.HandshakeOK)
+	require.Zero(t, result.Latency)
+}
+
+func TestPreflightDialFailure(t *testing.T) {
+	// Grab a port that nothing listens on
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	closedAddr := listener.Addr().String()
+	listener.Close()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(stripeauth.StripeCLISession{
+			WebSocketID:                "websocket-1",
+			WebSocketURL:               "wss://" + closedAddr + "/subscribe",
+			WebSocketAuthorizedFeature: "request_logs",
+		})
+	}))
+	defer ts.Close()
+
+	result, err := New(diagnoseConfig(ts.URL, &bytes.Buffer{})).Preflight(context.Background())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "connecting to the request_logs websocket host")
+
+	require.True(t, result.FeatureAuthorized)
+	require.Equal(t, "ws://"+closedAddr+"/subscribe?websocket_feature=request_logs", result.WebSocketURL)
+	require.False(t, result.DialOK)
+	require.False(t, result.HandshakeOK)
+}
+
+func TestPreflightFeatureNotAuthorized(t *testing.T) {
+	ts := newDiagnoseServer(t, "")
+	defer ts.Close()
+
+	cfg := diagnoseConfig(ts.URL, &bytes.Buffer{})
+	cfg.WebSocketFeature = "webhook_payloads"
+
+	result, err := New(cfg).Preflight(context.Background())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "isn't authorized for the webhook_payloads feature")
+
+	require.Equal(t, "webhook_payloads", result.Feature)
+	require.False(t, result.FeatureAuthorized)
+	require.False(t, result.DialOK)
+}
+
+func TestPreflightSessionFailure(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	result, err := New(diagnoseConfig(ts.URL, &bytes.Buffer{})).Preflight(context.Background())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "authorizing a request_logs session")
+
+	require.Equal(t, &PreflightResult{Feature: "request_logs"}, result)
+}
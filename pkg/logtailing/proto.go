@@ -0,0 +1,114 @@
+package logtailing
+
+// The request logs sent to gRPC collectors and written by the protobuf output
+// format are encoded as the following protobuf messages, which mirror
+// EventPayload:
+//
+//	syntax = "proto3";
+//
+//	package stripe.cli.logtailing.v1;
+//
+//	message Event {
+//	  int64 created_at = 1;
+//	  bool livemode = 2;
+//	  string method = 3;
+//	  string request_id = 4;
+//	  int32 status = 5;
+//	  string url = 6;
+//	  Error error = 7;
+//	}
+//
+//	message Error {
+//	  string type = 1;
+//	  string charge = 2;
+//	  string code = 3;
+//	  string decline_code = 4;
+//	  string message = 5;
+//	  string param = 6;
+//	}
+//
+// The messages are small and flat enough that they are encoded by hand
+// rather than with generated code.
+
+const (
+	protoWireVarint = 0
+	protoWireBytes  = 2
+)
+
+// encodeEvent returns the protobuf encoding of the payload as an Event
+// message. Fields with zero values are omitted, as in proto3.
+func encodeEvent(payload *EventPayload) []byte {
+	var b []byte
+
+	b = appendProtoVarint(b, 1, uint64(payload.CreatedAt))
+	b = appendProtoBool(b, 2, payload.Livemode)
+	b = appendProtoString(b, 3, payload.Method)
+	b = appendProtoString(b, 4, payload.RequestID)
+	b = appendProtoVarint(b, 5, uint64(payload.Status))
+	b = appendProtoString(b, 6, payload.URL)
+
+	if errorMessage := encodeError(&payload.Error); len(errorMessage) > 0 {
+		b = appendProtoBytes(b, 7, errorMessage)
+	}
+
+	return b
+}
+
+func encodeError(e *RedactedError) []byte {
+	var b []byte
+
+	b = appendProtoString(b, 1, e.Type)
+	b = appendProtoString(b, 2, e.Charge)
+	b = appendProtoString(b, 3, e.Code)
+	b = appendProtoString(b, 4, e.DeclineCode)
+	b = appendProtoString(b, 5, e.Message)
+	b = appendProtoString(b, 6, e.Param)
+
+	return b
+}
+
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+
+	return append(b, byte(v))
+}
+
+func appendProtoTag(b []byte, field int, wireType int) []byte {
+	return appendVarint(b, uint64(field)<<3|uint64(wireType))
+}
+
+func appendProtoVarint(b []byte, field int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+
+	b = appendProtoTag(b, field, protoWireVarint)
+
+	return appendVarint(b, v)
+}
+
+func appendProtoBool(b []byte, field int, v bool) []byte {
+	if !v {
+		return b
+	}
+
+	return appendProtoVarint(b, field, 1)
+}
+
+func appendProtoString(b []byte, field int, s string) []byte {
+	if s == "" {
+		return b
+	}
+
+	return appendProtoBytes(b, field, []byte(s))
+}
+
+func appendProtoBytes(b []byte, field int, data []byte) []byte {
+	b = appendProtoTag(b, field, protoWireBytes)
+	b = appendVarint(b, uint64(len(data)))
+
+	return append(b, data...)
+}
@@ -0,0 +1,115 @@
+package logtailing
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// decodeEvent decodes an Event message, as a collector would.
+func decodeEvent(b []byte) (EventPayload, error) {
+	var payload EventPayload
+
+	err := decodeProtoFields(b, func(field int, v uint64, data []byte) error {
+		switch field {
+		case 1:
+			payload.CreatedAt = int(int64(v))
+		case 2:
+			payload.Livemode = v != 0
+		case 3:
+			payload.Method = string(data)
+		case 4:
+			payload.RequestID = string(data)
+		case 5:
+			payload.Status = int(int32(v))
+		case 6:
+			payload.URL = string(data)
+		case 7:
+			return decodeProtoFields(data, func(field int, v uint64, data []byte) error {
+				values := []*string{
+					&payload.Error.Type,
+					&payload.Error.Charge,
+					&payload.Error.Code,
+					&payload.Error.DeclineCode,
+					&payload.Error.Message,
+					&payload.Error.Param,
+				}
+				if field >= 1 && field <= len(values) {
+					*values[field-1] = string(data)
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+
+	return payload, err
+}
+
+func decodeProtoFields(b []byte, fn func(field int, v uint64, data []byte) error) error {
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			return fmt.Errorf("invalid tag")
+		}
+
+		b = b[n:]
+
+		v, n := binary.Uvarint(b)
+		if n <= 0 {
+			return fmt.Errorf("invalid varint")
+		}
+
+		b = b[n:]
+
+		var data []byte
+
+		switch tag & 7 {
+		case protoWireVarint:
+		case protoWireBytes:
+			if uint64(len(b)) < v {
+				return fmt.Errorf("truncated field")
+			}
+
+			data, b = b[:v], b[v:]
+		default:
+			return fmt.Errorf("unexpected wire type %d", tag&7)
+		}
+
+		if err := fn(int(tag>>3), v, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func TestEncodeEvent(t *testing.T) {
+	payload := EventPayload{
+		CreatedAt: 1704207600,
+		Livemode:  true,
+		Method:    "POST",
+		RequestID: "req_123",
+		Status:    402,
+		URL:       "/v1/charges",
+		Error: RedactedError{
+			Type:        "card_error",
+			Code:        "card_declined",
+			DeclineCode: "insufficient_funds",
+			Message:     "Your card has insufficient funds.",
+		},
+	}
+
+	decoded, err := decodeEvent(encodeEvent(&payload))
+	require.NoError(t, err)
+	require.Equal(t, payload, decoded)
+}
+
+func TestEncodeEventOmitsZeroValues(t *testing.T) {
+	require.Empty(t, encodeEvent(&EventPayload{}))
+
+	// Field 5 (status) as a varint
+	require.Equal(t, []byte{5<<3 | protoWireVarint, 0xc8, 0x01}, encodeEvent(&EventPayload{Status: 200}))
+}
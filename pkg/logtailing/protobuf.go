@@ -0,0 +1,57 @@
+package logtailing
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+// binaryOut reports whether Out is reserved for the length-prefixed frames
+// of the protobuf format, in which case status messages are written to
+// Log.Out instead.
+func (t *Tailer) binaryOut() bool {
+	return strings.EqualFold(t.cfg.OutputFormat, outputFormatProtobuf)
+}
+
+// protobufOutputs reports whether any of the outputs uses the protobuf
+// format.
+func (t *Tailer) protobufOutputs() bool {
+	for _, output := range t.outputs {
+		if strings.EqualFold(output.Format, outputFormatProtobuf) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateProtobuf checks that the protobuf format of any output isn't
+// combined with modes that write text instead of request logs.
+func (t *Tailer) validateProtobuf() error {
+	if !t.protobufOutputs() {
+		return nil
+	}
+
+	switch {
+	case t.cfg.CountOnly:
+		return errors.New("the protobuf format can't be combined with count-only mode")
+	case t.cfg.Transitions:
+		return errors.New("the protobuf format can't be combined with transitions")
+	case t.cfg.NewPathsOnly:
+		return errors.New("the protobuf format can't be combined with new paths only")
+	}
+
+	return nil
+}
+
+// writeProtobuf writes the request log as an Event message, prefixed with
+// its length as a varint, the framing used by e.g. Java's writeDelimitedTo
+// and Go's protodelim.
+func (t *Tailer) writeProtobuf(w io.Writer, evt *event) {
+	message := encodeEvent(&evt.payload)
+	frame := append(appendVarint(nil, uint64(len(message))), message...)
+
+	if _, err := w.Write(frame); err != nil {
+		t.onError(err)
+	}
+}
@@ -0,0 +1,85 @@
+package logtailing
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// readFrames splits length-prefixed frames back into Event messages.
+func readFrames(t *testing.T, b []byte) []EventPayload {
+	var payloads []EventPayload
+
+	for len(b) > 0 {
+		size, n := binary.Uvarint(b)
+		require.Greater(t, n, 0)
+		require.GreaterOrEqual(t, uint64(len(b[n:])), size)
+
+		payload, err := decodeEvent(b[n : n+int(size)])
+		require.NoError(t, err)
+
+		payloads = append(payloads, payload)
+		b = b[n+int(size):]
+	}
+
+	return payloads
+}
+
+func TestProtobufFormat(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{ColorMode: "always", Heartbeat: time.Second, OutputFormat: "protobuf", Out: &buf})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"created_at":1704207845,"livemode":true,"method":"POST","request_id":"req_1","status":402,"url":"/v1/charges","error":{"code":"card_declined","type":"card_error"}}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"created_at":1704207846,"method":"GET","request_id":"req_2","status":200,"url":"/v1/customers"}`))
+
+	// Binary frames only, without colors or newlines
+	require.NotContains(t, buf.String(), "\x1b[")
+
+	payloads := readFrames(t, buf.Bytes())
+	require.Len(t, payloads, 2)
+
+	require.Equal(t, EventPayload{
+		CreatedAt: 1704207845,
+		Livemode:  true,
+		Method:    "POST",
+		RequestID: "req_1",
+		Status:    402,
+		URL:       "/v1/charges",
+		Error:     RedactedError{Code: "card_declined", Type: "card_error"},
+	}, payloads[0])
+	require.Equal(t, "req_2", payloads[1].RequestID)
+	require.Equal(t, 200, payloads[1].Status)
+
+	// Status messages are kept off the binary output
+	require.False(t, tailer.heartbeatEnabled())
+	require.Equal(t, tailer.cfg.Log.Out, tailer.statusOut())
+}
+
+func TestProtobufFormatInvalidCombinations(t *testing.T) {
+	for _, cfg := range []*Config{
+		{CountOnly: true},
+		{Transitions: true},
+		{NewPathsOnly: true},
+	} {
+		cfg.OutputFormat = "PROTOBUF"
+		cfg.Out = &bytes.Buffer{}
+
+		require.Error(t, New(cfg).validateConfig())
+	}
+
+	// The format of secondary outputs is checked too
+	for _, cfg := range []*Config{
+		{CountOnly: true},
+		{Transitions: true},
+		{NewPathsOnly: true},
+	} {
+		cfg.Out = &bytes.Buffer{}
+		cfg.Outputs = []Output{{Out: &bytes.Buffer{}, Format: "protobuf"}}
+
+		require.Error(t, New(cfg).validateConfig())
+	}
+}
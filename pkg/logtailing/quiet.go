@@ -0,0 +1,28 @@
+package logtailing
+
+import "fmt"
+
+// quiets reports whether the request log is kept off the console output by
+// QuietUntilError. It's still counted and handed to the sinks.
+func (t *Tailer) quiets(payload *EventPayload) bool {
+	return t.cfg.QuietUntilError && payload.Status < 400
+}
+
+// quietsOutput reports whether the request log is kept off the output by
+// QuietUntilError, which only mutes the console.
+func (t *Tailer) quietsOutput(output *Output, payload *EventPayload) bool {
+	return t.quiets(payload) && sameWriter(output.Out, t.cfg.Out)
+}
+
+// heartbeatMessage returns the heartbeat line. With QuietUntilError, it
+// tells how many request logs were kept quiet since the last line printed,
+// so that users know the tail is still receiving them.
+func (t *Tailer) heartbeatMessage() string {
+	timestamp := t.cfg.Now().Format("15:04:05")
+
+	if t.cfg.QuietUntilError && t.quieted > 0 {
+		return fmt.Sprintf("...still tailing (%d request logs, no errors) [%s]", t.quieted, timestamp)
+	}
+
+	return fmt.Sprintf("...still tailing (no events) [%s]", timestamp)
+}
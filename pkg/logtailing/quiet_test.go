@@ -0,0 +1,83 @@
+package logtailing
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuietUntilError(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{NoColor: true, Out: &buf, QuietUntilError: true, RecentSize: 10})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_ok"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":302,"url":"/v1/redirect","request_id":"req_redirect"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":402,"url":"/v1/charges","request_id":"req_declined","error":{"code":"card_declined"}}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":500,"url":"/v1/customers","request_id":"req_failed"}`))
+
+	out := buf.String()
+	require.NotContains(t, out, "req_ok")
+	require.NotContains(t, out, "req_redirect")
+	require.Contains(t, out, "[req_declined]")
+	require.Contains(t, out, "[req_failed]")
+
+	// Errors are printed in full
+	require.Contains(t, out, `"code": "card_declined"`)
+
+	// Quiet request logs are still counted and kept
+	require.Equal(t, []string{"req_ok", "req_redirect", "req_declined", "req_failed"}, recentRequestIDs(tailer.Recent()))
+
+	buf.Reset()
+	tailer.finish()
+	require.Contains(t, buf.String(), "Total: 4 request logs received")
+}
+
+func TestQuietUntilErrorOnlyMutesConsole(t *testing.T) {
+	var console, file bytes.Buffer
+
+	tailer := New(&Config{
+		NoColor:         true,
+		Out:             &console,
+		Outputs:         []Output{{Out: &file, Format: "JSON"}},
+		QuietUntilError: true,
+	})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_ok"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":500,"url":"/v1/customers","request_id":"req_failed"}`))
+
+	require.NotContains(t, console.String(), "req_ok")
+	require.Contains(t, console.String(), "[req_failed]")
+
+	// Secondary outputs get every request log
+	require.Contains(t, file.String(), `"request_id":"req_ok"`)
+	require.Contains(t, file.String(), `"request_id":"req_failed"`)
+}
+
+func TestQuietUntilErrorHeartbeat(t *testing.T) {
+	var buf syncBuffer
+
+	tailer := New(&Config{Heartbeat: 50 * time.Millisecond, NoColor: true, Out: &buf, QuietUntilError: true})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go tailer.runHeartbeat(ctx)
+
+	// Quiet request logs don't reset the heartbeat, which reports them
+	for i := 0; i < 3; i++ {
+		tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers"}`))
+	}
+
+	require.Eventually(t, func() bool {
+		return strings.Contains(buf.String(), "...still tailing (3 request logs, no errors) [")
+	}, time.Second, 5*time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		return strings.Contains(buf.String(), "...still tailing (no events) [")
+	}, time.Second, 5*time.Millisecond)
+}
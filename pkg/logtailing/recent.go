@@ -0,0 +1,54 @@
+package logtailing
+
+import "sync"
+
+// recentEvents is a fixed-size ring buffer of the latest request logs. It
+// has its own lock so that Recent doesn't wait on event processing, which
+// may be blocked by a slow Events consumer.
+type recentEvents struct {
+	mu       sync.Mutex
+	payloads []EventPayload
+	next     int
+	full     bool
+}
+
+func newRecentEvents(size int) *recentEvents {
+	return &recentEvents{payloads: make([]EventPayload, size)}
+}
+
+// add records a payload, evicting the oldest one if the buffer is full.
+func (r *recentEvents) add(payload EventPayload) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.payloads[r.next] = payload
+
+	r.next = (r.next + 1) % len(r.payloads)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// list returns a copy of the recorded payloads, oldest first.
+func (r *recentEvents) list() []EventPayload {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		return append([]EventPayload(nil), r.payloads[:r.next]...)
+	}
+
+	return append(append([]EventPayload(nil), r.payloads[r.next:]...), r.payloads[:r.next]...)
+}
+
+// Recent returns the last RecentSize request logs, after filtering,
+// middleware and redaction, oldest first. It's safe to call while Run is
+// processing request logs, e.g. to render a scrollback in a UI, and returns
+// nil when RecentSize isn't set.
+func (t *Tailer) Recent() []EventPayload {
+	if t.recent == nil {
+		return nil
+	}
+
+	return t.recent.list()
+}
@@ -0,0 +1,108 @@
+package logtailing
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func recentRequestIDs(payloads []EventPayload) []string {
+	ids := make([]string, len(payloads))
+	for i, payload := range payloads {
+		ids[i] = payload.RequestID
+	}
+
+	return ids
+}
+
+func TestRecent(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{NoColor: true, Out: &buf, RecentSize: 3})
+	require.Empty(t, tailer.Recent())
+
+	for i := 1; i <= 2; i++ {
+		tailer.processRequestLogEvent(requestLogMessage(fmt.Sprintf(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_%d"}`, i)))
+	}
+
+	require.Equal(t, []string{"req_1", "req_2"}, recentRequestIDs(tailer.Recent()))
+
+	// The oldest request logs are evicted
+	for i := 3; i <= 5; i++ {
+		tailer.processRequestLogEvent(requestLogMessage(fmt.Sprintf(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_%d"}`, i)))
+	}
+
+	recent := tailer.Recent()
+	require.Equal(t, []string{"req_3", "req_4", "req_5"}, recentRequestIDs(recent))
+
+	// Callers get a copy
+	recent[0].RequestID = "changed"
+	require.Equal(t, "req_3", tailer.Recent()[0].RequestID)
+}
+
+func TestRecentFiltered(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{ErrorsOnly: true, NoColor: true, Out: &buf, RecentSize: 3})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_1"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":402,"url":"/v1/charges","request_id":"req_2"}`))
+
+	require.Equal(t, []string{"req_2"}, recentRequestIDs(tailer.Recent()))
+}
+
+func TestRecentDisabled(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{NoColor: true, Out: &buf})
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_1"}`))
+
+	require.Nil(t, tailer.Recent())
+}
+
+func TestRecentConcurrent(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{NoColor: true, Out: &buf, RecentSize: 10})
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < 25; j++ {
+				tailer.processRequestLogEvent(requestLogMessage(fmt.Sprintf(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_%d_%d"}`, i, j)))
+			}
+		}(i)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 25; j++ {
+				require.LessOrEqual(t, len(tailer.Recent()), 10)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	require.Len(t, tailer.Recent(), 10)
+}
+
+func TestNewTailerWithRecent(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := NewTailer("sk_test_123", WithOutput(&buf), WithRecent(1))
+	require.Equal(t, 1, tailer.cfg.RecentSize)
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"request_id":"req_1"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"request_id":"req_2"}`))
+
+	require.Equal(t, []string{"req_2"}, recentRequestIDs(tailer.Recent()))
+}
@@ -0,0 +1,82 @@
+package logtailing
+
+import (
+	"reflect"
+	"regexp"
+)
+
+const redactedText = "[REDACTED]"
+
+// patternRedactor replaces the substrings of an EventPayload's string fields
+// that match any of its patterns.
+type patternRedactor struct {
+	patterns []*regexp.Regexp
+}
+
+func newPatternRedactor(patterns []string) (*patternRedactor, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	r := &patternRedactor{}
+
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		r.patterns = append(r.patterns, re)
+	}
+
+	return r, nil
+}
+
+func (r *patternRedactor) redact(payload EventPayload) EventPayload {
+	redactStrings(reflect.ValueOf(&payload).Elem(), r.replace)
+	return payload
+}
+
+func (r *patternRedactor) replace(s string) string {
+	for _, re := range r.patterns {
+		s = re.ReplaceAllString(s, redactedText)
+	}
+
+	return s
+}
+
+// redactStrings applies replace to every string field of the struct v,
+// including the fields of nested structs.
+func redactStrings(v reflect.Value, replace func(string) string) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+
+		switch field.Kind() {
+		case reflect.String:
+			if field.CanSet() {
+				field.SetString(replace(field.String()))
+			}
+		case reflect.Struct:
+			redactStrings(field, replace)
+		}
+	}
+}
+
+// redactionEnabled reports whether events need to go through redaction
+// before being written anywhere.
+func (t *Tailer) redactionEnabled() bool {
+	return t.patternRedactor != nil || t.cfg.Redactor != nil
+}
+
+// redact applies the RedactPatterns and then the Redactor hook to the payload.
+func (t *Tailer) redact(payload EventPayload) EventPayload {
+	if t.patternRedactor != nil {
+		payload = t.patternRedactor.redact(payload)
+	}
+
+	if t.cfg.Redactor != nil {
+		payload = t.cfg.Redactor(payload)
+	}
+
+	return payload
+}
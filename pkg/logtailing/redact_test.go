@@ -0,0 +1,66 @@
+package logtailing
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const emailPattern = `[\w.+-]+@[\w-]+\.[\w.]+`
+
+const redactPayload = `{"method":"GET","status":400,"url":"/v1/customers?email=jenny@example.com","request_id":"req_123","error":{"message":"No such customer: jenny@example.com","param":"email"}}`
+
+func TestRedactPatternsDefaultOutput(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{Out: &buf, RedactPatterns: []string{emailPattern}})
+	require.NoError(t, tailer.validateConfig())
+
+	tailer.processRequestLogEvent(requestLogMessage(redactPayload))
+
+	require.Contains(t, buf.String(), "GET /v1/customers?email=[REDACTED] [req_123]")
+	require.Contains(t, buf.String(), "Message: No such customer: [REDACTED]\n")
+	require.NotContains(t, buf.String(), "jenny@example.com")
+}
+
+func TestRedactPatternsJSONOutput(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{Out: &buf, OutputFormat: "JSON", RedactPatterns: []string{emailPattern}})
+
+	tailer.processRequestLogEvent(requestLogMessage(redactPayload))
+
+	require.Contains(t, buf.String(), `"url":"/v1/customers?email=[REDACTED]"`)
+	require.Contains(t, buf.String(), `"message":"No such customer: [REDACTED]"`)
+	require.NotContains(t, buf.String(), "jenny@example.com")
+}
+
+func TestRedactorHook(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{
+		Out:            &buf,
+		OutputFormat:   "JSON",
+		RedactPatterns: []string{emailPattern},
+		Redactor: func(payload EventPayload) EventPayload {
+			// Runs after the patterns have been applied
+			require.Equal(t, "/v1/customers?email=[REDACTED]", payload.URL)
+
+			payload.RequestID = redactedText
+			return payload
+		},
+	})
+
+	tailer.processRequestLogEvent(requestLogMessage(redactPayload))
+
+	require.Contains(t, buf.String(), `"request_id":"[REDACTED]"`)
+}
+
+func TestRedactPatternsInvalid(t *testing.T) {
+	tailer := New(&Config{RedactPatterns: []string{"("}})
+
+	err := tailer.validateConfig()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid redact pattern")
+}
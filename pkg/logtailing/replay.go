@@ -0,0 +1,144 @@
+package logtailing
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/stripe/stripe-cli/pkg/websocket"
+)
+
+const (
+	minReplaySpeed = 0.01
+	maxReplaySpeed = 1000
+
+	maxReplayLineSize = 1024 * 1024
+)
+
+// replaying reports whether events are read from Input or ReplayFile instead
+// of Stripe.
+func (t *Tailer) replaying() bool {
+	return t.cfg.Input != nil || t.cfg.ReplayFile != ""
+}
+
+// replay feeds the events of Input, or else ReplayFile, through the normal
+// processing pipeline, and prints a summary on Log.Out once the end of the
+// input is reached.
+func (t *Tailer) replay(ctx context.Context) error {
+	r := t.cfg.Input
+
+	if r == nil {
+		f, err := os.Open(t.cfg.ReplayFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		r = f
+	}
+
+	if err := t.replayFrom(ctx, r); err != nil {
+		return err
+	}
+
+	report := t.report()
+	fmt.Fprintf(t.log.Out, "Replay finished: %d request logs, %d malformed lines skipped\n", report.Total, report.Malformed)
+
+	return nil
+}
+
+// replayFrom feeds the NDJSON events read from r through the normal
+// processing pipeline, preserving the original timing between events scaled
+// by ReplaySpeed. Malformed lines are counted, reported to OnError and
+// skipped, unless StrictReplay is set in which case the replay stops with an
+// error.
+func (t *Tailer) replayFrom(ctx context.Context, r io.Reader) error {
+	speed := clampReplaySpeed(t.cfg.ReplaySpeed)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxReplayLineSize)
+
+	var previous *EventPayload
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		line = unwrapEnvelope(line)
+
+		var payload EventPayload
+		if err := json.Unmarshal([]byte(line), &payload); err != nil {
+			err = fmt.Errorf("malformed line %d in replay: %v", n, err)
+			if t.cfg.StrictReplay {
+				return err
+			}
+
+			t.mu.Lock()
+			t.recordMessage(true)
+			t.mu.Unlock()
+
+			t.onError(err)
+		} else {
+			if previous != nil {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-time.After(replayDelay(previous.CreatedAt, payload.CreatedAt, speed)):
+				}
+			}
+
+			previous = &payload
+
+			t.processRequestLogEvent(websocket.IncomingMessage{
+				RequestLogEvent: &websocket.RequestLogEvent{
+					EventPayload: line,
+					Type:         "request_log_event",
+				},
+			})
+		}
+
+		select {
+		case err := <-t.breakerTripped():
+			return err
+		case <-t.maxBytesHit:
+			return nil
+		default:
+		}
+	}
+
+	return scanner.Err()
+}
+
+// clampReplaySpeed returns a usable replay speed. Zero, negative and invalid
+// values replay as fast as possible, and very small or very large speeds are
+// limited to a sensible range.
+func clampReplaySpeed(speed float64) float64 {
+	switch {
+	case math.IsNaN(speed) || speed <= 0:
+		return 0
+	case speed < minReplaySpeed:
+		return minReplaySpeed
+	case speed > maxReplaySpeed:
+		return maxReplaySpeed
+	default:
+		return speed
+	}
+}
+
+// replayDelay returns how long to wait between two events created at the
+// given Unix times when replaying at speed.
+func replayDelay(previous, next int, speed float64) time.Duration {
+	if speed == 0 || next <= previous {
+		return 0
+	}
+
+	return time.Duration(float64(time.Duration(next-previous)*time.Second) / speed)
+}
@@ -0,0 +1,156 @@
+package logtailing
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func writeReplayFile(t *testing.T, lines ...string) string {
+	f, err := ioutil.TempFile("", "replay-*.ndjson")
+	require.NoError(t, err)
+	defer f.Close()
+
+	_, err = f.WriteString(strings.Join(lines, "\n") + "\n")
+	require.NoError(t, err)
+
+	return f.Name()
+}
+
+func TestReplaySpeedZeroEmitsImmediatelyInOrder(t *testing.T) {
+	path := writeReplayFile(t,
+		`{"created_at":1600000000,"method":"POST","status":200,"url":"/v1/charges","request_id":"req_1"}`,
+		`{"created_at":1600000100,"method":"GET","status":404,"url":"/v1/customers","request_id":"req_2"}`,
+		``,
+		`{"created_at":1600003600,"method":"DELETE","status":200,"url":"/v1/customers/cus_123","request_id":"req_3"}`,
+	)
+	defer os.Remove(path)
+
+	var buf bytes.Buffer
+
+	tailer := New(&Config{Out: &buf, OutputFormat: "JSON", ReplayFile: path})
+
+	start := time.Now()
+	require.NoError(t, tailer.Run(context.Background()))
+	require.Less(t, int64(time.Since(start)), int64(time.Second))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	require.Contains(t, lines[0], "req_1")
+	require.Contains(t, lines[1], "req_2")
+	require.Contains(t, lines[2], "req_3")
+}
+
+func TestInputReader(t *testing.T) {
+	input := strings.NewReader(`{"created_at":1600000000,"method":"POST","status":200,"url":"/v1/charges","request_id":"req_1"}
+{"seq":7,"payload":{"created_at":1600000100,"method":"GET","status":404,"url":"/v1/customers","request_id":"req_2","error":{"code":"resource_missing"}}}
+`)
+
+	var buf bytes.Buffer
+
+	tailer := New(&Config{Input: input, NoColor: true, Out: &buf})
+	require.NoError(t, tailer.Run(context.Background()))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	require.True(t, strings.HasSuffix(lines[0], "[200] POST /v1/charges [req_1]"))
+	require.True(t, strings.HasSuffix(lines[1], "[404] GET /v1/customers [req_2]"))
+	require.Equal(t, "Code: resource_missing", lines[2])
+}
+
+func TestInputTakesPrecedenceOverReplayFile(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{
+		Input:        strings.NewReader(`{"method":"POST","status":200,"request_id":"req_1"}`),
+		Out:          &buf,
+		OutputFormat: "JSON",
+		ReplayFile:   "does-not-exist.ndjson",
+	})
+	require.NoError(t, tailer.Run(context.Background()))
+
+	require.Contains(t, buf.String(), "req_1")
+}
+
+func TestReplayMissingFile(t *testing.T) {
+	tailer := New(&Config{Out: ioutil.Discard, ReplayFile: "does-not-exist.ndjson"})
+	require.Error(t, tailer.Run(context.Background()))
+}
+
+func TestReplayDelay(t *testing.T) {
+	require.Equal(t, 10*time.Second, replayDelay(100, 110, 1))
+	require.Equal(t, 5*time.Second, replayDelay(100, 110, 2))
+	require.Equal(t, 20*time.Second, replayDelay(100, 110, 0.5))
+	require.Equal(t, time.Duration(0), replayDelay(100, 110, 0))
+	require.Equal(t, time.Duration(0), replayDelay(110, 100, 1))
+}
+
+func TestClampReplaySpeed(t *testing.T) {
+	require.Equal(t, 0.0, clampReplaySpeed(0))
+	require.Equal(t, 0.0, clampReplaySpeed(-2))
+	require.Equal(t, 0.0, clampReplaySpeed(math.NaN()))
+	require.Equal(t, 2.0, clampReplaySpeed(2))
+	require.Equal(t, minReplaySpeed, clampReplaySpeed(0.0001))
+	require.Equal(t, float64(maxReplaySpeed), clampReplaySpeed(1e9))
+	require.Equal(t, float64(maxReplaySpeed), clampReplaySpeed(math.Inf(1)))
+}
+
+func TestReplaySkipsMalformedLines(t *testing.T) {
+	input := strings.NewReader(`{"method":"POST","status":200,"url":"/v1/charges","request_id":"req_1"}
+{"method":"POST",
+not json
+{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_2"}
+`)
+
+	var buf, logOut bytes.Buffer
+	var errs []error
+
+	tailer := New(&Config{
+		Input:   input,
+		Log:     &log.Logger{Out: &logOut},
+		NoColor: true,
+		OnError: func(err error) { errs = append(errs, err) },
+		Out:     &buf,
+	})
+	require.NoError(t, tailer.Run(context.Background()))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], "[req_1]")
+	require.Contains(t, lines[1], "[req_2]")
+
+	require.Len(t, errs, 2)
+	require.Contains(t, errs[0].Error(), "malformed line 2 in replay")
+	require.Contains(t, errs[1].Error(), "malformed line 3 in replay")
+
+	report := tailer.report()
+	require.Equal(t, 2, report.Total)
+	require.Equal(t, 2, report.Malformed)
+	require.Equal(t, "Replay finished: 2 request logs, 2 malformed lines skipped\n", logOut.String())
+}
+
+func TestStrictReplay(t *testing.T) {
+	input := strings.NewReader(`{"method":"POST","status":200,"url":"/v1/charges","request_id":"req_1"}
+not json
+{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_2"}
+`)
+
+	var buf bytes.Buffer
+
+	tailer := New(&Config{Input: input, NoColor: true, Out: &buf, StrictReplay: true})
+
+	err := tailer.Run(context.Background())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "malformed line 2 in replay")
+
+	require.Contains(t, buf.String(), "[req_1]")
+	require.NotContains(t, buf.String(), "[req_2]")
+}
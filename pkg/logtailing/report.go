@@ -0,0 +1,113 @@
+package logtailing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync/atomic"
+)
+
+// Report is the summary of a session written to ReportFile on exit.
+type Report struct {
+	// Total is the number of request logs received
+	Total int `json:"total"`
+
+	// StatusClasses counts the request logs received by status class, e.g.
+	// "2xx"
+	StatusClasses map[string]int `json:"status_classes"`
+
+	// DurationSeconds is the time elapsed since the session started
+	DurationSeconds float64 `json:"duration_seconds"`
+
+	// Reconnects is the number of times the session was reauthorized after
+	// expiring
+	Reconnects int `json:"reconnects"`
+
+	// SessionID is the ID of the latest websocket session, if any
+	SessionID string `json:"session_id,omitempty"`
+
+	// Malformed is the number of malformed messages received
+	Malformed int `json:"malformed"`
+
+	// DroppedEvents is the number of payloads dropped because the Events
+	// channel was full
+	DroppedEvents int `json:"dropped_events,omitempty"`
+
+	// OutputStalls is the number of writes to Out that waited for the
+	// OutputQueueSize queue to have room
+	OutputStalls int `json:"output_stalls,omitempty"`
+
+	// SinkBytes is the number of bytes written to each sink, when MaxBytes
+	// is set
+	SinkBytes map[string]int64 `json:"sink_bytes,omitempty"`
+
+	// SinkDropped is the number of request logs that couldn't be written to
+	// one of Sinks
+	SinkDropped int `json:"sink_dropped,omitempty"`
+
+	// MaxBytesReached is set when the session was stopped because MaxBytes
+	// was reached
+	MaxBytesReached bool `json:"max_bytes_reached,omitempty"`
+
+	// Latency is estimated from the request logs that include their
+	// duration, if any
+	Latency *LatencyQuantiles `json:"latency_ms,omitempty"`
+}
+
+// statusClass returns the class of a status code, e.g. "4xx".
+func statusClass(status int) string {
+	return fmt.Sprintf("%dxx", status/100)
+}
+
+// report returns the summary of the session so far.
+func (t *Tailer) report() Report {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	classes := make(map[string]int, len(t.statusClasses))
+	for class, n := range t.statusClasses {
+		classes[class] = n
+	}
+
+	var sinkBytes map[string]int64
+	if len(t.sinkBytes) > 0 {
+		sinkBytes = make(map[string]int64, len(t.sinkBytes))
+		for sink, n := range t.sinkBytes {
+			sinkBytes[sink] = n
+		}
+	}
+
+	return Report{
+		Total:           t.count,
+		StatusClasses:   classes,
+		DurationSeconds: t.cfg.Now().Sub(t.started).Seconds(),
+		Reconnects:      t.reconnects,
+		SessionID:       t.sessionID,
+		Malformed:       t.malformed,
+		DroppedEvents:   t.eventsDropped,
+		OutputStalls:    t.outputStalls(),
+		SinkBytes:       sinkBytes,
+		SinkDropped:     int(atomic.LoadInt64(&t.sinkDropped)),
+		MaxBytesReached: t.maxBytesReached,
+		Latency:         t.latencies.quantiles(),
+	}
+}
+
+// writeReport writes the session summary to ReportFile as JSON, or to stderr
+// if ReportFile is "-".
+func (t *Tailer) writeReport() error {
+	encoded, err := json.MarshalIndent(t.report(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	encoded = append(encoded, '\n')
+
+	if t.cfg.ReportFile == "-" {
+		_, err = os.Stderr.Write(encoded)
+		return err
+	}
+
+	return ioutil.WriteFile(t.cfg.ReportFile, encoded, 0644)
+}
@@ -0,0 +1,56 @@
+package logtailing
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "report")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	reportFile := filepath.Join(dir, "report.json")
+
+	tailer := New(&Config{
+		Out:        ioutil.Discard,
+		ReportFile: reportFile,
+		ReplayFile: writeReplayFile(t,
+			`{"method":"POST","status":200,"url":"/v1/charges"}`,
+			`{"method":"POST","status":201,"url":"/v1/customers"}`,
+			`{"method":"GET","status":404,"url":"/v1/customers/cus_123"}`,
+			`not json`,
+		),
+	})
+	require.NoError(t, tailer.Run(context.Background()))
+
+	data, err := ioutil.ReadFile(reportFile)
+	require.NoError(t, err)
+
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &fields))
+
+	for _, field := range []string{"total", "status_classes", "duration_seconds", "reconnects", "malformed"} {
+		require.Contains(t, fields, field)
+	}
+
+	var report Report
+	require.NoError(t, json.Unmarshal(data, &report))
+
+	require.Equal(t, 3, report.Total)
+	require.Equal(t, map[string]int{"2xx": 2, "4xx": 1}, report.StatusClasses)
+	require.Equal(t, 1, report.Malformed)
+	require.Equal(t, 0, report.Reconnects)
+	require.GreaterOrEqual(t, report.DurationSeconds, 0.0)
+}
+
+func TestStatusClass(t *testing.T) {
+	require.Equal(t, "2xx", statusClass(204))
+	require.Equal(t, "5xx", statusClass(503))
+}
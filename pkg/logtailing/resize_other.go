@@ -0,0 +1,9 @@
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package logtailing
+
+import "os"
+
+// notifyResize is a no-op on platforms without SIGWINCH, where the width of
+// the terminal is only looked up once.
+func notifyResize(ch chan<- os.Signal) {}
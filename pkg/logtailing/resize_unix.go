@@ -0,0 +1,14 @@
+// +build linux darwin dragonfly freebsd netbsd openbsd
+
+package logtailing
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyResize relays SIGWINCH, sent when the terminal is resized, to ch.
+func notifyResize(ch chan<- os.Signal) {
+	signal.Notify(ch, syscall.SIGWINCH)
+}
@@ -0,0 +1,145 @@
+package logtailing
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	sinkRetryQueueSize      = 1000
+	defaultSinkRetryBackoff = 100 * time.Millisecond
+)
+
+// sinkRetrier writes request logs to one of Sinks from its own goroutine,
+// retrying failed writes with exponential backoff so that a sink that's
+// briefly unavailable neither loses request logs nor holds up the others.
+// Payloads are queued, and dropped if the queue is full.
+type sinkRetrier struct {
+	t     *Tailer
+	index int
+	sink  Sink
+
+	payloads chan EventPayload
+	done     chan struct{}
+
+	// started and closed are guarded by t.mu
+	started bool
+	closed  bool
+}
+
+func newSinkRetrier(t *Tailer, index int, sink Sink) *sinkRetrier {
+	return &sinkRetrier{
+		t:        t,
+		index:    index,
+		sink:     sink,
+		payloads: make(chan EventPayload, sinkRetryQueueSize),
+		done:     make(chan struct{}),
+	}
+}
+
+// start writes the queued payloads until the retrier is closed. Retries stop
+// waiting once ctx is canceled, leaving a single attempt for each payload.
+// The caller must hold t.mu.
+func (r *sinkRetrier) start(ctx context.Context) {
+	r.started = true
+
+	go r.run(ctx)
+}
+
+// write queues a payload for the sink. The caller must hold t.mu.
+func (r *sinkRetrier) write(payload EventPayload) {
+	if r.closed {
+		atomic.AddInt64(&r.t.sinkDropped, 1)
+		return
+	}
+
+	select {
+	case r.payloads <- payload:
+	default:
+		atomic.AddInt64(&r.t.sinkDropped, 1)
+		r.t.onError(fmt.Errorf("sink %d is falling behind, dropping request log %s", r.index, payload.RequestID))
+	}
+}
+
+func (r *sinkRetrier) run(ctx context.Context) {
+	defer close(r.done)
+
+	for payload := range r.payloads {
+		r.deliver(ctx, payload)
+	}
+}
+
+// deliver writes the payload, retrying up to SinkRetries times. The payload
+// is counted as dropped and reported to OnError once retries are exhausted.
+func (r *sinkRetrier) deliver(ctx context.Context, payload EventPayload) {
+	backoff := r.t.cfg.SinkRetryBackoff
+
+	for attempt := 0; ; attempt++ {
+		err := runSinkOp("write", writeSink(r.sink, payload))
+		if err == nil {
+			return
+		}
+
+		if attempt >= r.t.cfg.SinkRetries || ctx.Err() != nil {
+			atomic.AddInt64(&r.t.sinkDropped, 1)
+
+			r.t.mu.Lock()
+			r.t.onError(fmt.Errorf("sink %d %v, giving up after %d retries", r.index, err, attempt))
+			r.t.mu.Unlock()
+
+			return
+		}
+
+		r.t.log.Debugf("Sink %d %v, retrying in %s", r.index, err, backoff)
+
+		timer := time.NewTimer(backoff)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+
+		backoff *= 2
+	}
+}
+
+// startSinkRetriers starts the retriers of Sinks, if SinkRetries is set.
+func (t *Tailer) startSinkRetriers(ctx context.Context) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, r := range t.sinkRetriers {
+		r.start(ctx)
+	}
+}
+
+// closeSinkRetriers waits for the retriers to write or give up on their
+// queued payloads. Later payloads are counted as dropped. The caller must not
+// hold t.mu, which the retriers take to report errors.
+func (t *Tailer) closeSinkRetriers() {
+	t.mu.Lock()
+
+	var started []*sinkRetrier
+
+	for _, r := range t.sinkRetriers {
+		if r.closed {
+			continue
+		}
+
+		r.closed = true
+		close(r.payloads)
+
+		if r.started {
+			started = append(started, r)
+		}
+	}
+
+	t.mu.Unlock()
+
+	for _, r := range started {
+		<-r.done
+	}
+}
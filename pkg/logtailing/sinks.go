@@ -0,0 +1,298 @@
+package logtailing
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	sinkTimeout          = 5 * time.Second
+	webhookSinkQueueSize = 1000
+)
+
+// Sink receives every request log after filtering, middleware and redaction,
+// e.g. to ship them to a stream such as Kinesis. Sinks are called one at a
+// time, so they don't need to be safe for concurrent use, but Write holds up
+// the processing of request logs and should queue the payload rather than
+// block on slow I/O, unless SinkRetries moves the writes to a goroutine per
+// sink. Flush and Close are called once when Run returns.
+type Sink interface {
+	Write(ctx context.Context, payload EventPayload) error
+	Flush(ctx context.Context) error
+	Close() error
+}
+
+// writeUserSinks writes the payload to each of Sinks, or queues it for their
+// retriers when SinkRetries is set. A sink that fails or panics is reported
+// to OnError without affecting the others, and the payload is counted as
+// dropped. Like the built-in sinks, they count towards MaxBytes and aren't
+// written to once it's reached. The caller must hold t.mu.
+func (t *Tailer) writeUserSinks(payload EventPayload, jsonLine string) {
+	if t.maxBytesReached {
+		return
+	}
+
+	if t.sinkRetriers != nil {
+		for i, r := range t.sinkRetriers {
+			r.write(payload)
+			t.countBytes(userSinkName(i), jsonLine)
+		}
+
+		return
+	}
+
+	for i, sink := range t.cfg.Sinks {
+		t.countBytes(userSinkName(i), jsonLine)
+
+		if !t.callSink(i, "write", writeSink(sink, payload)) {
+			atomic.AddInt64(&t.sinkDropped, 1)
+		}
+	}
+}
+
+// userSinkName is the name of the i-th of Sinks in the bytes counted towards
+// MaxBytes.
+func userSinkName(i int) string {
+	return fmt.Sprintf("sinks[%d]", i)
+}
+
+// closeUserSinks flushes and closes each of Sinks, once closeSinkRetriers
+// has returned. The caller must hold t.mu.
+func (t *Tailer) closeUserSinks() {
+	for i, sink := range t.cfg.Sinks {
+		t.callSink(i, "flush", func() error {
+			ctx, cancel := context.WithTimeout(context.Background(), sinkTimeout)
+			defer cancel()
+
+			return sink.Flush(ctx)
+		})
+
+		t.callSink(i, "close", sink.Close)
+	}
+}
+
+// writeSink returns the call writing the payload to sink.
+func writeSink(sink Sink, payload EventPayload) func() error {
+	return func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), sinkTimeout)
+		defer cancel()
+
+		return sink.Write(ctx, payload)
+	}
+}
+
+// callSink runs op on the i-th sink, reporting its error or panic to
+// OnError. It returns whether op succeeded.
+func (t *Tailer) callSink(i int, op string, fn func() error) bool {
+	if err := runSinkOp(op, fn); err != nil {
+		t.onError(fmt.Errorf("sink %d %v", i, err))
+		return false
+	}
+
+	return true
+}
+
+// runSinkOp runs op, turning a panic into an error.
+func runSinkOp(op string, fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panicked on %s: %v", op, r)
+		}
+	}()
+
+	if err := fn(); err != nil {
+		return fmt.Errorf("failed to %s: %v", op, err)
+	}
+
+	return nil
+}
+
+// ndjsonSink is a Sink writing request logs as NDJSON.
+type ndjsonSink struct {
+	w *bufio.Writer
+	c io.Closer
+}
+
+// NewNDJSONSink returns a Sink writing one JSON request log per line to w.
+// Writes are buffered until the sink is flushed, and w is closed with the
+// sink if it's an io.Closer.
+func NewNDJSONSink(w io.Writer) Sink {
+	s := &ndjsonSink{w: bufio.NewWriter(w)}
+	if c, ok := w.(io.Closer); ok {
+		s.c = c
+	}
+
+	return s
+}
+
+// NewFileSink returns a Sink appending NDJSON request logs to the file at
+// path, which is created if needed.
+func NewFileSink(path string) (Sink, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewNDJSONSink(f), nil
+}
+
+func (s *ndjsonSink) Write(ctx context.Context, payload EventPayload) error {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.w.Write(append(encoded, '\n')); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *ndjsonSink) Flush(ctx context.Context) error {
+	return s.w.Flush()
+}
+
+func (s *ndjsonSink) Close() error {
+	if s.c == nil {
+		return nil
+	}
+
+	return s.c.Close()
+}
+
+// webhookSink is a Sink POSTing each request log to a URL from a
+// background worker, so that a slow endpoint doesn't hold up the tail.
+type webhookSink struct {
+	url    string
+	client *http.Client
+
+	queue   chan EventPayload
+	pending sync.WaitGroup
+	done    chan struct{}
+
+	mu      sync.Mutex
+	failed  int
+	lastErr error
+}
+
+// NewWebhookSink returns a Sink POSTing each request log to url as a JSON
+// object. Request logs are queued and posted one at a time in the
+// background, and dropped when the queue is full. Responses with a status of
+// 300 and above are reported as errors when the sink is flushed. ForwardURL,
+// which batches request logs, is better suited to busy tails.
+func NewWebhookSink(url string) Sink {
+	s := &webhookSink{
+		url:    url,
+		client: &http.Client{Timeout: sinkTimeout},
+		queue:  make(chan EventPayload, webhookSinkQueueSize),
+		done:   make(chan struct{}),
+	}
+
+	go s.run()
+
+	return s
+}
+
+func (s *webhookSink) Write(ctx context.Context, payload EventPayload) error {
+	s.pending.Add(1)
+
+	select {
+	case s.queue <- payload:
+		return nil
+	default:
+		s.pending.Done()
+		return errors.New("queue is full, dropping request log")
+	}
+}
+
+// Flush waits for the queued request logs to be posted, and returns an
+// error if any failed since the last flush.
+func (s *webhookSink) Flush(ctx context.Context) error {
+	posted := make(chan struct{})
+
+	go func() {
+		s.pending.Wait()
+		close(posted)
+	}()
+
+	select {
+	case <-posted:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failed == 0 {
+		return nil
+	}
+
+	err := fmt.Errorf("failed to post %d request logs: %v", s.failed, s.lastErr)
+	s.failed, s.lastErr = 0, nil
+
+	return err
+}
+
+// Close posts the request logs left in the queue and stops the worker.
+func (s *webhookSink) Close() error {
+	close(s.queue)
+	<-s.done
+
+	s.client.CloseIdleConnections()
+
+	return nil
+}
+
+// run posts the queued request logs until the queue is closed.
+func (s *webhookSink) run() {
+	defer close(s.done)
+
+	for payload := range s.queue {
+		if err := s.post(payload); err != nil {
+			s.mu.Lock()
+			s.failed++
+			s.lastErr = err
+			s.mu.Unlock()
+		}
+
+		s.pending.Done()
+	}
+}
+
+func (s *webhookSink) post(payload EventPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
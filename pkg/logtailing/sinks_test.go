@@ -0,0 +1,350 @@
+package logtailing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSink records the calls it receives.
+type fakeSink struct {
+	calls    []string
+	payloads []EventPayload
+	err      error
+	panics   bool
+}
+
+func (s *fakeSink) Write(ctx context.Context, payload EventPayload) error {
+	if s.panics {
+		panic("boom")
+	}
+
+	s.calls = append(s.calls, "write")
+	s.payloads = append(s.payloads, payload)
+
+	return s.err
+}
+
+func (s *fakeSink) Flush(ctx context.Context) error {
+	s.calls = append(s.calls, "flush")
+	return s.err
+}
+
+func (s *fakeSink) Close() error {
+	s.calls = append(s.calls, "close")
+	return nil
+}
+
+func TestSinks(t *testing.T) {
+	var buf bytes.Buffer
+
+	sink := &fakeSink{}
+
+	tailer := New(&Config{
+		ErrorsOnly: true,
+		NoColor:    true,
+		Out:        &buf,
+		Sinks:      []Sink{sink},
+	})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_1"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":404,"url":"/v1/customers","request_id":"req_2"}`))
+
+	require.Equal(t, []string{"write"}, sink.calls)
+	require.Equal(t, "req_2", sink.payloads[0].RequestID)
+
+	tailer.finish()
+	require.Equal(t, []string{"write", "flush", "close"}, sink.calls)
+}
+
+func TestSinksErrorIsolation(t *testing.T) {
+	var buf bytes.Buffer
+
+	var reported []string
+
+	failing := &fakeSink{err: errors.New("unavailable")}
+	panicking := &fakeSink{panics: true}
+	healthy := &fakeSink{}
+
+	tailer := New(&Config{
+		NoColor: true,
+		OnError: func(err error) { reported = append(reported, err.Error()) },
+		Out:     &buf,
+		Sinks:   []Sink{failing, panicking, healthy},
+	})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_1"}`))
+	tailer.finish()
+
+	require.Equal(t, []string{"write", "flush", "close"}, healthy.calls)
+	require.Equal(t, []string{"flush", "close"}, panicking.calls)
+	require.Equal(t, []string{
+		"sink 0 failed to write: unavailable",
+		"sink 1 panicked on write: boom",
+		"sink 0 failed to flush: unavailable",
+	}, reported)
+	require.Equal(t, 2, tailer.report().SinkDropped)
+
+	// The console output isn't affected
+	require.Contains(t, buf.String(), "req_1")
+}
+
+// flakySink fails its first writes, and can be blocked until released.
+type flakySink struct {
+	fakeSink
+
+	mu       sync.Mutex
+	failures int
+	attempts int
+	release  chan struct{}
+}
+
+func (s *flakySink) Write(ctx context.Context, payload EventPayload) error {
+	if s.release != nil {
+		<-s.release
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.attempts++
+	if s.attempts <= s.failures {
+		return errors.New("unavailable")
+	}
+
+	return s.fakeSink.Write(ctx, payload)
+}
+
+func TestSinkRetries(t *testing.T) {
+	var reported []string
+
+	sink := &flakySink{failures: 2}
+
+	tailer := New(&Config{
+		OnError:          func(err error) { reported = append(reported, err.Error()) },
+		Out:              &bytes.Buffer{},
+		Sinks:            []Sink{sink},
+		SinkRetries:      3,
+		SinkRetryBackoff: time.Millisecond,
+	})
+	tailer.startSinks(context.Background())
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_1"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_2"}`))
+	tailer.finish()
+
+	require.Equal(t, []string{"req_1", "req_2"}, recentRequestIDs(sink.payloads))
+	require.Equal(t, 4, sink.attempts)
+	require.Equal(t, []string{"write", "write", "flush", "close"}, sink.calls)
+	require.Zero(t, tailer.report().SinkDropped)
+	require.Empty(t, reported)
+}
+
+func TestSinkRetriesGiveUp(t *testing.T) {
+	var reported []string
+
+	sink := &flakySink{failures: 100}
+
+	tailer := New(&Config{
+		OnError:          func(err error) { reported = append(reported, err.Error()) },
+		Out:              &bytes.Buffer{},
+		Sinks:            []Sink{sink},
+		SinkRetries:      2,
+		SinkRetryBackoff: time.Millisecond,
+	})
+	tailer.startSinks(context.Background())
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_1"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_2"}`))
+	tailer.finish()
+
+	require.Empty(t, sink.payloads)
+	require.Equal(t, 6, sink.attempts)
+	require.Equal(t, 2, tailer.report().SinkDropped)
+	require.Equal(t, []string{
+		"sink 0 failed to write: unavailable, giving up after 2 retries",
+		"sink 0 failed to write: unavailable, giving up after 2 retries",
+	}, reported)
+}
+
+func TestSinkRetriesDontBlock(t *testing.T) {
+	var buf bytes.Buffer
+
+	blocked := &flakySink{release: make(chan struct{})}
+	healthy := &flakySink{}
+
+	tailer := New(&Config{
+		NoColor:     true,
+		Out:         &buf,
+		Sinks:       []Sink{blocked, healthy},
+		SinkRetries: 1,
+	})
+	tailer.startSinks(context.Background())
+
+	// Neither the console output nor the other sinks wait for the blocked one
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_1"}`))
+	require.Contains(t, buf.String(), "req_1")
+
+	require.Eventually(t, func() bool {
+		healthy.mu.Lock()
+		defer healthy.mu.Unlock()
+
+		return len(healthy.payloads) == 1
+	}, time.Second, time.Millisecond)
+
+	close(blocked.release)
+	tailer.finish()
+
+	require.Equal(t, []string{"req_1"}, recentRequestIDs(blocked.payloads))
+}
+
+func TestSinkRetriesStopOnShutdown(t *testing.T) {
+	var reported []string
+
+	sink := &flakySink{failures: 100}
+
+	tailer := New(&Config{
+		OnError:          func(err error) { reported = append(reported, err.Error()) },
+		Out:              &bytes.Buffer{},
+		Sinks:            []Sink{sink},
+		SinkRetries:      5,
+		SinkRetryBackoff: time.Hour,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tailer.startSinks(ctx)
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_1"}`))
+
+	require.Eventually(t, func() bool {
+		sink.mu.Lock()
+		defer sink.mu.Unlock()
+
+		return sink.attempts == 1
+	}, time.Second, time.Millisecond)
+
+	// The sink is tried a last time instead of waiting for the backoff
+	start := time.Now()
+
+	cancel()
+	tailer.finish()
+
+	require.Less(t, int64(time.Since(start)), int64(time.Second))
+	require.Equal(t, 2, sink.attempts)
+	require.Equal(t, 1, tailer.report().SinkDropped)
+	require.Equal(t, []string{"sink 0 failed to write: unavailable, giving up after 1 retries"}, reported)
+}
+
+func TestSinkRetriersStartWithSinks(t *testing.T) {
+	tailer := New(&Config{Out: &bytes.Buffer{}, Sinks: []Sink{&fakeSink{}}, SinkRetries: 1})
+
+	// An unused tailer doesn't leave goroutines behind
+	require.False(t, tailer.sinkRetriers[0].started)
+
+	tailer.closeSinkRetriers()
+	require.True(t, tailer.sinkRetriers[0].closed)
+}
+
+func TestFileSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sinks")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "events.ndjson")
+
+	sink, err := NewFileSink(path)
+	require.NoError(t, err)
+
+	tailer := New(&Config{Out: &bytes.Buffer{}, Sinks: []Sink{sink}})
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_1"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":402,"url":"/v1/charges","request_id":"req_2"}`))
+	tailer.finish()
+
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	require.Len(t, lines, 2)
+
+	var payload EventPayload
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &payload))
+	require.Equal(t, "req_2", payload.RequestID)
+	require.Equal(t, 402, payload.Status)
+}
+
+func TestWebhookSink(t *testing.T) {
+	received := make(chan EventPayload, 2)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload EventPayload
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		received <- payload
+
+		if payload.Status >= 400 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer ts.Close()
+
+	sink := NewWebhookSink(ts.URL)
+
+	require.NoError(t, sink.Write(context.Background(), EventPayload{RequestID: "req_1", Status: 200}))
+	require.Equal(t, "req_1", (<-received).RequestID)
+	require.NoError(t, sink.Flush(context.Background()))
+
+	// Failures are reported when the sink is flushed
+	require.NoError(t, sink.Write(context.Background(), EventPayload{RequestID: "req_2", Status: 500}))
+	require.EqualError(t, sink.Flush(context.Background()), "failed to post 1 request logs: unexpected status code 502")
+	require.Equal(t, "req_2", (<-received).RequestID)
+
+	require.NoError(t, sink.Flush(context.Background()))
+	require.NoError(t, sink.Close())
+}
+
+func TestWebhookSinkDoesntBlock(t *testing.T) {
+	release := make(chan struct{})
+	received := make(chan string, 3)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+
+		var payload EventPayload
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+
+		received <- payload.RequestID
+	}))
+	defer ts.Close()
+
+	sink := NewWebhookSink(ts.URL)
+
+	start := time.Now()
+
+	for _, id := range []string{"req_1", "req_2", "req_3"} {
+		require.NoError(t, sink.Write(context.Background(), EventPayload{RequestID: id}))
+	}
+
+	require.Less(t, int64(time.Since(start)), int64(time.Second))
+
+	close(release)
+
+	// Close posts the request logs still queued
+	require.NoError(t, sink.Close())
+	require.Len(t, received, 3)
+	require.Equal(t, "req_1", <-received)
+	require.Equal(t, "req_2", <-received)
+	require.Equal(t, "req_3", <-received)
+}
@@ -0,0 +1,191 @@
+package logtailing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	socketSinkBufferSize  = 100
+	socketSinkMinBackoff  = 100 * time.Millisecond
+	socketSinkMaxBackoff  = 5 * time.Second
+	socketSinkDialTimeout = 2 * time.Second
+)
+
+// socketSink writes NDJSON events to a Unix domain socket. Events are queued
+// so that a slow or unavailable collector never blocks the console output,
+// and the connection is re-established with backoff if the collector goes
+// away.
+type socketSink struct {
+	path string
+	log  *log.Logger
+
+	events chan []byte
+	done   chan struct{}
+}
+
+func newSocketSink(path string, logger *log.Logger) *socketSink {
+	return &socketSink{
+		path:   path,
+		log:    logger,
+		events: make(chan []byte, socketSinkBufferSize),
+		done:   make(chan struct{}),
+	}
+}
+
+// write queues a JSON event for delivery. The event is dropped if the queue
+// is full.
+func (s *socketSink) write(payload string) {
+	var line bytes.Buffer
+	if err := json.Compact(&line, []byte(payload)); err != nil {
+		s.log.WithFields(log.Fields{
+			"prefix": "logtailing.socketSink.write",
+		}).Debug("Not sending malformed payload to event socket: ", err)
+
+		return
+	}
+
+	line.WriteByte('\n')
+
+	select {
+	case s.events <- line.Bytes():
+	default:
+		s.log.WithFields(log.Fields{
+			"prefix": "logtailing.socketSink.write",
+		}).Debug("Event socket queue is full, dropping event")
+	}
+}
+
+// run delivers queued events until ctx is canceled, then writes the events
+// left in the queue and closes the done channel.
+func (s *socketSink) run(ctx context.Context) {
+	defer close(s.done)
+
+	var conn net.Conn
+
+	defer func() {
+		if conn != nil {
+			conn.Close() // #nosec G104
+		}
+	}()
+
+	for {
+		var line []byte
+
+		select {
+		case <-ctx.Done():
+			s.drain(conn, nil)
+			return
+		case line = <-s.events:
+		}
+
+		for {
+			if conn == nil {
+				conn = s.dial(ctx)
+				if conn == nil {
+					s.drain(nil, line)
+					return
+				}
+			}
+
+			if _, err := conn.Write(line); err != nil {
+				s.log.WithFields(log.Fields{
+					"prefix": "logtailing.socketSink.run",
+				}).Debug("Event socket write error, reconnecting: ", err)
+
+				conn.Close() // #nosec G104
+				conn = nil
+
+				continue
+			}
+
+			break
+		}
+	}
+}
+
+// drain writes the pending event, if any, and the events left in the queue
+// to conn, connecting once if there's no connection. The rest are dropped as
+// soon as writing fails.
+func (s *socketSink) drain(conn net.Conn, pending []byte) {
+	if pending == nil && len(s.events) == 0 {
+		return
+	}
+
+	if conn == nil {
+		var err error
+
+		conn, err = net.DialTimeout("unix", s.path, socketSinkDialTimeout)
+		if err != nil {
+			s.log.WithFields(log.Fields{
+				"prefix": "logtailing.socketSink.drain",
+				"path":   s.path,
+			}).Debug("Failed to connect to event socket, dropping remaining events: ", err)
+
+			return
+		}
+		defer conn.Close()
+	}
+
+	for {
+		line := pending
+		pending = nil
+
+		if line == nil {
+			select {
+			case line = <-s.events:
+			default:
+				return
+			}
+		}
+
+		if _, err := conn.Write(line); err != nil {
+			s.log.WithFields(log.Fields{
+				"prefix": "logtailing.socketSink.drain",
+			}).Debug("Event socket write error, dropping remaining events: ", err)
+
+			return
+		}
+	}
+}
+
+// wait blocks until run has returned and the queue has been drained.
+func (s *socketSink) wait() {
+	<-s.done
+}
+
+// dial connects to the socket, retrying with exponential backoff. It returns
+// nil if ctx is canceled first.
+func (s *socketSink) dial(ctx context.Context) net.Conn {
+	backoff := socketSinkMinBackoff
+
+	for {
+		dialer := net.Dialer{Timeout: socketSinkDialTimeout}
+
+		conn, err := dialer.DialContext(ctx, "unix", s.path)
+		if err == nil {
+			return conn
+		}
+
+		s.log.WithFields(log.Fields{
+			"prefix": "logtailing.socketSink.dial",
+			"path":   s.path,
+		}).Debug("Failed to connect to event socket, retrying: ", err)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > socketSinkMaxBackoff {
+			backoff = socketSinkMaxBackoff
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package logtailing
+
+import (
+	"bufio"
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventSocketReceivesEvents(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logtailing")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "events.sock")
+
+	tailer := New(&Config{EventSocket: path, Out: ioutil.Discard})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Start the sink before the collector is listening to exercise the
+	// reconnect path.
+	go tailer.eventSocket.run(ctx)
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method": "POST", "status": 200, "url": "/v1/charges"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method": "GET", "status": 404, "url": "/v1/customers"}`))
+
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	lines := make(chan string)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	for _, expected := range []string{
+		`{"method":"POST","status":200,"url":"/v1/charges"}`,
+		`{"method":"GET","status":404,"url":"/v1/customers"}`,
+	} {
+		select {
+		case line := <-lines:
+			require.Equal(t, expected, line)
+		case <-time.After(2 * time.Second):
+			require.FailNow(t, "Timed out waiting for event on socket")
+		}
+	}
+}
+
+func TestEventSocketDrainsOnShutdown(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logtailing")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "events.sock")
+
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	received := make(chan []string)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var lines []string
+
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+
+		received <- lines
+	}()
+
+	tailer := New(&Config{EventSocket: path, Out: ioutil.Discard})
+
+	// The events are queued when the tail stops
+	tailer.processRequestLogEvent(requestLogMessage(`{"method": "POST", "status": 200, "url": "/v1/charges"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method": "GET", "status": 404, "url": "/v1/customers"}`))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tailer.startSinks(ctx)
+	tailer.finish()
+
+	select {
+	case lines := <-received:
+		require.Equal(t, []string{
+			`{"method":"POST","status":200,"url":"/v1/charges"}`,
+			`{"method":"GET","status":404,"url":"/v1/customers"}`,
+		}, lines)
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "Timed out waiting for events on socket")
+	}
+}
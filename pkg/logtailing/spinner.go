@@ -0,0 +1,101 @@
+package logtailing
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/stripe/stripe-cli/pkg/ansi"
+)
+
+const defaultSpinnerMessage = "Getting ready..."
+
+// isTerminal is overridden in tests to exercise the spinner without a TTY.
+var isTerminal = ansi.IsTerminal
+
+// spinnerEnabled reports whether the spinner should be shown. The spinner
+// is drawn on Out, where request logs appear, and is always skipped when Out
+// is not a terminal so that redirected output isn't polluted with progress
+// messages. Log.Out can't be used to decide, as it defaults to discarding
+// everything. Machine-readable formats such as JSON never get a spinner,
+// even on a terminal, as their output is meant for other programs.
+func (t *Tailer) spinnerEnabled() bool {
+	return !t.cfg.NoSpinner && !t.cfg.StrictJSON && !machineReadable(t.cfg.OutputFormat) && t.cfg.Out != nil && isTerminal(t.console)
+}
+
+// startSpinner starts the spinner with the given message, or updates the
+// message of the spinner if it's already running.
+func (t *Tailer) startSpinner(msg string) {
+	if !t.spinnerEnabled() {
+		return
+	}
+
+	t.spinnerMu.Lock()
+	defer t.spinnerMu.Unlock()
+
+	if t.spinner == nil && !t.spinnerActive {
+		t.spinner = ansi.StartNewSpinner(msg, t.cfg.Out)
+	} else {
+		ansi.StartSpinner(t.spinner, msg, t.cfg.Out)
+	}
+
+	t.spinnerActive = true
+}
+
+// stopSpinner stops the spinner, printing msg in its place if non-empty. It
+// is a no-op if the spinner isn't running.
+func (t *Tailer) stopSpinner(msg string) {
+	t.spinnerMu.Lock()
+	defer t.spinnerMu.Unlock()
+
+	if !t.spinnerActive {
+		return
+	}
+
+	ansi.StopSpinner(t.spinner, msg, t.cfg.Out)
+	t.spinnerActive = false
+}
+
+// connected is called every time the websocket connection is established. The
+// first connection replaces the spinner with the connected banner, which is
+// printed on Log.Out instead when the spinner isn't shown (e.g. when Out
+// isn't a terminal). Later connections only stop the reconnecting spinner.
+func (t *Tailer) connected() {
+	if t.cfg.StrictJSON {
+		return
+	}
+
+	first := false
+	t.bannerOnce.Do(func() { first = true })
+
+	if !first || t.cfg.NoBanner {
+		t.stopSpinner("Ready! You're now waiting to receive API request logs (^C to quit)")
+		return
+	}
+
+	t.spinnerMu.Lock()
+	active := t.spinnerActive
+	t.spinnerMu.Unlock()
+
+	if active {
+		t.stopSpinner(t.banner(t.cfg.Out))
+		return
+	}
+
+	fmt.Fprintln(t.log.Out, t.banner(t.log.Out))
+}
+
+// banner returns the connected banner, colored if w supports it. It includes
+// the websocket session ID when ShowSessionID is set.
+func (t *Tailer) banner(w io.Writer) string {
+	msg := "Tailing request logs... (^C to quit)"
+
+	if t.cfg.ShowSessionID {
+		if id := t.SessionID(); id != "" {
+			msg = fmt.Sprintf("%s [session %s]", msg, id)
+		}
+	}
+
+	color := t.color(w)
+
+	return fmt.Sprintf("%s %s", color.Green("Connected!").Bold(), msg)
+}
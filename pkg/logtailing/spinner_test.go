@@ -0,0 +1,196 @@
+package logtailing
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/ansi"
+)
+
+func TestSpinnerDisabled(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{Log: &log.Logger{Out: &buf}, NoSpinner: true})
+	require.False(t, tailer.spinnerEnabled())
+
+	tailer.startSpinner("Getting ready...")
+	tailer.stopSpinner("Ready!")
+
+	require.Nil(t, tailer.spinner)
+	require.Empty(t, buf.String())
+}
+
+func TestSpinnerSkippedWhenNotTerminal(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{Out: &buf})
+	require.False(t, tailer.spinnerEnabled())
+
+	tailer.startSpinner("Getting ready...")
+	tailer.stopSpinner("Ready!")
+
+	require.Nil(t, tailer.spinner)
+	require.Empty(t, buf.String())
+}
+
+func TestSpinnerTargetsOut(t *testing.T) {
+	var logOut, out bytes.Buffer
+
+	defer func() { isTerminal = ansi.IsTerminal }()
+
+	// Only Log.Out is a terminal
+	isTerminal = func(w io.Writer) bool { return w == &logOut }
+
+	tailer := New(&Config{Log: &log.Logger{Out: &logOut}, Out: &out})
+	require.False(t, tailer.spinnerEnabled())
+
+	// Only Out is a terminal, while Log.Out defaults to discarding
+	isTerminal = func(w io.Writer) bool { return w == &out }
+
+	tailer = New(&Config{Out: &out})
+	require.True(t, tailer.spinnerEnabled())
+
+	tailer.startSpinner("Getting ready...")
+	tailer.stopSpinner("Ready!")
+	require.Equal(t, "Getting ready...\nReady!\n", out.String())
+
+	// A nil Out never gets a spinner
+	tailer.cfg.Out = nil
+	require.False(t, tailer.spinnerEnabled())
+}
+
+func TestSpinnerSkippedForMachineFormats(t *testing.T) {
+	var out bytes.Buffer
+
+	defer func() { isTerminal = ansi.IsTerminal }()
+	isTerminal = func(io.Writer) bool { return true }
+
+	for _, format := range []string{"JSON", "json", "logfmt", "PROTOBUF"} {
+		tailer := New(&Config{OutputFormat: format, Out: &out})
+		require.False(t, tailer.spinnerEnabled(), format)
+
+		tailer.startSpinner("Getting ready...")
+		tailer.stopSpinner("")
+
+		require.Nil(t, tailer.spinner, format)
+		require.Empty(t, out.String(), format)
+	}
+
+	tailer := New(&Config{Out: &out})
+	require.True(t, tailer.spinnerEnabled())
+}
+
+func TestSpinnerMessageDefault(t *testing.T) {
+	tailer := New(&Config{})
+	require.Equal(t, "Getting ready...", tailer.cfg.SpinnerMessage)
+
+	tailer = New(&Config{SpinnerMessage: "Connecting..."})
+	require.Equal(t, "Connecting...", tailer.cfg.SpinnerMessage)
+}
+
+func TestSpinnerStoppedOnError(t *testing.T) {
+	defer func() { isTerminal = ansi.IsTerminal }()
+	isTerminal = func(io.Writer) bool { return true }
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+
+	tailer := New(&Config{APIBaseURL: ts.URL, Out: &buf})
+	require.True(t, tailer.spinnerEnabled())
+
+	// A canceled context makes the authorization fail without retrying
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := tailer.Run(ctx)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Error while authenticating with Stripe")
+
+	require.False(t, tailer.spinnerActive)
+	require.True(t, strings.HasPrefix(buf.String(), "Getting ready...\n"))
+}
+
+func TestSpinnerRestart(t *testing.T) {
+	defer func() { isTerminal = ansi.IsTerminal }()
+	isTerminal = func(io.Writer) bool { return true }
+
+	var buf bytes.Buffer
+
+	tailer := New(&Config{Out: &buf})
+
+	tailer.startSpinner("Getting ready...")
+	require.True(t, tailer.spinnerActive)
+
+	tailer.stopSpinner("Ready!")
+	require.False(t, tailer.spinnerActive)
+
+	// Stopping twice is a no-op
+	tailer.stopSpinner("Ready!")
+
+	tailer.startSpinner("Session expired, reconnecting...")
+	require.True(t, tailer.spinnerActive)
+
+	require.Equal(t, "Getting ready...\nReady!\nSession expired, reconnecting...\n", buf.String())
+}
+
+func TestConnectedBannerPrintedOnce(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{Log: &log.Logger{Out: &buf}, NoColor: true})
+
+	tailer.connected()
+	tailer.connected()
+
+	require.Equal(t, "Connected! Tailing request logs... (^C to quit)\n", buf.String())
+}
+
+func TestConnectedBannerReplacesSpinner(t *testing.T) {
+	isTerminal = func(io.Writer) bool { return true }
+	defer func() { isTerminal = ansi.IsTerminal }()
+
+	var buf bytes.Buffer
+
+	tailer := New(&Config{NoColor: true, Out: &buf})
+
+	tailer.startSpinner("Getting ready...")
+	tailer.connected()
+	tailer.stopSpinner("")
+
+	require.Equal(t, 1, strings.Count(buf.String(), "Connected! Tailing request logs..."))
+	require.NotContains(t, buf.String(), "Ready!")
+}
+
+func TestConnectedBannerSessionID(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{Log: &log.Logger{Out: &buf}, NoColor: true, ShowSessionID: true})
+	tailer.sessionID = "websocket-123"
+
+	tailer.connected()
+
+	require.Equal(t, "Connected! Tailing request logs... (^C to quit) [session websocket-123]\n", buf.String())
+	require.Equal(t, "websocket-123", tailer.report().SessionID)
+}
+
+func TestConnectedBannerDisabled(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{Log: &log.Logger{Out: &buf}, NoBanner: true, NoColor: true})
+
+	tailer.connected()
+	tailer.connected()
+
+	require.Empty(t, buf.String())
+}
@@ -0,0 +1,185 @@
+package logtailing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ws "github.com/gorilla/websocket"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/stripeauth"
+	"github.com/stripe/stripe-cli/pkg/websocket"
+)
+
+// newStreamsServer returns a stub of the Stripe sessions endpoint and of the
+// websocket server, sending the messages given for each feature on its
+// stream.
+func newStreamsServer(t *testing.T, messages map[string][]interface{}, done chan struct{}) *httptest.Server {
+	upgrader := ws.Upgrader{}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/stripecli/sessions" {
+			require.NoError(t, r.ParseForm())
+			feature := r.PostForm.Get("websocket_feature")
+
+			json.NewEncoder(w).Encode(stripeauth.StripeCLISession{
+				WebSocketID:                "websocket-" + feature,
+				WebSocketURL:               "wss://" + r.Host + "/subscribe/" + feature,
+				WebSocketAuthorizedFeature: feature,
+			})
+
+			return
+		}
+
+		feature := strings.TrimPrefix(r.URL.Path, "/subscribe/")
+
+		c, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer c.Close()
+
+		for _, msg := range messages[feature] {
+			require.NoError(t, c.WriteJSON(msg))
+		}
+
+		// Keep the connection open so that the client doesn't reconnect
+		<-done
+	}))
+}
+
+func TestWebSocketFeatures(t *testing.T) {
+	done := make(chan struct{})
+
+	ts := newStreamsServer(t, map[string][]interface{}{
+		"request_logs": {
+			websocket.RequestLogEvent{Type: "request_log_event", EventPayload: `{"method":"POST","request_id":"req_1","status":200,"url":"/v1/charges"}`},
+			websocket.RequestLogEvent{Type: "request_log_event", EventPayload: `{"method":"POST","request_id":"req_2","status":402,"url":"/v1/charges"}`},
+		},
+		"webhooks": {
+			websocket.WebhookEvent{Type: "webhook_event", WebhookID: "wh_1", EventPayload: `{}`},
+			websocket.RequestLogEvent{Type: "request_log_event", EventPayload: `{"method":"GET","request_id":"req_3","status":200,"url":"/v1/events"}`},
+		},
+	}, done)
+	defer ts.Close()
+	defer close(done)
+
+	var out syncBuffer
+
+	logger := log.New()
+	logger.Out = &syncBuffer{}
+	logger.ExitFunc = func(int) {}
+
+	tailer := New(&Config{
+		APIBaseURL:        ts.URL,
+		Key:               "sk_test_123",
+		Log:               logger,
+		NoColor:           true,
+		NoWSS:             true,
+		Out:               &out,
+		WebSocketFeatures: []string{"request_logs", "webhooks"},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error)
+
+	go func() {
+		stopped <- tailer.Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return strings.Count(out.String(), "\n") == 3
+	}, 2*time.Second, 10*time.Millisecond)
+
+	cancel()
+
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "Timed out waiting for Run to return")
+	}
+
+	output := out.String()
+	require.Contains(t, output, "[request_logs] ")
+	require.Contains(t, output, "[webhooks] ")
+
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		switch {
+		case strings.Contains(line, "[req_3]"):
+			require.True(t, strings.HasPrefix(line, "[webhooks] "), line)
+		default:
+			require.True(t, strings.HasPrefix(line, "[request_logs] "), line)
+		}
+	}
+
+	// The webhook event isn't a request log
+	require.Equal(t, 3, tailer.report().Total)
+	require.Zero(t, tailer.report().Malformed)
+}
+
+func TestWebSocketFeaturesJSON(t *testing.T) {
+	var buf syncBuffer
+
+	tailer := New(&Config{Out: &buf, OutputFormat: "JSON", WebSocketFeatures: []string{"request_logs", "webhooks"}})
+
+	tailer.processMessage(requestLogMessage(`{"method":"GET","status":200}`), "webhooks")
+
+	var envelope Envelope
+	require.NoError(t, json.Unmarshal([]byte(buf.String()), &envelope))
+	require.Equal(t, "webhooks", envelope.Feature)
+	require.JSONEq(t, `{"method":"GET","status":200}`, string(envelope.Payload))
+}
+
+func TestSingleFeatureIsNotTagged(t *testing.T) {
+	tailer := New(&Config{WebSocketFeature: "request_logs"})
+	require.Equal(t, []string{"request_logs"}, tailer.features())
+	require.False(t, tailer.tagged())
+
+	tailer = New(&Config{WebSocketFeatures: []string{"request_logs"}})
+	require.False(t, tailer.tagged())
+}
+
+func TestSessionIDFromStream(t *testing.T) {
+	done := make(chan struct{})
+
+	ts := newStreamsServer(t, map[string][]interface{}{}, done)
+	defer ts.Close()
+	defer close(done)
+
+	var logOut syncBuffer
+
+	logger := log.New()
+	logger.Out = &logOut
+	logger.ExitFunc = func(int) {}
+
+	tailer := New(&Config{
+		APIBaseURL:       ts.URL,
+		Key:              "sk_test_123",
+		Log:              logger,
+		NoColor:          true,
+		NoWSS:            true,
+		Out:              &syncBuffer{},
+		ShowSessionID:    true,
+		WebSocketFeature: "request_logs",
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error)
+
+	go func() {
+		stopped <- tailer.Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return strings.Contains(logOut.String(), "[session websocket-request_logs]")
+	}, 2*time.Second, 10*time.Millisecond)
+
+	require.Equal(t, "websocket-request_logs", tailer.SessionID())
+
+	cancel()
+	<-stopped
+}
@@ -0,0 +1,51 @@
+package logtailing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// validateStrictJSON checks that nothing but JSON objects can be written to
+// Out when StrictJSON is set.
+func (t *Tailer) validateStrictJSON() error {
+	if !t.cfg.StrictJSON {
+		return nil
+	}
+
+	if t.cfg.CountOnly {
+		return errors.New("strict JSON output can't be combined with count-only mode")
+	}
+
+	for _, output := range t.outputs {
+		if output.Out == t.cfg.Out && !strings.EqualFold(output.Format, outputFormatJSON) {
+			return fmt.Errorf("strict JSON output requires the JSON format, got %q", output.Format)
+		}
+	}
+
+	return nil
+}
+
+// statusOut returns where status messages such as pause notices are
+// written: Out, unless StrictJSON reserves it for JSON objects or the
+// protobuf format for binary frames.
+func (t *Tailer) statusOut() io.Writer {
+	if t.cfg.StrictJSON || t.binaryOut() {
+		return t.log.Out
+	}
+
+	return t.cfg.Out
+}
+
+// writeStrictJSON writes a JSON line without colors, dropping it if it
+// isn't valid JSON.
+func (t *Tailer) writeStrictJSON(w io.Writer, jsonLine string) {
+	if !json.Valid([]byte(jsonLine)) {
+		t.onError(fmt.Errorf("not writing malformed JSON in strict mode: %s", jsonLine))
+		return
+	}
+
+	fmt.Fprintln(w, jsonLine)
+}
@@ -0,0 +1,82 @@
+package logtailing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/ansi"
+)
+
+func TestStrictJSON(t *testing.T) {
+	defer func() { isTerminal = ansi.IsTerminal }()
+	isTerminal = func(io.Writer) bool { return true }
+
+	var out, logOut bytes.Buffer
+
+	tailer := New(&Config{
+		ControlRecords: true,
+		Log:            &log.Logger{Out: &logOut},
+		Out:            &out,
+		OutputFormat:   "JSON",
+		Pausable:       true,
+		StrictJSON:     true,
+	})
+	require.NoError(t, tailer.validateConfig())
+
+	tailer.startSpinner("Getting ready...")
+	tailer.connected()
+
+	tailer.ProcessMessage(requestLogMessage(`{"method":"POST","request_id":"req_1","status":200}`))
+	tailer.ProcessMessage(requestLogMessage(`not json`))
+	tailer.recordReconnect()
+
+	tailer.mu.Lock()
+	tailer.pause()
+	tailer.mu.Unlock()
+
+	tailer.ProcessMessage(requestLogMessage(`{"method":"GET","request_id":"req_2","status":500}`))
+
+	tailer.mu.Lock()
+	tailer.resume()
+	tailer.mu.Unlock()
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 3)
+
+	for _, line := range lines {
+		var object map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &object), line)
+	}
+
+	require.Contains(t, logOut.String(), "Paused, press space to resume")
+	require.NotContains(t, logOut.String(), "Connected!")
+}
+
+func TestStrictJSONRequiresJSONFormat(t *testing.T) {
+	ctx := context.Background()
+
+	err := New(&Config{Out: &bytes.Buffer{}, StrictJSON: true}).Run(ctx)
+	require.EqualError(t, err, `strict JSON output requires the JSON format, got ""`)
+
+	err = New(&Config{CountOnly: true, Out: &bytes.Buffer{}, OutputFormat: "JSON", StrictJSON: true}).Run(ctx)
+	require.EqualError(t, err, "strict JSON output can't be combined with count-only mode")
+
+	var out bytes.Buffer
+
+	err = New(&Config{
+		Out:          &out,
+		OutputFormat: "JSON",
+		Outputs:      []Output{{Out: &bytes.Buffer{}, Format: "logfmt"}},
+		StrictJSON:   true,
+		Input:        strings.NewReader(`{"method":"POST","status":200}`),
+	}).Run(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "{\"method\":\"POST\",\"status\":200}\n", out.String())
+}
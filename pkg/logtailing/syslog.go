@@ -0,0 +1,180 @@
+package logtailing
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultSyslogTag = "stripe-cli"
+
+	syslogSinkBufferSize    = 1000
+	syslogSinkRetryInterval = 5 * time.Second
+	syslogSinkDialTimeout   = 2 * time.Second
+)
+
+// syslogDial is overridden in tests to simulate an unresponsive syslog.
+var syslogDial = dialSyslog
+
+// syslogWriter is the subset of *syslog.Writer used by syslogSink.
+type syslogWriter interface {
+	Info(msg string) error
+	Err(msg string) error
+	Close() error
+}
+
+// syslogMessage is a formatted event queued for syslog.
+type syslogMessage struct {
+	line  string
+	error bool
+}
+
+// syslogSink writes formatted events to the local or a remote syslog. Events
+// are queued so that an unavailable syslog never blocks the console output,
+// and connecting is retried at most every syslogSinkRetryInterval.
+type syslogSink struct {
+	network string
+	addr    string
+	tag     string
+	log     *log.Logger
+
+	messages chan syslogMessage
+	done     chan struct{}
+}
+
+func newSyslogSink(network, addr, tag string, logger *log.Logger) *syslogSink {
+	if tag == "" {
+		tag = defaultSyslogTag
+	}
+
+	return &syslogSink{
+		network:  network,
+		addr:     addr,
+		tag:      tag,
+		log:      logger,
+		messages: make(chan syslogMessage, syslogSinkBufferSize),
+		done:     make(chan struct{}),
+	}
+}
+
+// write queues a formatted event, logged at the error priority if isError.
+// The event is dropped if the queue is full.
+func (s *syslogSink) write(line string, isError bool) {
+	select {
+	case s.messages <- syslogMessage{line: line, error: isError}:
+	default:
+		s.log.WithFields(log.Fields{
+			"prefix": "logtailing.syslogSink.write",
+		}).Debug("Syslog queue is full, dropping event")
+	}
+}
+
+// run writes queued events until ctx is canceled, then writes the remaining
+// events and closes the done channel.
+func (s *syslogSink) run(ctx context.Context) {
+	defer close(s.done)
+
+	var writer syslogWriter
+	var lastDial time.Time
+
+	defer func() {
+		if writer != nil {
+			writer.Close() // #nosec G104
+		}
+	}()
+
+	send := func(msg syslogMessage) {
+		if writer == nil {
+			if !lastDial.IsZero() && time.Since(lastDial) < syslogSinkRetryInterval {
+				return
+			}
+
+			lastDial = time.Now()
+
+			var err error
+			if writer, err = s.dial(ctx); err != nil {
+				s.log.WithFields(log.Fields{
+					"prefix": "logtailing.syslogSink.run",
+				}).Debug("Failed to connect to syslog, dropping event: ", err)
+
+				return
+			}
+		}
+
+		write := writer.Info
+		if msg.error {
+			write = writer.Err
+		}
+
+		if err := write(msg.line); err != nil {
+			s.log.WithFields(log.Fields{
+				"prefix": "logtailing.syslogSink.run",
+			}).Debug("Syslog write error: ", err)
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case msg := <-s.messages:
+					send(msg)
+				default:
+					return
+				}
+			}
+		case msg := <-s.messages:
+			send(msg)
+		}
+	}
+}
+
+// dial connects to the syslog, giving up after syslogSinkDialTimeout or
+// once ctx is canceled, since dialing can't be interrupted otherwise. A
+// connection established after giving up is closed.
+func (s *syslogSink) dial(ctx context.Context) (syslogWriter, error) {
+	type dialResult struct {
+		writer syslogWriter
+		err    error
+	}
+
+	results := make(chan dialResult, 1)
+	dial := syslogDial
+
+	go func() {
+		writer, err := dial(s.network, s.addr, s.tag)
+		results <- dialResult{writer, err}
+	}()
+
+	abandon := func() {
+		go func() {
+			if r := <-results; r.writer != nil {
+				r.writer.Close() // #nosec G104
+			}
+		}()
+	}
+
+	timer := time.NewTimer(syslogSinkDialTimeout)
+	defer timer.Stop()
+
+	select {
+	case r := <-results:
+		return r.writer, r.err
+	case <-ctx.Done():
+		abandon()
+		return nil, ctx.Err()
+	case <-timer.C:
+		abandon()
+		return nil, errors.New("timed out connecting to syslog")
+	}
+}
+
+// wait blocks until run has returned and the remaining events have been
+// written.
+func (s *syslogSink) wait() {
+	<-s.done
+}
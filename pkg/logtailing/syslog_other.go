@@ -0,0 +1,12 @@
+// +build windows plan9
+
+package logtailing
+
+import (
+	"errors"
+)
+
+// dialSyslog is not supported on this platform.
+func dialSyslog(network, addr, tag string) (syslogWriter, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
@@ -0,0 +1,123 @@
+// +build !windows,!plan9
+
+package logtailing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyslog(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	tailer := New(&Config{
+		NoColor:       true,
+		Out:           &bytes.Buffer{},
+		Syslog:        true,
+		SyslogAddr:    conn.LocalAddr().String(),
+		SyslogNetwork: "udp",
+		SyslogTag:     "tail-test",
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tailer.startSinks(ctx)
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_1"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":402,"url":"/v1/charges","request_id":"req_2"}`))
+
+	var messages []string
+
+	buf := make([]byte, 2048)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+
+		n, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+
+		messages = append(messages, string(buf[:n]))
+	}
+
+	cancel()
+	tailer.finish()
+
+	// <14> is user.info and <11> is user.err
+	require.Regexp(t, `^<14>.* tail-test\[\d+\]: .*\[200\] GET /v1/customers \[req_1\]\n?$`, messages[0])
+	require.Regexp(t, `^<11>.* tail-test\[\d+\]: .*\[402\] POST /v1/charges \[req_2\]\n?$`, messages[1])
+}
+
+func TestSyslogUnavailableDoesNotBlock(t *testing.T) {
+	// Dialing hangs, as it does against an unreachable TCP address
+	hang := make(chan struct{})
+	defer close(hang)
+
+	defer func() { syslogDial = dialSyslog }()
+	syslogDial = func(network, addr, tag string) (syslogWriter, error) {
+		<-hang
+		return nil, errors.New("unreachable")
+	}
+
+	lines := make([]string, syslogSinkBufferSize+10)
+	for i := range lines {
+		lines[i] = `{"method":"GET","status":200,"url":"/v1/customers"}`
+	}
+
+	var out bytes.Buffer
+
+	tailer := New(&Config{
+		NoColor:       true,
+		Out:           &out,
+		ReplayFile:    writeReplayFile(t, lines...),
+		Syslog:        true,
+		SyslogAddr:    "10.255.255.1:514",
+		SyslogNetwork: "tcp",
+	})
+
+	stopped := make(chan error)
+
+	go func() {
+		stopped <- tailer.Run(context.Background())
+	}()
+
+	select {
+	case err := <-stopped:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		require.FailNow(t, "Timed out waiting for Run to return")
+	}
+
+	require.Equal(t, syslogSinkBufferSize+10, bytes.Count(out.Bytes(), []byte("\n")))
+}
+
+func TestSyslogDialTimeout(t *testing.T) {
+	hang := make(chan struct{})
+	defer close(hang)
+
+	defer func() { syslogDial = dialSyslog }()
+	syslogDial = func(network, addr, tag string) (syslogWriter, error) {
+		<-hang
+		return nil, errors.New("unreachable")
+	}
+
+	sink := newSyslogSink("tcp", "10.255.255.1:514", "", &log.Logger{Out: ioutil.Discard})
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	_, err := sink.dial(ctx)
+	require.Equal(t, context.Canceled, err)
+}
@@ -0,0 +1,18 @@
+// +build !windows,!plan9
+
+package logtailing
+
+import (
+	"log/syslog"
+)
+
+// dialSyslog connects to the syslog at addr over network, or to the local
+// syslog if network is empty.
+func dialSyslog(network, addr, tag string) (syslogWriter, error) {
+	writer, err := syslog.Dial(network, addr, syslog.LOG_INFO|syslog.LOG_USER, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	return writer, nil
+}
@@ -3,14 +3,20 @@ package logtailing
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"os/signal"
-	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/briandowns/spinner"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/stripe/stripe-cli/pkg/ansi"
@@ -18,7 +24,20 @@ import (
 	"github.com/stripe/stripe-cli/pkg/websocket"
 )
 
-const outputFormatJSON = "JSON"
+const (
+	outputFormatJSON     = "JSON"
+	outputFormatLogfmt   = "LOGFMT"
+	outputFormatProtobuf = "PROTOBUF"
+)
+
+// DefaultEmptyURLText is printed in place of the path of request logs
+// without a URL when Config.EmptyURLText is empty.
+const DefaultEmptyURLText = "[View path in dashboard]"
+
+// DefaultExcludeExactPaths are the request paths excluded from the request
+// logs when Config.ExcludeExactPaths is nil. The stripecli/sessions requests
+// are generated by the CLI itself.
+var DefaultExcludeExactPaths = []string{"/v1/stripecli/sessions"}
 
 // LogFilters contains all of the potential user-provided filters for log tailing
 type LogFilters struct {
@@ -30,53 +49,619 @@ type LogFilters struct {
 	FilterSource         []string `json:"filter_source,omitempty"`
 	FilterStatusCode     []string `json:"filter_status_code,omitempty"`
 	FilterStatusCodeType []string `json:"filter_status_code_type,omitempty"`
+
+	// The following filters are applied client-side only
+	FilterDeclineCode    []string `json:"-"`
+	FilterErrorCode      []string `json:"-"`
+	FilterRequestID      []string `json:"-"`
+	FilterStatusCategory []string `json:"-"`
+	FilterStatusText     []string `json:"-"`
+
+	// MinStatus and MaxStatus are inclusive bounds on the status code. Zero
+	// means no bound.
+	MinStatus int `json:"-"`
+	MaxStatus int `json:"-"`
 }
 
 // Config provides the configuration of a log tailer
 type Config struct {
 	APIBaseURL string
 
+	// BurstGap prints a faint --- divider before a request log received more
+	// than BurstGap after the previous one, to separate bursts of traffic.
+	// JSON output is left untouched. Zero disables it.
+	BurstGap time.Duration
+
+	// Collapse coalesces consecutive request logs with the same method, URL
+	// and status into a single line with a (xN) count in the default output
+	// format. JSON output keeps every event.
+	Collapse bool
+
+	// CollapseInterval is the maximum time a group of collapsed request logs
+	// is held before being printed. Defaults to 1 second.
+	CollapseInterval time.Duration
+
+	// ColorMode controls colors and other ANSI sequences: auto (the default)
+	// uses them when the output supports it, always forces them even when
+	// redirected, e.g. to a pager, and never disables them. NoColor takes
+	// precedence.
+	ColorMode string
+
+	// CompactErrors prints the error fields of a request log on a single
+	// indented line, e.g. `error: type=card_error code=card_declined`,
+	// instead of one line per field
+	CompactErrors bool
+
+	// ControlRecords writes a ControlRecord such as
+	// {"_control":"reconnect","at":"2024-01-02T15:00:00Z"} to the JSON
+	// outputs and OutFile when the session is reconnected, so that NDJSON
+	// consumers can detect possible gaps in the stream
+	ControlRecords bool
+
+	// CountOnly replaces the request log lines with a single, in-place
+	// updated count of received request logs
+	CountOnly bool
+
+	// DayDividers prints a "--- 2024-01-03 ---" divider before the first
+	// request log of each day, and only the time at the start of each line, in
+	// the default format. This keeps the date in view for tails that run for
+	// days.
+	DayDividers bool
+
 	// DeviceName is the name of the device sent to Stripe to help identify the device
 	DeviceName string
 
+	// ExpandErrors prints the indented JSON payload beneath the request log
+	// line of events with a status of 400 or above
+	ExpandErrors bool
+
+	// DropWhenFull drops the oldest pending payload of the Events channel when
+	// it's full, instead of blocking the processing of request logs until the
+	// consumer catches up. Dropped payloads are counted in the report.
+	DropWhenFull bool
+
+	// EmptyURLText is printed in place of the path of request logs without a
+	// URL in the default format, unless OmitEmptyURL is set. Defaults to
+	// DefaultEmptyURLText.
+	EmptyURLText string
+
+	// ErrorsOnly only keeps the request logs that failed: the ones with any
+	// error field set, including on a successful status, or a status of 400
+	// and above. It applies on top of the status filters.
+	ErrorsOnly bool
+
+	// EventChannelBuffer is the capacity of the channel returned by Events.
+	// Defaults to 100.
+	EventChannelBuffer int
+
+	// EventSocket is the path of a Unix domain socket that receives every
+	// displayed event as NDJSON, in addition to the console output
+	EventSocket string
+
+	// ExcludeExactPaths are request paths, compared without their query
+	// string, whose request logs are never shown. Defaults to
+	// DefaultExcludeExactPaths when nil; set it to an empty slice to show
+	// every path.
+	ExcludeExactPaths []string
+
+	// FilterNoise drops request logs for health checks and similar noise,
+	// i.e. paths ending with one of DefaultNoisePaths or NoisePaths
+	FilterNoise bool
+
 	// Filters for API request logs
 	Filters *LogFilters
 
+	// FlushInterval is how often OutFile is flushed with the "interval"
+	// FlushPolicy. Defaults to 1s.
+	FlushInterval time.Duration
+
+	// FlushPolicy controls when lines buffered for OutFile are written to
+	// disk: after every "line" (the default), every FlushInterval with
+	// "interval", or once FlushSize bytes are buffered with "size". The
+	// buffer is always flushed on shutdown.
+	FlushPolicy string
+
+	// FlushSize is the number of buffered bytes that flushes OutFile with the
+	// "size" FlushPolicy. Defaults to 64KiB.
+	FlushSize int
+
+	// ForceColorJSON colorizes the JSON output format even when the output
+	// isn't a terminal, e.g. to page through a file with `less -R`. It's
+	// ignored with NoColor or the never ColorMode.
+	ForceColorJSON bool
+
+	// ForwardURL is an HTTP endpoint that receives displayed events as JSON
+	// arrays, in addition to the console output
+	ForwardURL string
+
+	// ForwardBatchSize is the number of events sent to ForwardURL in a single
+	// request. Defaults to 1.
+	ForwardBatchSize int
+
+	// ForwardFlushInterval is the maximum time events are buffered before
+	// being sent to ForwardURL, regardless of ForwardBatchSize. Zero disables
+	// time-based flushing.
+	ForwardFlushInterval time.Duration
+
+	// GapThreshold warns about possibly lost request logs when one is created
+	// more than this before the latest one received, or more than this after
+	// it beyond the time elapsed between their arrivals. Zero disables the
+	// detection.
+	GapThreshold time.Duration
+
+	// GRPCTarget is the address of a gRPC collector, as host:port for
+	// plaintext HTTP/2 or an https:// URL, that receives displayed events as
+	// protobuf Event messages on a streaming RPC, in addition to the console
+	// output
+	GRPCTarget string
+
+	// Headers are additional headers sent with the websocket handshake
+	// request, e.g. for proxies or gateways in front of Stripe. Headers set
+	// by the websocket client itself can't be overridden.
+	Headers map[string]string
+
+	// Heartbeat prints a line when no request log has been received for the
+	// given interval, so that long silent periods don't look like a hang.
+	// Ignored in the JSON, logfmt and count-only modes.
+	Heartbeat time.Duration
+
+	// HighlightLatest shows the most recent request log line in reverse video,
+	// so that it stands out in a fast scroll. The line is restored when the
+	// next one arrives. Only applies to the default format on a terminal
+	// with colors, and is ignored in the count-only, NewPathsOnly and
+	// Transitions modes.
+	HighlightLatest bool
+
+	// IncludeDevice adds DeviceName to the Envelope of each JSON request log,
+	// to tell where events come from once captures from several machines are
+	// merged
+	IncludeDevice bool
+
+	// IncludeEventID adds a stable hash of the request ID, creation time and
+	// status of each JSON request log to its Envelope, as an idempotency key
+	// for consumers deduping the output of several CLI instances
+	IncludeEventID bool
+
+	// IncludeHostname adds the host name of the machine to the Envelope of
+	// each JSON request log
+	IncludeHostname bool
+
+	// IncludeMetadata wraps the JSON output in an Envelope carrying the
+	// request log ID and type of the websocket message that delivered each
+	// request log, and the kind of its error
+	IncludeMetadata bool
+
+	// Input is a reader of NDJSON request log payloads, as written by the
+	// JSON output format, that is read instead of connecting to Stripe. It
+	// takes precedence over ReplayFile.
+	Input io.Reader
+
+	// JSONArray writes the JSON outputs as a single JSON array instead of
+	// NDJSON, closed once every request log is written. It requires a
+	// bounded capture, i.e. Input or ReplayFile.
+	JSONArray bool
+
 	// Key is the API key used to authenticate with Stripe
 	Key string
 
 	// Info, error, etc. logger. Unrelated to API request logs.
 	Log *log.Logger
 
+	// LineColorByStatus colors the whole line of request logs in the default
+	// format by status class, instead of only the status code
+	LineColorByStatus bool
+
+	// MalformedThreshold is the share of malformed messages, between 0 and 1,
+	// above which Run gives up with an error suggesting a version or feature
+	// mismatch. Zero disables the check.
+	MalformedThreshold float64
+
+	// MalformedWindow is the period over which the share of malformed
+	// messages is measured. Defaults to 10 seconds.
+	MalformedWindow time.Duration
+
+	// MaskAccount masks all but the last 4 characters of account IDs in the
+	// console output, e.g. acct_***a1b2, for screen-sharing Connect tails.
+	// OutFile and the other sinks receive the unmasked request logs.
+	MaskAccount bool
+
+	// MaxAge drops request logs created more than this long ago, e.g. to
+	// skip stale events when replaying an old capture. Request logs without
+	// a creation time are kept. Zero keeps every request log.
+	MaxAge time.Duration
+
+	// MaxBytes stops the session once the events written to the sinks,
+	// including Sinks, add up to more than this many bytes, counted for every
+	// sink as the size of their JSON encoding, newline included. It requires
+	// at least one sink. Zero means no limit.
+	MaxBytes int64
+
+	// MaxErrorMessageLen truncates error messages longer than this many
+	// characters in the default output format. JSON output is left
+	// untouched. Zero means no truncation.
+	MaxErrorMessageLen int
+
+	// Middleware is a chain of functions applied in order to every request
+	// log that passes the Filters, before redaction. Each function returns
+	// the payload passed to the next one, or false to drop the event. When
+	// any middleware is configured, JSON output is re-encoded from the
+	// resulting EventPayload rather than passed through verbatim.
+	Middleware []func(EventPayload) (EventPayload, bool)
+
+	// MutatingOnly only keeps the request logs of requests that can change
+	// data, i.e. POST, PUT, PATCH and DELETE, dropping reads such as GET,
+	// HEAD and OPTIONS. It's applied client-side.
+	MutatingOnly bool
+
+	// NewPathsOnly only prints a line the first time each endpoint, a method
+	// and normalized path such as GET /v1/customers/:id, is hit during the
+	// session, building an inventory of the endpoints in use. Later request
+	// logs for the endpoint are still counted.
+	NewPathsOnly bool
+
+	// OnError is called with the non-fatal errors encountered while
+	// processing request logs, such as malformed payloads. These errors are
+	// also logged at the debug level.
+	OnError func(error)
+
+	// OnErrorCommand is a shell command run in the background whenever a
+	// server error (status 500 or above) passes the filters, including in
+	// the count-only mode and while paused. The request log's fields are
+	// passed as environment variables such as STRIPE_STATUS and
+	// STRIPE_REQUEST_ID, unmasked by MaskAccount. Failures are logged as
+	// warnings. Run waits for the running commands before returning, for up
+	// to 5 seconds.
+	OnErrorCommand string
+
+	// OnReconnect is called with a copy of the current filters before the
+	// session is reauthorized after expiring, e.g. to narrow the filters
+	// after an initial broad sample. The filters it returns are validated
+	// and sent to Stripe, or the current ones are kept if it returns nil or
+	// invalid filters. It's called while the processing of request logs is
+	// held, so it should return quickly.
+	OnReconnect func(*LogFilters) *LogFilters
+
+	// Now returns the current time. It defaults to time.Now and can be
+	// replaced to make time-dependent output deterministic, e.g. in tests.
+	Now func() time.Time
+
+	// NoBanner suppresses the banner printed on Log.Out once the first
+	// connection is established.
+	NoBanner bool
+
+	// NoColor disables colors and other ANSI sequences in request logs
+	NoColor bool
+
+	// NoSpinner disables the progress spinner shown on Out while
+	// connecting. The spinner is always skipped when Out is not a terminal.
+	NoSpinner bool
+
 	// Force use of unencrypted ws:// protocol instead of wss://
 	NoWSS bool
 
+	// NoisePaths are additional path suffixes dropped by FilterNoise
+	NoisePaths []string
+
+	// OmitEmptyURL omits the path of request logs without a URL altogether
+	// in the default format, instead of printing EmptyURLText
+	OmitEmptyURL bool
+
+	// OTLPEndpoint is an OTLP/HTTP logs endpoint, e.g.
+	// http://localhost:4318/v1/logs, that displayed events are also exported
+	// to as OpenTelemetry log records
+	OTLPEndpoint string
+
+	// Out is where request logs are written, in every output format.
+	// Defaults to os.Stdout. Status messages such as warnings are written to
+	// Log.Out instead so that they never get mixed with JSON output.
+	Out io.Writer
+
+	// OutFile is a file that request logs are appended to as NDJSON, in
+	// addition to the console output. When PartitionBy is set, it is used as
+	// the prefix of the partition files.
+	OutFile string
+
 	// Output format for request logs
 	OutputFormat string
 
+	// Outputs are additional destinations for request logs, each with its
+	// own format
+	Outputs []Output
+
+	// OutputQueueSize queues up to this many writes to Out for a dedicated
+	// writer, so that a slow Out such as a laggy terminal over SSH doesn't
+	// hold up the processing of each request log. Once the queue is full,
+	// processing waits for it rather than dropping output, and the stall is
+	// counted in the report. Zero writes to Out directly.
+	OutputQueueSize int
+
+	// Pausable lets the output be paused and resumed by pressing space when
+	// Out is a terminal. Events received while paused are buffered, up to
+	// PauseBufferSize, and displayed on resume.
+	Pausable bool
+
+	// PauseBufferSize is the maximum number of events buffered while paused.
+	// Further events are dropped from the console output. Defaults to 1000.
+	PauseBufferSize int
+
+	// PauseInput is where key presses are read from when Pausable is set.
+	// Defaults to os.Stdin.
+	PauseInput io.Reader
+
+	// PartitionBy splits OutFile into one file per "hour" or "day" of the
+	// request logs' creation time in UTC, e.g. traffic-2024-01-02-15.ndjson
+	// for an OutFile of traffic
+	PartitionBy string
+
+	// QuietUntilError keeps the console output silent until something goes
+	// wrong: only request logs with a status of 400 and above are printed,
+	// expanded as with ExpandErrors. The others are still counted, sent to
+	// the sinks and Outputs other than the console, and included in the
+	// summary printed on exit. With Heartbeat, the heartbeat line tells how
+	// many request logs were received quietly.
+	QuietUntilError bool
+
+	// RecentSize keeps the last RecentSize request logs in memory, for
+	// Recent to return. Zero disables it.
+	RecentSize int
+
+	// ReconnectJitter randomly shortens or lengthens each wait between
+	// attempts to reconnect to Stripe by up to this duration, so that CLIs
+	// disconnected at the same time don't all reconnect at once. Defaults to
+	// 2s, negative disables jitter.
+	ReconnectJitter time.Duration
+
+	// RedactPatterns are regular expressions whose matches are replaced with
+	// [REDACTED] in every string field of request logs before they are output
+	RedactPatterns []string
+
+	// Redactor is called with every request log before it is output, after
+	// RedactPatterns are applied, and returns the payload to output instead.
+	// When any redaction is configured, JSON output is re-encoded from the
+	// redacted EventPayload rather than passed through verbatim.
+	Redactor func(EventPayload) EventPayload
+
+	// ReplayFile is an NDJSON file of request log payloads, as written by the
+	// JSON output format, that is replayed instead of connecting to Stripe
+	ReplayFile string
+
+	// ReplaySpeed scales the original time between replayed events, e.g. 2
+	// replays twice as fast. Zero replays events as fast as possible.
+	ReplaySpeed float64
+
+	// ReportFile is a file written on exit with a JSON summary of the
+	// session, for machine consumers. "-" writes the summary to stderr.
+	ReportFile string
+
+	// Responsive adapts the columns of the default format to the width of
+	// Out: narrow terminals only get the time, status and method, while
+	// wide ones also get the latency. The width is looked up again when the
+	// terminal is resized, and outputs that aren't terminals get the usual
+	// columns.
+	Responsive bool
+
+	// ShowFilters prints a summary of the active filters on Log.Out at
+	// startup, e.g. `Filters: methods=[POST], status_type=[5XX]`. Ignored
+	// with StrictJSON.
+	ShowFilters bool
+
+	// ShowMatchedFilter appends the client-side filters that let each
+	// request log through to the default output, and adds them as
+	// matched_filters to the JSON Envelope
+	ShowMatchedFilter bool
+
+	// ShowSessionID adds the ID of the websocket session to the connected
+	// banner, for Stripe support to correlate it with their logs. It's
+	// always included in the report.
+	ShowSessionID bool
+
+	// ShowSeq numbers the displayed request logs, prefixing each line with
+	// its sequence number and adding a seq field to the JSON output
+	ShowSeq bool
+
+	// ShowSize appends the size of each request log's payload as received
+	// from Stripe to its line, e.g. [412B], and adds a payload_bytes field to
+	// the JSON output
+	ShowSize bool
+
+	// ShowWebSocketURL logs the resolved websocket URL before connecting,
+	// useful for debugging connectivity
+	ShowWebSocketURL bool
+
+	// Sinks receive every request log alongside the console output, see
+	// Sink
+	Sinks []Sink
+
+	// SinkRetries is the number of times a failed write to one of Sinks is
+	// retried before the request log is dropped and reported to OnError.
+	// When set, each sink is written to from its own goroutine, so that
+	// retries don't hold up the processing of request logs. Zero doesn't
+	// retry.
+	SinkRetries int
+
+	// SinkRetryBackoff is the wait before the first retry of a failed sink
+	// write, doubling with every retry. Defaults to 100ms.
+	SinkRetryBackoff time.Duration
+
+	// SpinnerMessage is the message shown next to the spinner while
+	// connecting. Defaults to "Getting ready...".
+	SpinnerMessage string
+
+	// StatusGlyph replaces the status code with a colored ● in the default
+	// output format: green for successes, cyan for redirects and red for
+	// errors, followed by the code in parentheses for errors only
+	StatusGlyph bool
+
+	// StrictJSON guarantees that nothing but valid JSON objects is written to
+	// Out, for scripts parsing the JSON output format: malformed payloads are
+	// dropped, colors are disabled, the spinner and banner are turned off and
+	// status messages go to Log.Out instead
+	StrictJSON bool
+
+	// StrictReplay stops a replay with an error on the first malformed line
+	// instead of skipping it
+	StrictReplay bool
+
+	// StripQuery removes query strings from request paths in the default
+	// output format. JSON output is left untouched.
+	StripQuery bool
+
+	// Syslog writes each request log, formatted as in the default output, to
+	// the syslog: at the error priority for statuses of 400 and above, and at
+	// the info priority otherwise
+	Syslog bool
+
+	// SyslogAddr and SyslogNetwork are the address of a remote syslog and
+	// the network to reach it on, e.g. "udp". Defaults to the local syslog.
+	SyslogAddr    string
+	SyslogNetwork string
+
+	// SyslogTag is the tag of the syslog messages. Defaults to "stripe-cli".
+	SyslogTag string
+
+	// ThroughputInterval updates a gauge of received request logs per second
+	// at this interval in count-only mode. The gauge is updated in place on
+	// terminals and printed as a new line each interval otherwise. Zero
+	// disables the gauge.
+	ThroughputInterval time.Duration
+
+	// TimeZone is the IANA name of the time zone request log times are
+	// printed in, e.g. America/New_York. Defaults to the local time zone.
+	TimeZone string
+
+	// Transitions only prints a line when the status class of a path changes,
+	// e.g. from 2xx to 5xx, instead of every request log. Object IDs in paths
+	// are normalized so that e.g. all customers share one path.
+	Transitions bool
+
+	// WatchBell rings the terminal bell when the request log of
+	// WatchRequestID arrives.
+	WatchBell bool
+
+	// WatchRequestID shows the request log of this request in reverse video
+	// when it arrives, e.g. when waiting for a request during a support
+	// session, while still showing every other request log. Only applies to
+	// the default format, and the highlight needs colors.
+	WatchRequestID string
+
 	// WebSocketFeature is the feature specified for the websocket connection
 	WebSocketFeature string
+
+	// WebSocketFeatures streams several features at once, with one
+	// connection per feature, instead of WebSocketFeature alone. When there
+	// is more than one, request logs are tagged with the feature they were
+	// received on.
+	WebSocketFeatures []string
+
+	// WrapWidth soft-wraps the payloads printed by ExpandErrors at this
+	// column, keeping the indentation of wrapped lines. Zero wraps at the
+	// width of the terminal, if Out is one, and negative disables wrapping.
+	// JSON output is never wrapped.
+	WrapWidth int
 }
 
 // Tailer is the main interface for running the log tailing session
 type Tailer struct {
 	cfg *Config
 
+	// log is cfg.Log, or a logger derived from it for the current run
+	log *log.Logger
+
+	breaker          *malformedBreaker
+	bannerOnce       sync.Once
+	collapsed        []*collapsedGroup
+	eventSocket      *socketSink
+	events           chan EventPayload
+	eventsClosed     bool
+	eventsDone       <-chan struct{}
+	fileSink         *fileSink
+	grpcSink         *grpcSink
+	forwarder        *forwardSink
+	highlighted      *highlightedLine
+	hostname         string
+	location         *time.Location
+	locationErr      error
+	otlpSink         *otlpSink
+	outWidth         int32
+	outputs          []Output
+	patternRedactor  *patternRedactor
+	recent           *recentEvents
+	restoreInput     func()
+	redactorErr      error
+	spinner          *spinner.Spinner
+	spinnerActive    bool
+	spinnerMu        sync.Mutex
+	syslogSink       *syslogSink
 	stripeAuthClient *stripeauth.Client
-	webSocketClient  *websocket.Client
 
 	interruptCh chan os.Signal
+
+	// errorCommands limits the number of running OnErrorCommand processes
+	errorCommands   chan struct{}
+	errorCommandsWG sync.WaitGroup
+
+	// outQueue is the queue Out is wrapped in, if OutputQueueSize is set,
+	// and console the writer it wraps, which terminal checks look at
+	outQueue *outputQueue
+	console  io.Writer
+
+	// mu serializes the processing of events, which the websocket client
+	// delivers concurrently
+	mu            sync.Mutex
+	arrivals      []time.Time
+	arraysOpen    []bool
+	count         int
+	paused        bool
+	pauseBuffer   []pausedEvent
+	pauseDropped  int
+	lastActivity  time.Time
+	malformed     int
+	eventsDropped int
+	quieted       int
+	reconnects    int
+	sessionID     string
+	started       time.Time
+	statusClasses map[string]int
+	pathClasses   map[string]string
+	seenPaths     map[string]struct{}
+	lastDays      []string
+	lastDisplayed time.Time
+	latencies     *latencyReservoir
+
+	// sinkBytes are the bytes written to each sink when MaxBytes is set
+	sinkBytes       map[string]int64
+	sinkDropped     int64
+	sinkRetriers    []*sinkRetrier
+	totalBytes      int64
+	maxBytesReached bool
+	maxBytesSink    string
+	maxBytesHit     chan struct{}
+
+	// maxCreatedAt is the latest CreatedAt seen, and maxCreatedArrival when
+	// it arrived
+	maxCreatedAt      int
+	maxCreatedArrival time.Time
 }
 
 // EventPayload is the mapping for fields in event payloads from request log tailing
 type EventPayload struct {
+	Account   string        `json:"account,omitempty"`
 	CreatedAt int           `json:"created_at"`
 	Livemode  bool          `json:"livemode"`
 	Method    string        `json:"method"`
+	Protocol  string        `json:"protocol,omitempty"`
 	RequestID string        `json:"request_id"`
 	Status    int           `json:"status"`
 	URL       string        `json:"url"`
 	Error     RedactedError `json:"error"`
+
+	// DurationMs is the time Stripe took to handle the request, when it's
+	// included in the request log
+	DurationMs float64 `json:"duration_ms,omitempty"`
+
+	// APIVersion is the Stripe API version the request was made with, when
+	// it's included in the request log
+	APIVersion string `json:"api_version,omitempty"`
 }
 
 // RedactedError is the mapping for fields in error from an EventPayload
@@ -89,20 +674,166 @@ type RedactedError struct {
 	Param       string `json:"param"`
 }
 
+// newStripeAuthClient returns the client authorizing sessions with cfg,
+// logging to logger.
+func newStripeAuthClient(cfg *Config, logger *log.Logger) *stripeauth.Client {
+	return stripeauth.NewClient(cfg.Key, &stripeauth.Config{
+		Log:        logger,
+		APIBaseURL: cfg.APIBaseURL,
+	})
+}
+
 // New creates a new Tailer
 func New(cfg *Config) *Tailer {
 	if cfg.Log == nil {
 		cfg.Log = &log.Logger{Out: ioutil.Discard}
 	}
 
-	return &Tailer{
-		cfg: cfg,
-		stripeAuthClient: stripeauth.NewClient(cfg.Key, &stripeauth.Config{
-			Log:        cfg.Log,
-			APIBaseURL: cfg.APIBaseURL,
-		}),
-		interruptCh: make(chan os.Signal, 1),
+	if cfg.Out == nil {
+		cfg.Out = os.Stdout
+	}
+
+	console := cfg.Out
+
+	var outQueue *outputQueue
+	if cfg.OutputQueueSize > 0 {
+		outQueue = newOutputQueue(cfg.Out, cfg.OutputQueueSize)
+		cfg.Out = outQueue
+	}
+
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+
+	if cfg.EventChannelBuffer <= 0 {
+		cfg.EventChannelBuffer = defaultEventChannelBuffer
+	}
+
+	if cfg.EmptyURLText == "" {
+		cfg.EmptyURLText = DefaultEmptyURLText
+	}
+
+	if cfg.ExcludeExactPaths == nil {
+		cfg.ExcludeExactPaths = append([]string(nil), DefaultExcludeExactPaths...)
+	}
+
+	if cfg.PauseBufferSize <= 0 {
+		cfg.PauseBufferSize = defaultPauseBufferSize
+	}
+
+	if cfg.PauseInput == nil {
+		cfg.PauseInput = os.Stdin
+	}
+
+	if cfg.ReconnectJitter == 0 {
+		cfg.ReconnectJitter = defaultReconnectJitter
+	}
+
+	if cfg.SpinnerMessage == "" {
+		cfg.SpinnerMessage = defaultSpinnerMessage
+	}
+
+	t := &Tailer{
+		cfg:              cfg,
+		log:              cfg.Log,
+		console:          console,
+		outQueue:         outQueue,
+		stripeAuthClient: newStripeAuthClient(cfg, cfg.Log),
+		interruptCh:      make(chan os.Signal, 1),
+		statusClasses:    make(map[string]int),
+		pathClasses:      make(map[string]string),
+		seenPaths:        make(map[string]struct{}),
+		latencies:        newLatencyReservoir(),
+		sinkBytes:        make(map[string]int64),
+		maxBytesHit:      make(chan struct{}, 1),
+		errorCommands:    make(chan struct{}, maxErrorCommands),
+	}
+
+	if cfg.IncludeHostname {
+		name, err := hostname()
+		if err != nil {
+			cfg.Log.Debug("Unable to look up the host name: ", err)
+		}
+
+		t.hostname = name
+	}
+
+	if cfg.EventSocket != "" {
+		t.eventSocket = newSocketSink(cfg.EventSocket, cfg.Log)
+	}
+
+	if cfg.GRPCTarget != "" {
+		t.grpcSink = newGRPCSink(cfg.GRPCTarget, cfg.Log)
+	}
+
+	if cfg.OutFile != "" {
+		if cfg.FlushInterval <= 0 {
+			cfg.FlushInterval = defaultFlushInterval
+		}
+
+		t.fileSink = newFileSink(cfg.OutFile, cfg.PartitionBy, cfg.FlushPolicy, cfg.FlushSize, cfg.Log)
+	}
+
+	if cfg.MalformedThreshold > 0 {
+		t.breaker = newMalformedBreaker(cfg.MalformedThreshold, cfg.MalformedWindow)
+	}
+
+	if cfg.Syslog {
+		t.syslogSink = newSyslogSink(cfg.SyslogNetwork, cfg.SyslogAddr, cfg.SyslogTag, cfg.Log)
+	}
+
+	if cfg.RecentSize > 0 {
+		t.recent = newRecentEvents(cfg.RecentSize)
+	}
+
+	t.patternRedactor, t.redactorErr = newPatternRedactor(cfg.RedactPatterns)
+
+	if cfg.SinkRetries > 0 {
+		if cfg.SinkRetryBackoff <= 0 {
+			cfg.SinkRetryBackoff = defaultSinkRetryBackoff
+		}
+
+		for i, sink := range cfg.Sinks {
+			t.sinkRetriers = append(t.sinkRetriers, newSinkRetrier(t, i, sink))
+		}
+	}
+
+	t.location, t.locationErr = loadTimeZone(cfg.TimeZone)
+	if t.locationErr != nil {
+		t.location = time.Local
+	}
+
+	t.outputs = append([]Output{{Out: cfg.Out, Format: cfg.OutputFormat}}, cfg.Outputs...)
+
+	// Outputs sharing the console go through its queue too, so that their
+	// writes stay in order and they're recognized as the console
+	if outQueue != nil {
+		for i := range t.outputs {
+			if sameWriter(t.outputs[i].Out, console) {
+				t.outputs[i].Out = outQueue
+			}
+		}
+	}
+	t.lastDays = make([]string, len(t.outputs))
+	t.arraysOpen = make([]bool, len(t.outputs))
+
+	if cfg.Collapse {
+		if cfg.CollapseInterval <= 0 {
+			cfg.CollapseInterval = defaultCollapseInterval
+		}
+
+		t.collapsed = make([]*collapsedGroup, len(t.outputs))
+	}
+
+	if cfg.ForwardURL != "" {
+		t.forwarder = newForwardSink(cfg.ForwardURL, cfg.ForwardBatchSize, cfg.ForwardFlushInterval, cfg.Log)
 	}
+
+	if cfg.OTLPEndpoint != "" {
+		t.otlpSink = newOTLPSink(cfg.OTLPEndpoint, cfg.Log)
+	}
+
+	return t
 }
 
 func withSIGTERMCancel(ctx context.Context, onCancel func()) context.Context {
@@ -122,98 +853,371 @@ func withSIGTERMCancel(ctx context.Context, onCancel func()) context.Context {
 
 const maxConnectAttempts = 3
 
+const defaultReconnectJitter = 2 * time.Second
+
+// errAborted is returned by stream when ctx is canceled, e.g. on Ctrl+C.
+var errAborted = errors.New("aborted")
+
+// errKeyRejected is returned by Run when Stripe rejects the API key.
+var errKeyRejected = errors.New("API key rejected by Stripe, it may be expired or revoked. Run `stripe login` to authenticate again")
+
+// keyRejected reports whether err is Stripe rejecting the API key.
+func keyRejected(err error) bool {
+	var authErr *stripeauth.AuthorizationError
+	return errors.As(err, &authErr) && authErr.StatusCode == http.StatusUnauthorized
+}
+
 // Run sets the websocket connection
 func (t *Tailer) Run(ctx context.Context) error {
-	s := ansi.StartNewSpinner("Getting ready...", t.cfg.Log.Out)
+	if err := t.validateConfig(); err != nil {
+		return err
+	}
+
+	defer t.flushOutput()
+
+	t.useCorrelationID(ctx)
+	t.printFilterSummary()
 
 	ctx = withSIGTERMCancel(ctx, func() {
-		log.WithFields(log.Fields{
+		t.log.WithFields(log.Fields{
 			"prefix": "logtailing.Tailer.Run",
 		}).Debug("Ctrl+C received, cleaning up...")
 	})
 
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	t.mu.Lock()
+	t.started = t.cfg.Now()
+	t.eventsDone = ctx.Done()
+	t.mu.Unlock()
+
+	t.startSinks(ctx)
+
+	if t.replaying() {
+		err := t.replay(ctx)
+
+		cancel()
+		t.finish()
+
+		return err
+	}
+
+	t.startSpinner(t.cfg.SpinnerMessage)
+	defer t.stopSpinner("")
+
+	features := t.features()
+	errs := make(chan error, len(features))
+
+	for _, feature := range features {
+		go func(feature string) {
+			errs <- t.stream(ctx, feature)
+		}(feature)
+	}
+
+	// The first stream to stop stops the others
+	var err error
+
+	select {
+	case err = <-errs:
+	case err = <-t.breakerTripped():
+	case <-t.maxBytesHit:
+	}
+
+	// Sinks, reports and the terminal are cleaned up however the streams
+	// stopped
+	cancel()
+	t.stopSpinner("")
+	t.finish()
+
+	if err == errAborted {
+		t.log.Fatalf("Aborting")
+	}
+
+	t.log.WithFields(log.Fields{
+		"prefix": "logtailing.Tailer.Run",
+	}).Debug("Bye!")
+
+	return err
+}
+
+// SessionID returns the ID of the current websocket session, or of the
+// latest one when several websocket features are streamed. It's empty until
+// a session has been authorized.
+func (t *Tailer) SessionID() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.sessionID
+}
+
+// features returns the websocket features to stream: WebSocketFeatures if
+// set, or else WebSocketFeature alone.
+func (t *Tailer) features() []string {
+	if len(t.cfg.WebSocketFeatures) > 0 {
+		return t.cfg.WebSocketFeatures
+	}
+
+	return []string{t.cfg.WebSocketFeature}
+}
+
+// tagged reports whether request logs are tagged with the feature of the
+// stream they were received on, i.e. when several features are streamed.
+func (t *Tailer) tagged() bool {
+	return len(t.cfg.WebSocketFeatures) > 1
+}
+
+// stream authorizes a session for the websocket feature and processes the
+// messages received on it, reauthorizing when the session expires, until
+// ctx is canceled or the stream fails.
+func (t *Tailer) stream(ctx context.Context, feature string) error {
+	var webSocketClient *websocket.Client
 	var warned = false
-	var nAttempts int = 0
+	// nAttempts is reset from the goroutine waiting for each connection, so
+	// it's accessed atomically
+	var nAttempts int32 = 0
 
-	for nAttempts < maxConnectAttempts {
-		session, err := t.createSession(ctx)
+	for atomic.LoadInt32(&nAttempts) < maxConnectAttempts {
+		session, err := t.createSession(ctx, feature)
 
 		if err != nil {
-			ansi.StopSpinner(s, "", t.cfg.Log.Out)
-			t.cfg.Log.Fatalf("Error while authenticating with Stripe: %v", err)
+			if keyRejected(err) {
+				return errKeyRejected
+			}
+
+			return fmt.Errorf("Error while authenticating with Stripe: %v", err)
 		}
 
 		if session.DisplayConnectFilterWarning && !warned {
-			color := ansi.Color(os.Stdout)
-			fmt.Printf("%s you specified the 'account' filter for Connect accounts but are not a Connect user, so the filter will not be applied.\n", color.Yellow("Warning"))
+			t.warnConnectFilter()
 			// Only display this warning once
 			warned = true
 		}
 
-		t.webSocketClient = websocket.NewClient(
-			session.WebSocketURL,
-			session.WebSocketID,
-			session.WebSocketAuthorizedFeature,
-			&websocket.Config{
-				EventHandler:      websocket.EventHandlerFunc(t.processRequestLogEvent),
-				Log:               t.cfg.Log,
-				NoWSS:             t.cfg.NoWSS,
-				ReconnectInterval: time.Duration(session.ReconnectDelay) * time.Second,
-			},
-		)
-
-		go func() {
-			<-t.webSocketClient.Connected()
-			nAttempts = 0
-			ansi.StopSpinner(s, "Ready! You're now waiting to receive API request logs (^C to quit)", t.cfg.Log.Out)
-		}()
-
-		go t.webSocketClient.Run(ctx)
-		nAttempts++
+		t.mu.Lock()
+		t.sessionID = session.WebSocketID
+		t.mu.Unlock()
+
+		webSocketClient = t.newWebSocketClient(session, feature)
+		t.logWebSocketURL(webSocketClient.DialURL())
+
+		go func(client *websocket.Client) {
+			<-client.Connected()
+			atomic.StoreInt32(&nAttempts, 0)
+			t.connected()
+		}(webSocketClient)
+
+		go webSocketClient.Run(ctx)
+		atomic.AddInt32(&nAttempts, 1)
 
 		select {
 		case <-ctx.Done():
-			ansi.StopSpinner(s, "", t.cfg.Log.Out)
-			t.cfg.Log.Fatalf("Aborting")
-		case <-t.webSocketClient.NotifyExpired:
-			if nAttempts < maxConnectAttempts {
-				ansi.StartSpinner(s, "Session expired, reconnecting...", t.cfg.Log.Out)
+			return errAborted
+		case <-webSocketClient.NotifyExpired:
+			if attempts := atomic.LoadInt32(&nAttempts); attempts < maxConnectAttempts {
+				t.recordReconnect()
+				t.applyReconnectFilters()
+				t.startSpinner("Session expired, reconnecting...")
 			} else {
-				t.cfg.Log.Fatalf("Session expired. Terminating after %d failed attempts to reauthorize", nAttempts)
+				return fmt.Errorf("Session expired. Terminating after %d failed attempts to reauthorize", attempts)
 			}
 		}
 	}
 
-	if t.webSocketClient != nil {
-		t.webSocketClient.Stop()
+	if webSocketClient != nil {
+		webSocketClient.Stop()
 	}
 
-	log.WithFields(log.Fields{
-		"prefix": "logtailing.Tailer.Run",
-	}).Debug("Bye!")
-
 	return nil
 }
 
-func (t *Tailer) createSession(ctx context.Context) (*stripeauth.StripeCLISession, error) {
+// startSinks starts the background goroutines that deliver events to the
+// configured sinks. They stop when ctx is canceled.
+func (t *Tailer) startSinks(ctx context.Context) {
+	t.startSinkRetriers(ctx)
+
+	if t.eventSocket != nil {
+		go t.eventSocket.run(ctx)
+	}
+
+	if t.forwarder != nil {
+		go t.forwarder.run(ctx)
+	}
+
+	if t.grpcSink != nil {
+		go t.grpcSink.run(ctx)
+	}
+
+	if t.otlpSink != nil {
+		go t.otlpSink.run(ctx)
+	}
+
+	if t.syslogSink != nil {
+		go t.syslogSink.run(ctx)
+	}
+
+	if t.heartbeatEnabled() {
+		go t.runHeartbeat(ctx)
+	}
+
+	if t.cfg.Responsive {
+		go t.watchResize(ctx)
+	}
+
+	if t.collapseEnabled() {
+		go t.runCollapseFlush(ctx)
+	}
+
+	if t.fileSink != nil && t.fileSink.flushPolicy == flushPolicyInterval {
+		go t.runFileFlush(ctx)
+	}
+
+	if t.throughputEnabled() {
+		go t.runThroughput(ctx)
+	}
+
+	if t.pauseEnabled() {
+		t.startKeyboard()
+	}
+}
+
+// finish waits for the sinks to flush and prints the session summary. The
+// context passed to startSinks must be canceled first.
+func (t *Tailer) finish() {
+	if t.forwarder != nil {
+		t.forwarder.wait()
+	}
+
+	if t.eventSocket != nil {
+		t.eventSocket.wait()
+	}
+
+	if t.grpcSink != nil {
+		t.grpcSink.wait()
+	}
+
+	if t.otlpSink != nil {
+		t.otlpSink.wait()
+	}
+
+	if t.syslogSink != nil {
+		t.syslogSink.wait()
+	}
+
+	t.waitErrorCommands()
+
+	if t.collapseEnabled() {
+		t.mu.Lock()
+		t.flushCollapsed()
+		t.mu.Unlock()
+	}
+
+	t.mu.Lock()
+	t.clearHighlight()
+	t.mu.Unlock()
+
+	if t.fileSink != nil {
+		t.mu.Lock()
+		t.fileSink.close()
+		t.mu.Unlock()
+	}
+
+	t.closeSinkRetriers()
+
+	t.mu.Lock()
+	t.closeUserSinks()
+	t.mu.Unlock()
+
+	t.closeArrays()
+
+	t.stopKeyboard()
+	t.closeEvents()
+
+	if t.cfg.CountOnly || t.cfg.QuietUntilError {
+		t.printSummary()
+	}
+
+	t.printMaxBytesReached()
+
+	if t.cfg.ReportFile != "" {
+		if err := t.writeReport(); err != nil {
+			t.log.Error("Unable to write report: ", err)
+		}
+	}
+
+	t.closeOutput()
+}
+
+// validateConfig checks that the configuration is consistent before
+// connecting to Stripe.
+func (t *Tailer) validateConfig() error {
+	if err := t.cfg.Filters.validate(); err != nil {
+		return err
+	}
+
+	if t.redactorErr != nil {
+		return fmt.Errorf("invalid redact pattern: %v", t.redactorErr)
+	}
+
+	if t.locationErr != nil {
+		return fmt.Errorf("unknown time zone %q: %v", t.cfg.TimeZone, t.locationErr)
+	}
+
+	if err := validatePartitionBy(t.cfg.PartitionBy, t.cfg.OutFile); err != nil {
+		return err
+	}
+
+	if err := validateFlushPolicy(t.cfg.FlushPolicy); err != nil {
+		return err
+	}
+
+	if err := validateColorMode(t.cfg.ColorMode); err != nil {
+		return err
+	}
+
+	if err := websocket.ValidateHeaders(t.handshakeHeaders()); err != nil {
+		return err
+	}
+
+	if err := t.validateStrictJSON(); err != nil {
+		return err
+	}
+
+	if err := t.validateProtobuf(); err != nil {
+		return err
+	}
+
+	if err := t.validateJSONArray(); err != nil {
+		return err
+	}
+
+	if err := t.validateMaxBytes(); err != nil {
+		return err
+	}
+
+	return validateOutputs(t.outputs)
+}
+
+func (t *Tailer) createSession(ctx context.Context, feature string) (*stripeauth.StripeCLISession, error) {
 	var session *stripeauth.StripeCLISession
 
 	var err error
 
 	exitCh := make(chan struct{})
 
-	filters, err := jsonifyFilters(t.cfg.Filters)
+	filters, err := t.FiltersJSON()
 	if err != nil {
-		t.cfg.Log.Fatalf("Error while converting log filters to JSON encoding: %v", err)
+		t.log.Fatalf("Error while converting log filters to JSON encoding: %v", err)
 	}
 
 	go func() {
 		// Try to authorize at least 5 times before failing. Sometimes we have random
 		// transient errors that we just need to retry for.
 		for i := 0; i <= 5; i++ {
-			session, err = t.stripeAuthClient.Authorize(ctx, t.cfg.DeviceName, t.cfg.WebSocketFeature, &filters)
+			session, err = t.stripeAuthClient.Authorize(ctx, t.cfg.DeviceName, feature, &filters)
 
-			if err == nil {
+			// Retrying won't help if the key itself is rejected
+			if err == nil || keyRejected(err) {
 				exitCh <- struct{}{}
 				return
 			}
@@ -233,60 +1237,370 @@ func (t *Tailer) createSession(ctx context.Context) (*stripeauth.StripeCLISessio
 	return session, err
 }
 
+func (t *Tailer) newWebSocketClient(session *stripeauth.StripeCLISession, feature string) *websocket.Client {
+	handler := t.processRequestLogEvent
+	if t.tagged() {
+		handler = func(msg websocket.IncomingMessage) {
+			t.processMessage(msg, feature)
+		}
+	}
+
+	return websocket.NewClient(
+		session.WebSocketURL,
+		session.WebSocketID,
+		session.WebSocketAuthorizedFeature,
+		&websocket.Config{
+			EventHandler:       websocket.EventHandlerFunc(handler),
+			Headers:            t.handshakeHeaders(),
+			Log:                t.log,
+			NoWSS:              t.cfg.NoWSS,
+			OnMalformedMessage: t.processMalformedFrame,
+			ReconnectInterval:  time.Duration(session.ReconnectDelay) * time.Second,
+			ReconnectJitter:    t.cfg.ReconnectJitter,
+		},
+	)
+}
+
+// warnConnectFilter warns on Log.Out that the account filter is ignored
+// because the user isn't a Connect user.
+func (t *Tailer) warnConnectFilter() {
+	color := t.color(t.log.Out)
+	fmt.Fprintf(t.log.Out, "%s you specified the 'account' filter for Connect accounts but are not a Connect user, so the filter will not be applied.\n", color.Yellow("Warning"))
+}
+
+// handshakeHeaders returns the Headers to send with the websocket handshake.
+func (t *Tailer) handshakeHeaders() http.Header {
+	if len(t.cfg.Headers) == 0 {
+		return nil
+	}
+
+	header := make(http.Header, len(t.cfg.Headers))
+	for name, value := range t.cfg.Headers {
+		header.Set(name, value)
+	}
+
+	return header
+}
+
+// logWebSocketURL logs the URL the websocket client is about to dial. It is
+// only visible at the debug level unless ShowWebSocketURL is set.
+func (t *Tailer) logWebSocketURL(url string) {
+	if t.cfg.Key != "" {
+		url = strings.ReplaceAll(url, t.cfg.Key, "[REDACTED]")
+	}
+
+	entry := t.log.WithFields(log.Fields{
+		"prefix": "logtailing.Tailer.Run",
+		"url":    url,
+	})
+
+	if t.cfg.ShowWebSocketURL {
+		entry.Info("Connecting to websocket")
+	} else {
+		entry.Debug("Connecting to websocket")
+	}
+}
+
 func (t *Tailer) processRequestLogEvent(msg websocket.IncomingMessage) {
+	t.ProcessMessage(msg)
+}
+
+// ProcessMessage processes a websocket message as if it had been received
+// from Stripe, writing the request log it contains to Out and the sinks. It
+// lets tests and embedders feed messages to the Tailer without a live
+// connection. It is safe for concurrent use.
+func (t *Tailer) ProcessMessage(msg websocket.IncomingMessage) {
+	t.processMessage(msg, "")
+}
+
+// processMessage processes a websocket message received on the stream of the
+// given feature. feature is empty when request logs aren't tagged.
+func (t *Tailer) processMessage(msg websocket.IncomingMessage, feature string) {
+	if msg.ThrottleEvent != nil {
+		t.warnThrottle(msg.ThrottleEvent)
+
+		t.mu.Lock()
+		t.recordMessage(false)
+		t.mu.Unlock()
+
+		return
+	}
+
+	// Other events, e.g. from the other features streamed alongside request
+	// logs, aren't malformed and don't count towards the breaker
 	if msg.RequestLogEvent == nil {
-		t.cfg.Log.Debug("WebSocket specified for request logs received non-request-logs event")
+		t.log.Debug("WebSocket specified for request logs received non-request-logs event")
 		return
 	}
 
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
 	requestLogEvent := msg.RequestLogEvent
 
-	t.cfg.Log.WithFields(log.Fields{
+	t.log.WithFields(log.Fields{
 		"prefix":     "logtailing.Tailer.processRequestLogEvent",
 		"webhook_id": requestLogEvent.RequestLogID,
 	}).Debugf("Processing request log event")
 
 	var payload EventPayload
-	if err := json.Unmarshal([]byte(requestLogEvent.EventPayload), &payload); err != nil {
-		t.cfg.Log.Debug("Received malformed payload: ", err)
+	err := json.Unmarshal([]byte(requestLogEvent.EventPayload), &payload)
+	if err != nil {
+		t.onError(fmt.Errorf("received malformed payload: %v", err))
+	}
+
+	t.recordMessage(err != nil)
+
+	if err == nil {
+		t.checkGap(&payload)
 	}
 
-	// Don't show stripecli/sessions logs since they're generated by the CLI
-	if payload.URL == "/v1/stripecli/sessions" {
-		t.cfg.Log.Debug("Filtering out /v1/stripecli/sessions from logs")
+	if t.excluded(payload.URL) {
+		t.log.Debugf("Filtering out %s from logs", payload.URL)
 		return
 	}
 
-	if t.cfg.OutputFormat == outputFormatJSON {
-		fmt.Println(ansi.ColorizeJSON(requestLogEvent.EventPayload, false, os.Stdout))
+	if !t.cfg.Filters.matches(&payload) {
 		return
 	}
 
-	coloredStatus := ansi.ColorizeStatus(payload.Status)
+	if t.cfg.ErrorsOnly && !hasError(&payload) {
+		return
+	}
 
-	url := urlForRequestID(&payload)
-	requestLink := ansi.Linkify(payload.RequestID, url, os.Stdout)
+	if t.cfg.MutatingOnly && !mutating(payload.Method) {
+		return
+	}
 
-	if payload.URL == "" {
-		payload.URL = "[View path in dashboard]"
+	if t.stale(&payload) {
+		t.log.Debugf("Filtering out stale request log %s", payload.RequestID)
+		return
 	}
 
-	exampleLayout := "2006-01-02 15:04:05"
-	localTime := time.Unix(int64(payload.CreatedAt), 0).Format(exampleLayout)
+	raw := requestLogEvent.EventPayload
 
-	color := ansi.Color(os.Stdout)
-	outputStr := fmt.Sprintf("%s [%d] %s %s [%s]", color.Faint(localTime), coloredStatus, payload.Method, payload.URL, requestLink)
-	fmt.Println(outputStr)
+	if len(t.cfg.Middleware) > 0 {
+		var keep bool
+		if payload, keep = t.applyMiddleware(payload); !keep {
+			return
+		}
+	}
 
-	errorValues := reflect.ValueOf(&payload.Error).Elem()
-	errType := errorValues.Type()
+	if t.redactionEnabled() {
+		payload = t.redact(payload)
+	}
 
-	for i := 0; i < errorValues.NumField(); i++ {
-		fieldValue := errorValues.Field(i).Interface()
-		if fieldValue != "" {
-			fmt.Printf("%s: %s\n", errType.Field(i).Name, fieldValue)
+	if len(t.cfg.Middleware) > 0 || t.redactionEnabled() {
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			t.log.Debug("Unable to encode processed payload: ", err)
+			return
 		}
+
+		raw = string(encoded)
 	}
+
+	t.count++
+
+	// Quiet request logs don't count as activity, so that the heartbeat
+	// keeps reporting them
+	if !t.quiets(&payload) {
+		t.lastActivity = t.cfg.Now()
+	}
+
+	if payload.Status > 0 {
+		t.statusClasses[statusClass(payload.Status)]++
+	}
+
+	if payload.DurationMs > 0 {
+		t.latencies.add(payload.DurationMs)
+	}
+
+	evt := &event{
+		payload:      payload,
+		raw:          raw,
+		seq:          t.count,
+		requestLogID: requestLogEvent.RequestLogID,
+		msgType:      requestLogEvent.Type,
+		size:         len(requestLogEvent.EventPayload),
+		feature:      feature,
+	}
+
+	if t.cfg.ShowMatchedFilter {
+		evt.matchedFilters = t.cfg.Filters.matched(&payload)
+	}
+
+	jsonLine := t.encodeJSON(evt)
+
+	t.writeSinks(evt, jsonLine)
+
+	t.publishEvent(payload)
+	t.writeUserSinks(payload, jsonLine)
+	t.runErrorCommand(evt)
+
+	if t.recent != nil {
+		t.recent.add(payload)
+	}
+
+	if masked := t.maskAccount(evt); masked != evt {
+		evt = masked
+		jsonLine = t.encodeJSON(evt)
+	}
+
+	if t.cfg.CountOnly {
+		if !t.throughputEnabled() {
+			t.printCount()
+			return
+		}
+
+		t.recordArrival(t.cfg.Now())
+
+		if t.countInPlace() {
+			t.printThroughput()
+		}
+
+		return
+	}
+
+	if t.quiets(&payload) {
+		t.quieted++
+	} else {
+		t.quieted = 0
+	}
+
+	if t.paused {
+		t.bufferEvent(evt, jsonLine)
+		return
+	}
+
+	t.display(evt, jsonLine)
+}
+
+// display writes the event to every output that accepts it. The caller must
+// hold t.mu.
+func (t *Tailer) display(evt *event, jsonLine string) {
+	if t.cfg.Transitions {
+		t.displayTransition(evt)
+		return
+	}
+
+	if t.cfg.NewPathsOnly {
+		t.displayNewPath(evt)
+		return
+	}
+
+	burst := t.newBurst()
+
+	for i, output := range t.outputs {
+		if !output.accepts(&evt.payload) || t.quietsOutput(&output, &evt.payload) {
+			continue
+		}
+
+		if burst {
+			t.printBurstDivider(i)
+		}
+
+		t.printDayDivider(i, evt)
+
+		if t.collapses(output) {
+			if !t.watched(evt) {
+				t.collapseEvent(i, evt)
+				continue
+			}
+
+			t.flushGroup(i)
+		}
+
+		if t.cfg.JSONArray && strings.EqualFold(output.Format, outputFormatJSON) {
+			t.writeArrayElement(i, jsonLine)
+			continue
+		}
+
+		t.writeEvent(output, evt, jsonLine)
+	}
+}
+
+// excluded reports whether request logs for the URL are excluded by
+// ExcludeExactPaths or FilterNoise.
+func (t *Tailer) excluded(url string) bool {
+	return containsString(t.cfg.ExcludeExactPaths, stripQuery(url)) || t.noisy(url)
+}
+
+// writeSinks hands an event to the configured sinks, until MaxBytes is
+// reached. The caller must hold t.mu.
+func (t *Tailer) writeSinks(evt *event, jsonLine string) {
+	if t.maxBytesReached {
+		return
+	}
+
+	payload := &evt.payload
+
+	if t.eventSocket != nil {
+		t.eventSocket.write(jsonLine)
+		t.countBytes("event_socket", jsonLine)
+	}
+
+	if t.forwarder != nil {
+		t.forwarder.write(jsonLine)
+		t.countBytes("forward", jsonLine)
+	}
+
+	if t.fileSink != nil {
+		t.fileSink.write(payload.CreatedAt, jsonLine)
+		t.countBytes("out_file", jsonLine)
+	}
+
+	if t.grpcSink != nil {
+		t.grpcSink.write(payload)
+		t.countBytes("grpc", jsonLine)
+	}
+
+	if t.otlpSink != nil {
+		t.otlpSink.write(payload)
+		t.countBytes("otlp", jsonLine)
+	}
+
+	if t.syslogSink != nil {
+		// Formatted without hyperlinks or wrapping, and stripped of the
+		// colors ColorMode may force
+		line := ansi.Strip(t.formatLine(ioutil.Discard, evt, 1))
+
+		t.syslogSink.write(line, payload.Status >= 400)
+		t.countBytes("syslog", jsonLine)
+	}
+}
+
+// onError logs a non-fatal error and reports it to the OnError hook.
+func (t *Tailer) onError(err error) {
+	t.log.Debug(err)
+
+	if t.cfg.OnError != nil {
+		t.cfg.OnError(err)
+	}
+}
+
+// warn logs a non-fatal error as a warning, since it deserves the user's
+// attention, and reports it to the OnError hook.
+func (t *Tailer) warn(err error) {
+	t.log.Warn(err)
+
+	if t.cfg.OnError != nil {
+		t.cfg.OnError(err)
+	}
+}
+
+// FiltersJSON returns the filters sent to Stripe when authorizing the
+// session, encoded as JSON, e.g. to inspect them when debugging.
+func (t *Tailer) FiltersJSON() (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.filtersJSON()
+}
+
+// filtersJSON is FiltersJSON for callers already holding t.mu.
+func (t *Tailer) filtersJSON() (string, error) {
+	return jsonifyFilters(t.cfg.Filters)
 }
 
 func jsonifyFilters(logFilters *LogFilters) (string, error) {
@@ -1,9 +1,26 @@
 package logtailing
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	ws "github.com/gorilla/websocket"
+	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/stripeauth"
+	"github.com/stripe/stripe-cli/pkg/websocket"
 )
 
 func TestJsonifyFiltersAll(t *testing.T) {
@@ -50,6 +67,24 @@ func TestJsonifyFiltersEmpty(t *testing.T) {
 	require.Equal(t, "{}", filtersStr)
 }
 
+func TestFiltersJSON(t *testing.T) {
+	filters := &LogFilters{
+		FilterAccount:    []string{"connect_in"},
+		FilterHTTPMethod: []string{"POST", "DELETE"},
+		FilterSource:     []string{"api"},
+		FilterStatusCode: []string{"402"},
+		MinStatus:        400,
+	}
+
+	expected, err := jsonifyFilters(filters)
+	require.NoError(t, err)
+
+	filtersStr, err := New(&Config{Filters: filters}).FiltersJSON()
+	require.NoError(t, err)
+	require.Equal(t, expected, filtersStr)
+	require.Equal(t, `{"filter_account":["connect_in"],"filter_http_method":["POST","DELETE"],"filter_source":["api"],"filter_status_code":["402"]}`, filtersStr)
+}
+
 func TestURLForRequestID(t *testing.T) {
 	evt := &EventPayload{RequestID: "req_123", Livemode: false}
 	require.Equal(t, "https://dashboard.stripe.com/test/logs/req_123", urlForRequestID(evt))
@@ -57,3 +92,256 @@ func TestURLForRequestID(t *testing.T) {
 	evt = &EventPayload{RequestID: "req_123", Livemode: true}
 	require.Equal(t, "https://dashboard.stripe.com/logs/req_123", urlForRequestID(evt))
 }
+
+func TestLogWebSocketURL(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session := stripeauth.StripeCLISession{
+			WebSocketID:                "some-id",
+			WebSocketURL:               "wss://" + r.Host + "/subscribe/acct_123",
+			WebSocketAuthorizedFeature: "request_logs",
+		}
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(session)
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	logger := log.New()
+	logger.Out = &buf
+
+	tailer := New(&Config{
+		APIBaseURL:       ts.URL,
+		Key:              "sk_test_123",
+		Log:              logger,
+		NoWSS:            true,
+		ShowWebSocketURL: true,
+		WebSocketFeature: "request_logs",
+	})
+
+	session, err := tailer.createSession(context.Background(), "request_logs")
+	require.NoError(t, err)
+
+	tailer.logWebSocketURL(tailer.newWebSocketClient(session, "").DialURL())
+
+	host := strings.TrimPrefix(ts.URL, "http://")
+	require.Contains(t, buf.String(), "ws://"+host+"/subscribe/acct_123?websocket_feature=request_logs")
+	require.NotContains(t, buf.String(), "wss://")
+}
+
+func TestLogWebSocketURLRedactsKey(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.New()
+	logger.Out = &buf
+
+	tailer := New(&Config{
+		Key:              "sk_test_123",
+		Log:              logger,
+		ShowWebSocketURL: true,
+	})
+
+	tailer.logWebSocketURL("wss://example.com/subscribe?key=sk_test_123")
+
+	require.Contains(t, buf.String(), "key=[REDACTED]")
+	require.NotContains(t, buf.String(), "sk_test_123")
+}
+
+func requestLogMessage(payload string) websocket.IncomingMessage {
+	return websocket.IncomingMessage{
+		RequestLogEvent: &websocket.RequestLogEvent{
+			EventPayload: payload,
+			RequestLogID: "resp_123",
+			Type:         "request_log_event",
+		},
+	}
+}
+
+func TestExpandErrors(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{ExpandErrors: true, Out: &buf})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":402,"url":"/v1/charges","error":{"code":"card_declined"}}`))
+
+	require.Contains(t, buf.String(), "[402] POST /v1/charges")
+	require.Contains(t, buf.String(), "{\n  \"method\": \"POST\",\n  \"status\": 402,")
+	require.Contains(t, buf.String(), "\"error\": {\n    \"code\": \"card_declined\"\n  }")
+}
+
+func TestExpandErrorsSkipsSuccessfulRequests(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{ExpandErrors: true, Out: &buf})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers"}`))
+
+	require.Contains(t, buf.String(), "[200] GET /v1/customers")
+	require.NotContains(t, buf.String(), "{")
+	require.Equal(t, 1, strings.Count(buf.String(), "\n"))
+}
+
+func TestRunKeyRejected(t *testing.T) {
+	var requests int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":{"message":"Expired API Key provided: sk_test_***123","type":"invalid_request_error"}}`))
+	}))
+	defer ts.Close()
+
+	tailer := New(&Config{APIBaseURL: ts.URL, Key: "sk_test_123"})
+
+	err := tailer.Run(context.Background())
+	require.EqualError(t, err, "API key rejected by Stripe, it may be expired or revoked. Run `stripe login` to authenticate again")
+
+	// The rejected key isn't retried
+	require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestRunFinishesOnStreamError(t *testing.T) {
+	displayed := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+
+	upgrader := ws.Upgrader{}
+
+	// The webhooks session is rejected once the request log is displayed
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/stripecli/sessions" {
+			require.NoError(t, r.ParseForm())
+			feature := r.PostForm.Get("websocket_feature")
+
+			if feature == "webhooks" {
+				<-displayed
+				w.WriteHeader(http.StatusUnauthorized)
+
+				return
+			}
+
+			json.NewEncoder(w).Encode(stripeauth.StripeCLISession{
+				WebSocketID:                "websocket-" + feature,
+				WebSocketURL:               "wss://" + r.Host + "/subscribe/" + feature,
+				WebSocketAuthorizedFeature: feature,
+			})
+
+			return
+		}
+
+		c, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer c.Close()
+
+		require.NoError(t, c.WriteJSON(websocket.RequestLogEvent{Type: "request_log_event", EventPayload: `{"method":"POST","request_id":"req_1","status":200,"url":"/v1/charges"}`}))
+
+		<-done
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "finish")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	outFile := filepath.Join(dir, "out.ndjson")
+	reportFile := filepath.Join(dir, "report.json")
+
+	var out syncBuffer
+
+	sink := &fakeSink{}
+
+	tailer := New(&Config{
+		APIBaseURL:        ts.URL,
+		Key:               "sk_test_123",
+		NoColor:           true,
+		NoWSS:             true,
+		Out:               &out,
+		OutFile:           outFile,
+		ReportFile:        reportFile,
+		Sinks:             []Sink{sink},
+		WebSocketFeatures: []string{"request_logs", "webhooks"},
+	})
+
+	stopped := make(chan error)
+
+	go func() {
+		stopped <- tailer.Run(context.Background())
+	}()
+
+	require.Eventually(t, func() bool {
+		return strings.Contains(out.String(), "[req_1]")
+	}, 2*time.Second, 10*time.Millisecond)
+
+	close(displayed)
+
+	select {
+	case err := <-stopped:
+		require.Equal(t, errKeyRejected, err)
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "Timed out waiting for Run to return")
+	}
+
+	captured, err := ioutil.ReadFile(outFile)
+	require.NoError(t, err)
+	require.Contains(t, string(captured), `"request_id":"req_1"`)
+
+	report, err := ioutil.ReadFile(reportFile)
+	require.NoError(t, err)
+	require.Contains(t, string(report), `"total": 1`)
+
+	require.Equal(t, []string{"write", "flush", "close"}, sink.calls)
+}
+
+func TestKeyRejected(t *testing.T) {
+	require.True(t, keyRejected(&stripeauth.AuthorizationError{StatusCode: http.StatusUnauthorized}))
+	require.False(t, keyRejected(&stripeauth.AuthorizationError{StatusCode: http.StatusInternalServerError}))
+	require.False(t, keyRejected(errors.New("connection refused")))
+}
+
+func TestHandshakeHeaders(t *testing.T) {
+	tailer := New(&Config{Headers: map[string]string{"x-route": "us-east-1"}})
+	require.NoError(t, tailer.validateConfig())
+
+	client := tailer.newWebSocketClient(&stripeauth.StripeCLISession{WebSocketURL: "wss://example.com/subscribe"}, "")
+	require.NotNil(t, client)
+	require.Equal(t, http.Header{"X-Route": []string{"us-east-1"}}, tailer.handshakeHeaders())
+
+	require.Nil(t, New(&Config{}).handshakeHeaders())
+}
+
+func TestRunRejectsReservedHeaders(t *testing.T) {
+	tailer := New(&Config{Headers: map[string]string{"User-Agent": "my-agent"}})
+
+	err := tailer.Run(context.Background())
+	require.EqualError(t, err, "header User-Agent is reserved for the websocket handshake and can't be overridden")
+}
+
+func TestReconnectJitterDefault(t *testing.T) {
+	require.Equal(t, defaultReconnectJitter, New(&Config{}).cfg.ReconnectJitter)
+	require.Equal(t, 5*time.Second, New(&Config{ReconnectJitter: 5 * time.Second}).cfg.ReconnectJitter)
+	require.Equal(t, -time.Second, New(&Config{ReconnectJitter: -time.Second}).cfg.ReconnectJitter)
+}
+
+func TestProcessMessage(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{NoColor: true, Out: &buf})
+
+	tailer.ProcessMessage(requestLogMessage(`{"created_at":0,"method":"POST","request_id":"req_1","status":402,"url":"/v1/charges","error":{"decline_code":"insufficient_funds"}}`))
+	tailer.ProcessMessage(websocket.IncomingMessage{})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	require.True(t, strings.HasSuffix(lines[0], "[402] POST /v1/charges [req_1]"))
+	require.Equal(t, "DeclineCode: insufficient_funds", lines[1])
+
+	require.Equal(t, 1, tailer.report().Total)
+}
+
+func TestProcessMessageJSON(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{Out: &buf, OutputFormat: "JSON"})
+
+	tailer.ProcessMessage(requestLogMessage(`{"method":"GET","status":200}`))
+
+	require.Equal(t, "{\"method\":\"GET\",\"status\":200}\n", buf.String())
+}
@@ -0,0 +1,24 @@
+package logtailing
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/stripe/stripe-cli/pkg/websocket"
+)
+
+// warnThrottle logs a throttle notice sent by Stripe as a warning, and
+// reports it to the OnError hook.
+func (t *Tailer) warnThrottle(evt *websocket.ThrottleEvent) {
+	msg := evt.Message
+	if msg == "" {
+		msg = "too many request logs"
+	}
+
+	err := fmt.Errorf("Stripe is throttling this session: %s", msg)
+	if evt.RetryAfter > 0 {
+		err = fmt.Errorf("%v, retry after %s", err, time.Duration(evt.RetryAfter)*time.Second)
+	}
+
+	t.warn(err)
+}
@@ -0,0 +1,50 @@
+package logtailing
+
+import (
+	"bytes"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/websocket"
+)
+
+func TestThrottleNotice(t *testing.T) {
+	var logs, out bytes.Buffer
+
+	var errs []error
+
+	tailer := New(&Config{
+		Log:     &log.Logger{Out: &logs, Formatter: &log.TextFormatter{DisableTimestamp: true}, Level: log.InfoLevel},
+		OnError: func(err error) { errs = append(errs, err) },
+		Out:     &out,
+	})
+
+	tailer.ProcessMessage(websocket.IncomingMessage{ThrottleEvent: &websocket.ThrottleEvent{
+		Message:    "Too many request logs",
+		RetryAfter: 30,
+		Type:       "throttle_event",
+	}})
+
+	require.Len(t, errs, 1)
+	require.Equal(t, "Stripe is throttling this session: Too many request logs, retry after 30s", errs[0].Error())
+	require.Contains(t, logs.String(), "level=warning")
+	require.Contains(t, logs.String(), "retry after 30s")
+	require.Empty(t, out.String())
+	require.Zero(t, tailer.malformed)
+}
+
+func TestThrottleNoticeDefaults(t *testing.T) {
+	var errs []error
+
+	tailer := New(&Config{
+		OnError: func(err error) { errs = append(errs, err) },
+		Out:     &bytes.Buffer{},
+	})
+
+	tailer.ProcessMessage(websocket.IncomingMessage{ThrottleEvent: &websocket.ThrottleEvent{Type: "throttle_event"}})
+
+	require.Len(t, errs, 1)
+	require.Equal(t, "Stripe is throttling this session: too many request logs", errs[0].Error())
+}
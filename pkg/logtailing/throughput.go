@@ -0,0 +1,95 @@
+package logtailing
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// throughputWindow is the period over which the throughput gauge measures
+// the rate of received request logs.
+const throughputWindow = 5 * time.Second
+
+// throughputEnabled reports whether the throughput gauge is shown.
+func (t *Tailer) throughputEnabled() bool {
+	return t.cfg.CountOnly && t.cfg.ThroughputInterval > 0
+}
+
+// countInPlace reports whether the count-only output is updated in place.
+// The throughput gauge falls back to periodic lines when Out isn't a
+// terminal.
+func (t *Tailer) countInPlace() bool {
+	return !t.throughputEnabled() || isTerminal(t.console)
+}
+
+// recordArrival adds a request log received at now to the throughput
+// window. The caller must hold t.mu.
+func (t *Tailer) recordArrival(now time.Time) {
+	t.arrivals = append(t.arrivals, now)
+	t.pruneArrivals(now)
+}
+
+func (t *Tailer) pruneArrivals(now time.Time) {
+	cutoff := now.Add(-throughputWindow)
+
+	i := 0
+	for i < len(t.arrivals) && t.arrivals[i].Before(cutoff) {
+		i++
+	}
+
+	t.arrivals = t.arrivals[i:]
+}
+
+// throughput returns the rate of request logs per second over the window,
+// or since the session started if that is more recent. The caller must hold
+// t.mu.
+func (t *Tailer) throughput(now time.Time) float64 {
+	t.pruneArrivals(now)
+
+	window := throughputWindow
+	if elapsed := now.Sub(t.started); !t.started.IsZero() && elapsed < window {
+		window = elapsed
+	}
+
+	if window <= 0 {
+		return 0
+	}
+
+	return float64(len(t.arrivals)) / window.Seconds()
+}
+
+// runThroughput updates the throughput gauge every ThroughputInterval until
+// ctx is canceled.
+func (t *Tailer) runThroughput(ctx context.Context) {
+	ticker := time.NewTicker(t.cfg.ThroughputInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.mu.Lock()
+			t.printThroughput()
+			t.mu.Unlock()
+		}
+	}
+}
+
+// printThroughput prints the count of received request logs along with the
+// current throughput. The caller must hold t.mu.
+func (t *Tailer) printThroughput() {
+	color := t.color(t.cfg.Out)
+	line := fmt.Sprintf("%d request logs received (%.1f/s)", color.Bold(t.count), t.throughput(t.cfg.Now()))
+	if latency := t.latencies.quantiles(); latency != nil {
+		line = fmt.Sprintf("%d request logs received (%.1f/s, %s)", color.Bold(t.count), t.throughput(t.cfg.Now()), latency)
+	}
+
+	if t.countInPlace() {
+		// Clear the rest of the line in case the previous one was longer
+		fmt.Fprintf(t.cfg.Out, "\r%s\033[K", line)
+		return
+	}
+
+	fmt.Fprintln(t.cfg.Out, line)
+}
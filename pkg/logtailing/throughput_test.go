@@ -0,0 +1,80 @@
+package logtailing
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/ansi"
+)
+
+func TestThroughputLinesWhenNotTerminal(t *testing.T) {
+	var buf syncBuffer
+
+	tailer := New(&Config{CountOnly: true, NoColor: true, Out: &buf, ThroughputInterval: 20 * time.Millisecond})
+	require.True(t, tailer.throughputEnabled())
+	require.False(t, tailer.countInPlace())
+
+	tailer.started = time.Now()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go tailer.runThroughput(ctx)
+
+	for i := 0; i < 3; i++ {
+		tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":200,"url":"/v1/charges"}`))
+	}
+
+	require.Eventually(t, func() bool {
+		return strings.Count(buf.String(), "3 request logs received (") >= 2
+	}, time.Second, 5*time.Millisecond)
+
+	cancel()
+
+	output := buf.String()
+	require.NotContains(t, output, "\r")
+	require.Regexp(t, regexp.MustCompile(`^(\d+ request logs received \(\d+\.\d/s\)\n)+$`), output)
+}
+
+func TestThroughputInPlaceOnTerminal(t *testing.T) {
+	defer func() { isTerminal = ansi.IsTerminal }()
+	isTerminal = func(io.Writer) bool { return true }
+
+	var buf bytes.Buffer
+
+	tailer := New(&Config{CountOnly: true, NoColor: true, Out: &buf, ThroughputInterval: time.Second})
+	require.True(t, tailer.countInPlace())
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"POST","status":200,"url":"/v1/charges"}`))
+
+	require.True(t, strings.HasPrefix(buf.String(), "\r1 request logs received ("))
+	require.True(t, strings.HasSuffix(buf.String(), "/s)\033[K"))
+}
+
+func TestThroughputRate(t *testing.T) {
+	tailer := New(&Config{CountOnly: true, ThroughputInterval: time.Second})
+
+	now := time.Now()
+	tailer.started = now.Add(-time.Minute)
+
+	// Arrivals older than the window don't count towards the rate
+	tailer.recordArrival(now.Add(-10 * time.Second))
+
+	for i := 0; i < 10; i++ {
+		tailer.recordArrival(now.Add(-time.Second))
+	}
+
+	require.Equal(t, 2.0, tailer.throughput(now))
+
+	// The rate is measured since the start when the session is younger than
+	// the window
+	tailer.started = now.Add(-2 * time.Second)
+	require.Equal(t, 5.0, tailer.throughput(now))
+}
@@ -0,0 +1,18 @@
+package logtailing
+
+import "time"
+
+// loadTimeZone returns the location request log times are rendered in: the
+// named IANA time zone, or the local time zone when name is empty.
+func loadTimeZone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+
+	return time.LoadLocation(name)
+}
+
+// createdAt returns the creation time of a request log in TimeZone.
+func (t *Tailer) createdAt(createdAt int) time.Time {
+	return time.Unix(int64(createdAt), 0).In(t.location)
+}
@@ -0,0 +1,35 @@
+package logtailing
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeZone(t *testing.T) {
+	var buf bytes.Buffer
+
+	// 2024-01-02 15:04:05 UTC
+	payload := `{"created_at":1704207845,"method":"GET","status":200,"url":"/v1/customers","request_id":"req_1"}`
+
+	tailer := New(&Config{NoColor: true, Out: &buf, TimeZone: "America/New_York"})
+	require.NoError(t, tailer.validateConfig())
+
+	tailer.processRequestLogEvent(requestLogMessage(payload))
+	require.Contains(t, buf.String(), "2024-01-02 10:04:05 ")
+
+	buf.Reset()
+
+	tailer = New(&Config{NoColor: true, Out: &buf, TimeZone: "Asia/Tokyo"})
+	tailer.processRequestLogEvent(requestLogMessage(payload))
+	require.Contains(t, buf.String(), "2024-01-03 00:04:05 ")
+}
+
+func TestTimeZoneInvalid(t *testing.T) {
+	tailer := New(&Config{Out: &bytes.Buffer{}, TimeZone: "Mars/Olympus_Mons"})
+
+	err := tailer.validateConfig()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `unknown time zone "Mars/Olympus_Mons"`)
+}
@@ -0,0 +1,132 @@
+package logtailing
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/logrusorgru/aurora"
+)
+
+// idSegment matches path segments that identify a specific object, such as
+// cus_123 or 42, so that requests for different objects share one path. IDs
+// always contain a digit or an uppercase letter, unlike resource names such
+// as payment_intents.
+var idSegment = regexp.MustCompile(`^([a-z]+_[A-Za-z0-9_]*[A-Z0-9][A-Za-z0-9_]*|[0-9]+)$`)
+
+// transition is a change of status class for a normalized path.
+type transition struct {
+	CreatedAt int    `json:"created_at"`
+	Method    string `json:"method"`
+	Path      string `json:"path"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Status    int    `json:"status"`
+	RequestID string `json:"request_id"`
+}
+
+// normalizePath strips the query string from path and replaces object IDs
+// with ":id".
+func normalizePath(path string) string {
+	segments := strings.Split(stripQuery(path), "/")
+	for i, segment := range segments {
+		if idSegment.MatchString(segment) {
+			segments[i] = ":id"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// recordTransition records the status class of the event for its normalized
+// path and returns the transition if the class changed. The first request
+// for a path isn't a transition. The caller must hold t.mu.
+func (t *Tailer) recordTransition(payload *EventPayload) (transition, bool) {
+	if payload.Status <= 0 {
+		return transition{}, false
+	}
+
+	path := normalizePath(payload.URL)
+	key := payload.Method + " " + path
+	class := statusClass(payload.Status)
+
+	from, seen := t.pathClasses[key]
+	t.pathClasses[key] = class
+
+	if !seen || from == class {
+		return transition{}, false
+	}
+
+	return transition{
+		CreatedAt: payload.CreatedAt,
+		Method:    payload.Method,
+		Path:      path,
+		From:      from,
+		To:        class,
+		Status:    payload.Status,
+		RequestID: payload.RequestID,
+	}, true
+}
+
+// displayTransition writes the event to the outputs that accept it if it
+// changes the status class of its path. The caller must hold t.mu.
+func (t *Tailer) displayTransition(evt *event) {
+	tr, ok := t.recordTransition(&evt.payload)
+	if !ok {
+		return
+	}
+
+	for _, output := range t.outputs {
+		if !output.accepts(&evt.payload) || t.quietsOutput(&output, &evt.payload) {
+			continue
+		}
+
+		switch {
+		case strings.EqualFold(output.Format, outputFormatProtobuf):
+			// There's no message for transitions, see validateProtobuf
+			continue
+		case strings.EqualFold(output.Format, outputFormatLogfmt):
+			fmt.Fprintln(output.Out, logfmtLine(
+				"ts", logfmtTime(tr.CreatedAt),
+				"method", tr.Method,
+				"path", tr.Path,
+				"from", tr.From,
+				"to", tr.To,
+				"status", strconv.Itoa(tr.Status),
+				"request_id", tr.RequestID,
+			))
+
+			continue
+		case machineReadable(output.Format):
+			line, err := json.Marshal(tr)
+			if err != nil {
+				t.onError(err)
+				continue
+			}
+
+			fmt.Fprintln(output.Out, string(line))
+
+			continue
+		}
+
+		color := t.color(output.Out)
+		localTime := t.createdAt(tr.CreatedAt).Format(dateTimeLayout)
+
+		fmt.Fprintf(output.Out, "%s %s %s %s → %s [%s]\n",
+			color.Faint(localTime), tr.Method, tr.Path, tr.From, colorizeClass(color, tr.Status, tr.To), tr.RequestID)
+	}
+}
+
+// colorizeClass colors a status class the way the status itself would be.
+func colorizeClass(color aurora.Aurora, status int, class string) aurora.Value {
+	switch {
+	case status >= 500:
+		return color.Red(class).Bold()
+	case status >= 300:
+		return color.Yellow(class).Bold()
+	default:
+		return color.Green(class).Bold()
+	}
+}
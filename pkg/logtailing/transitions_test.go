@@ -0,0 +1,72 @@
+package logtailing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizePath(t *testing.T) {
+	require.Equal(t, "/v1/customers/:id", normalizePath("/v1/customers/cus_123?expand[]=sources"))
+	require.Equal(t, "/v1/customers/:id/sources/:id", normalizePath("/v1/customers/cus_123/sources/card_1Abc"))
+	require.Equal(t, "/v1/payment_intents", normalizePath("/v1/payment_intents"))
+	require.Equal(t, "/v1/events/:id", normalizePath("/v1/events/42"))
+}
+
+func TestTransitions(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{NoColor: true, Out: &buf, Transitions: true})
+
+	sequence := []struct {
+		url    string
+		status int
+	}{
+		{"/v1/charges", 200},
+		{"/v1/customers/cus_1", 200},
+		{"/v1/charges", 201},
+		{"/v1/charges", 500},
+		{"/v1/customers/cus_2", 404},
+		{"/v1/charges", 502},
+		{"/v1/charges", 200},
+		{"/v1/customers/cus_3", 404},
+	}
+
+	for i, req := range sequence {
+		tailer.processRequestLogEvent(requestLogMessage(fmt.Sprintf(`{"method":"POST","request_id":"req_%d","status":%d,"url":"%s"}`, i, req.status, req.url)))
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	require.True(t, strings.HasSuffix(lines[0], "POST /v1/charges 2xx → 5xx [req_3]"))
+	require.True(t, strings.HasSuffix(lines[1], "POST /v1/customers/:id 2xx → 4xx [req_4]"))
+	require.True(t, strings.HasSuffix(lines[2], "POST /v1/charges 5xx → 2xx [req_6]"))
+}
+
+func TestTransitionsJSON(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{Out: &buf, OutputFormat: outputFormatJSON, Transitions: true})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","request_id":"req_1","status":200,"url":"/v1/balance"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","request_id":"req_2","status":429,"url":"/v1/balance"}`))
+
+	var tr transition
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &tr))
+	require.Equal(t, transition{Method: "GET", Path: "/v1/balance", From: "2xx", To: "4xx", Status: 429, RequestID: "req_2"}, tr)
+}
+
+func TestTransitionsLogfmt(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{Out: &buf, OutputFormat: outputFormatLogfmt, Transitions: true})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"created_at":1704207845,"method":"GET","request_id":"req_1","status":200,"url":"/v1/balance"}`))
+	tailer.processRequestLogEvent(requestLogMessage(`{"created_at":1704207846,"method":"GET","request_id":"req_2","status":429,"url":"/v1/balance"}`))
+
+	require.Equal(t, "ts=2024-01-02T15:04:06Z method=GET path=/v1/balance from=2xx to=4xx status=429 request_id=req_2\n", buf.String())
+}
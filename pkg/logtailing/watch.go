@@ -0,0 +1,41 @@
+package logtailing
+
+import (
+	"fmt"
+
+	"github.com/stripe/stripe-cli/pkg/ansi"
+)
+
+// watched reports whether evt is the request log of WatchRequestID.
+func (t *Tailer) watched(evt *event) bool {
+	return t.cfg.WatchRequestID != "" && evt.payload.RequestID == t.cfg.WatchRequestID
+}
+
+// writeWatched writes the request log of WatchRequestID in the default
+// format with its line in reverse video, ringing the terminal bell first if
+// WatchBell is set. The caller must hold t.mu.
+func (t *Tailer) writeWatched(output Output, evt *event) {
+	w := output.Out
+
+	if t.highlightEnabled(output) {
+		t.clearHighlight()
+	}
+
+	if t.cfg.WatchBell && output.Out == t.cfg.Out && isTerminal(t.console) {
+		fmt.Fprint(w, "\a")
+	}
+
+	line := t.formatLine(w, evt, 1)
+	details := t.formatDetails(w, evt)
+
+	if output.Plain {
+		fmt.Fprint(w, ansi.Strip(line+"\n"+details))
+		return
+	}
+
+	if t.useColors(w) {
+		line = reverse(line)
+	}
+
+	fmt.Fprint(w, line+"\n"+details)
+}
@@ -0,0 +1,66 @@
+package logtailing
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/ansi"
+)
+
+func TestWatchRequestID(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{ColorMode: colorModeAlways, WatchRequestID: "req_2", Out: &buf})
+
+	for _, id := range []string{"req_1", "req_2", "req_3"} {
+		tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"` + id + `"}`))
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 3)
+	require.NotContains(t, lines[0], reverseVideo)
+	require.True(t, strings.HasPrefix(lines[1], reverseVideo), lines[1])
+	require.Contains(t, lines[1], "req_2")
+	require.NotContains(t, lines[2], reverseVideo)
+	require.NotContains(t, buf.String(), "\a")
+}
+
+func TestWatchBell(t *testing.T) {
+	defer func() { isTerminal = ansi.IsTerminal }()
+	isTerminal = func(io.Writer) bool { return true }
+
+	var buf bytes.Buffer
+
+	tailer := New(&Config{NoColor: true, WatchBell: true, WatchRequestID: "req_2", Out: &buf})
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_1"}`))
+	require.NotContains(t, buf.String(), "\a")
+
+	tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"req_2"}`))
+	require.Equal(t, 1, strings.Count(buf.String(), "\a"))
+
+	// Colors are off, so the line isn't highlighted
+	require.NotContains(t, buf.String(), reverseVideo)
+}
+
+func TestWatchRequestIDNotCollapsed(t *testing.T) {
+	var buf bytes.Buffer
+
+	tailer := New(&Config{Collapse: true, NoColor: true, WatchRequestID: "req_2", Out: &buf})
+
+	for _, id := range []string{"req_1", "req_2", "req_3"} {
+		tailer.processRequestLogEvent(requestLogMessage(`{"method":"GET","status":200,"url":"/v1/customers","request_id":"` + id + `"}`))
+	}
+
+	tailer.flushCollapsed()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 3)
+	require.Contains(t, lines[0], "[req_1]")
+	require.Contains(t, lines[1], "[req_2]")
+	require.Contains(t, lines[2], "[req_3]")
+}
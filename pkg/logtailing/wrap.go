@@ -0,0 +1,129 @@
+package logtailing
+
+import (
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// minWrapContent is the minimum number of columns left after the indentation
+// of a line for it to be wrapped.
+const minWrapContent = 10
+
+// terminalWidth is overridden in tests to wrap without a TTY.
+var terminalWidth = defaultTerminalWidth
+
+// defaultTerminalWidth returns the width of w if it's a terminal, or zero.
+func defaultTerminalWidth(w io.Writer) int {
+	f, ok := w.(interface{ Fd() uintptr })
+	if !ok || !isTerminal(w) {
+		return 0
+	}
+
+	width, _, err := terminal.GetSize(int(f.Fd()))
+	if err != nil {
+		return 0
+	}
+
+	return width
+}
+
+// wrapWidth returns the column expanded errors written to w are wrapped at,
+// or zero for no wrapping.
+func (t *Tailer) wrapWidth(w io.Writer) int {
+	switch {
+	case t.cfg.WrapWidth < 0:
+		return 0
+	case t.cfg.WrapWidth > 0:
+		return t.cfg.WrapWidth
+	default:
+		return terminalWidth(t.probed(w))
+	}
+}
+
+// wrapText soft-wraps every line of text at width columns. Continuation
+// lines keep the indentation of the line they belong to. ANSI escape
+// sequences don't count towards the width.
+func wrapText(text string, width int) string {
+	if width <= 0 {
+		return text
+	}
+
+	var wrapped []string
+	for _, line := range strings.Split(text, "\n") {
+		wrapped = append(wrapped, wrapLine(line, width)...)
+	}
+
+	return strings.Join(wrapped, "\n")
+}
+
+func wrapLine(line string, width int) []string {
+	indent := line[:len(line)-len(strings.TrimLeft(line, " "))]
+	if width < len(indent)+minWrapContent {
+		return []string{line}
+	}
+
+	var lines []string
+
+	for {
+		i := breakIndex(line, width, len(indent))
+		if i < 0 {
+			return append(lines, line)
+		}
+
+		lines = append(lines, strings.TrimRight(line[:i], " "))
+
+		rest := strings.TrimLeft(line[i:], " ")
+		if rest == "" {
+			return lines
+		}
+
+		line = indent + rest
+	}
+}
+
+// breakIndex returns the byte index at which line should be broken to fit in
+// width columns, preferring the last space after the indentation, or -1 if
+// the line fits.
+func breakIndex(line string, width, indent int) int {
+	col := 0
+	lastSpace := -1
+
+	for i := 0; i < len(line); {
+		// Skip ANSI escape sequences such as colors
+		if line[i] == '\x1b' && i+1 < len(line) && line[i+1] == '[' {
+			j := i + 2
+			for j < len(line) && (line[j] < 0x40 || line[j] > 0x7e) {
+				j++
+			}
+
+			i = j + 1
+
+			continue
+		}
+
+		if col == width {
+			if line[i] == ' ' {
+				return i
+			}
+
+			if lastSpace > 0 {
+				return lastSpace
+			}
+
+			return i
+		}
+
+		if line[i] == ' ' && col >= indent {
+			lastSpace = i
+		}
+
+		_, size := utf8.DecodeRuneInString(line[i:])
+		i += size
+		col++
+	}
+
+	return -1
+}
@@ -0,0 +1,71 @@
+package logtailing
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrapText(t *testing.T) {
+	text := "{\n  \"message\": \"No such customer: 'cus_123'; a similar object exists in live mode\"\n}"
+
+	require.Equal(t, strings.Join([]string{
+		"{",
+		"  \"message\": \"No such",
+		"  customer: 'cus_123'; a",
+		"  similar object exists",
+		"  in live mode\"",
+		"}",
+	}, "\n"), wrapText(text, 24))
+
+	require.Equal(t, text, wrapText(text, 0))
+}
+
+func TestWrapTextWithoutSpaces(t *testing.T) {
+	require.Equal(t, "  \"abcdefghi\n  jklmnopqrs\n  tuvwxyz\"", wrapText("  \"abcdefghijklmnopqrstuvwxyz\"", 12))
+}
+
+func TestWrapTextIgnoresEscapeSequences(t *testing.T) {
+	colored := "\x1b[34m\"code\"\x1b[0m: \x1b[32m\"resource_missing\"\x1b[0m"
+
+	// The visible text is 26 columns wide
+	require.Equal(t, colored, wrapText(colored, 26))
+	require.Equal(t, "\x1b[34m\"code\"\x1b[0m:\n\x1b[32m\"resource_missing\"\x1b[0m", wrapText(colored, 20))
+}
+
+func TestWrapExpandedErrors(t *testing.T) {
+	var text, jsonBuf bytes.Buffer
+
+	payload := `{"error":{"message":"No such customer: 'cus_123'; a similar object exists in live mode"},"method":"GET","status":404,"url":"/v1/customers/cus_123"}`
+
+	tailer := New(&Config{
+		ExpandErrors: true,
+		NoColor:      true,
+		Out:          &text,
+		Outputs:      []Output{{Out: &jsonBuf, Format: "JSON"}},
+		WrapWidth:    40,
+	})
+
+	tailer.processRequestLogEvent(requestLogMessage(payload))
+
+	expanded := text.String()[strings.Index(text.String(), "{"):]
+	for _, line := range strings.Split(strings.TrimSpace(expanded), "\n") {
+		require.LessOrEqual(t, len(line), 40)
+	}
+	require.Contains(t, expanded, "    \"message\": \"No such customer:\n    'cus_123'; a similar object exists\n    in live mode\"")
+
+	// The NDJSON stream is left untouched
+	require.Equal(t, payload+"\n", jsonBuf.String())
+}
+
+func TestWrapWidth(t *testing.T) {
+	terminalWidth = func(io.Writer) int { return 120 }
+	defer func() { terminalWidth = defaultTerminalWidth }()
+
+	require.Equal(t, 120, New(&Config{}).wrapWidth(nil))
+	require.Equal(t, 80, New(&Config{WrapWidth: 80}).wrapWidth(nil))
+	require.Equal(t, 0, New(&Config{WrapWidth: -1}).wrapWidth(nil))
+}
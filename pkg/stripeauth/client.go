@@ -28,6 +28,17 @@ type Config struct {
 	APIBaseURL string
 }
 
+// AuthorizationError is returned by Authorize when Stripe rejects the
+// request to initiate a new CLI session.
+type AuthorizationError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *AuthorizationError) Error() string {
+	return fmt.Sprintf("Authorization failed, status=%d, body=%s", e.StatusCode, e.Body)
+}
+
 // Client is the client used to initiate new CLI sessions with Stripe.
 type Client struct {
 	apiKey string
@@ -73,8 +84,7 @@ func (c *Client) Authorize(ctx context.Context, deviceName string, websocketFeat
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		err := fmt.Errorf("Authorization failed, status=%d, body=%s", resp.StatusCode, body)
-		return nil, err
+		return nil, &AuthorizationError{StatusCode: resp.StatusCode, Body: body}
 	}
 
 	var session *StripeCLISession
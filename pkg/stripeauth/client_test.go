@@ -80,3 +80,22 @@ func TestStripeClientUserAgent(t *testing.T) {
 	})
 	client.Authorize(context.TODO(), "my-device", "webhooks", nil)
 }
+
+func TestAuthorizeRejected(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":{"type":"invalid_request_error"}}`))
+	}))
+	defer ts.Close()
+
+	client := NewClient("sk_test_123", &Config{
+		APIBaseURL: ts.URL,
+	})
+	session, err := client.Authorize(context.TODO(), "my-device", "webhooks", nil)
+	require.Nil(t, session)
+
+	authErr, ok := err.(*AuthorizationError)
+	require.True(t, ok)
+	require.Equal(t, http.StatusUnauthorized, authErr.StatusCode)
+	require.Equal(t, `Authorization failed, status=401, body={"error":{"type":"invalid_request_error"}}`, err.Error())
+}
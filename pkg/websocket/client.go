@@ -4,12 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
+	"math/rand"
 	"net"
 	"net/http"
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	ws "github.com/gorilla/websocket"
@@ -28,11 +31,22 @@ type Config struct {
 
 	Dialer *ws.Dialer
 
+	// Headers are additional headers sent with the handshake request, e.g.
+	// for proxies in front of Stripe. They can't override the headers set by
+	// the client, see ValidateHeaders.
+	Headers http.Header
+
 	Log *log.Logger
 
 	// Force use of unencrypted ws:// protocol instead of wss://
 	NoWSS bool
 
+	// OnMalformedMessage is called with the error of each frame that
+	// couldn't be decoded, e.g. invalid or truncated JSON. Frames containing
+	// messages of an unknown type aren't malformed. Like EventHandler, it's
+	// called in its own goroutine.
+	OnMalformedMessage func(error)
+
 	PingPeriod time.Duration
 
 	PongWait time.Duration
@@ -40,11 +54,45 @@ type Config struct {
 	// Interval at which the websocket client should reset the connection
 	ReconnectInterval time.Duration
 
+	// ReconnectJitter randomly shortens or lengthens each wait between
+	// connection attempts by up to this duration, so that many clients
+	// disconnected at the same time don't all reconnect at once. Zero
+	// disables jitter.
+	ReconnectJitter time.Duration
+
 	WriteWait time.Duration
 
 	EventHandler EventHandler
 }
 
+// reservedHeaders are the handshake headers that are set by the client or
+// the websocket dialer and can't be overridden.
+var reservedHeaders = []string{
+	"Accept-Encoding",
+	"Connection",
+	"Sec-Websocket-Extensions",
+	"Sec-Websocket-Key",
+	"Sec-Websocket-Version",
+	"Upgrade",
+	"User-Agent",
+	"Websocket-Id",
+	"X-Stripe-Client-User-Agent",
+}
+
+// ValidateHeaders returns an error if any of the headers is reserved for the
+// websocket handshake.
+func ValidateHeaders(headers http.Header) error {
+	for name := range headers {
+		for _, reserved := range reservedHeaders {
+			if http.CanonicalHeaderKey(name) == reserved {
+				return fmt.Errorf("header %s is reserved for the websocket handshake and can't be overridden", reserved)
+			}
+		}
+	}
+
+	return nil
+}
+
 // EventHandler handles an event.
 type EventHandler interface {
 	ProcessEvent(IncomingMessage)
@@ -76,9 +124,10 @@ type Client struct {
 	// Optional configuration parameters
 	cfg *Config
 
-	conn        *ws.Conn
-	done        chan struct{}
-	isConnected bool
+	conn *ws.Conn
+	done chan struct{}
+	// isConnected is read and written atomically, as 1 once connected
+	isConnected int32
 
 	NotifyExpired chan struct{}
 	notifyClose   chan error
@@ -94,7 +143,7 @@ func (c *Client) Connected() <-chan struct{} {
 	d := make(chan struct{})
 
 	go func() {
-		for !c.isConnected {
+		for atomic.LoadInt32(&c.isConnected) == 0 {
 			time.Sleep(100 * time.Millisecond)
 		}
 		close(d)
@@ -106,7 +155,7 @@ func (c *Client) Connected() <-chan struct{} {
 // Run starts listening for incoming webhook requests from Stripe.
 func (c *Client) Run(ctx context.Context) {
 	for {
-		c.isConnected = false
+		atomic.StoreInt32(&c.isConnected, 0)
 		c.cfg.Log.WithFields(log.Fields{
 			"prefix": "websocket.client.Run",
 		}).Debug("Attempting to connect to Stripe")
@@ -126,7 +175,7 @@ func (c *Client) Run(ctx context.Context) {
 				case <-ctx.Done():
 					c.Stop()
 					return
-				case <-time.After(c.cfg.ConnectAttemptWait):
+				case <-time.After(c.connectAttemptWait()):
 					c.NotifyExpired <- struct{}{}
 					return
 				}
@@ -134,7 +183,7 @@ func (c *Client) Run(ctx context.Context) {
 			select {
 			case <-ctx.Done():
 				c.Stop()
-			case <-time.After(c.cfg.ConnectAttemptWait):
+			case <-time.After(c.connectAttemptWait()):
 			}
 			err = c.connect(ctx)
 		}
@@ -176,6 +225,31 @@ func (c *Client) Run(ctx context.Context) {
 	}
 }
 
+// connectAttemptWait returns how long to wait before the next connection
+// attempt: ConnectAttemptWait, plus or minus up to ReconnectJitter.
+func (c *Client) connectAttemptWait() time.Duration {
+	return jitter(c.cfg.ConnectAttemptWait, c.cfg.ReconnectJitter)
+}
+
+// jitter returns base shifted by a random duration in [-max, max], never
+// less than zero.
+func jitter(base, max time.Duration) time.Duration {
+	if max <= 0 {
+		return base
+	}
+
+	jitterRandMu.Lock()
+	offset := time.Duration(jitterRand.Int63n(int64(2*max)+1)) - max
+	jitterRandMu.Unlock()
+
+	d := base + offset
+	if d < 0 {
+		return 0
+	}
+
+	return d
+}
+
 // Stop stops listening for incoming webhook events.
 func (c *Client) Stop() {
 	close(c.done)
@@ -186,6 +260,17 @@ func (c *Client) SendMessage(msg *OutgoingMessage) {
 	c.send <- msg
 }
 
+// DialURL returns the URL the client dials when connecting, with the NoWSS
+// override and the websocket feature applied.
+func (c *Client) DialURL() string {
+	url := c.URL
+	if c.cfg.NoWSS && strings.HasPrefix(url, "wss") {
+		url = "ws" + strings.TrimPrefix(c.URL, "wss")
+	}
+
+	return url + "?websocket_feature=" + c.WebSocketAuthorizedFeature
+}
+
 func readWSConnectErrorMessage(resp *http.Response) string {
 	if resp == nil {
 		return ""
@@ -223,19 +308,8 @@ var ErrUnknownID error = errors.New(unknownIDMessage)
 // the success of the attempt.
 
 func (c *Client) connect(ctx context.Context) error {
-	header := http.Header{}
-	// Disable compression by requiring "identity"
-	header.Set("Accept-Encoding", "identity")
-	header.Set("User-Agent", useragent.GetEncodedUserAgent())
-	header.Set("X-Stripe-Client-User-Agent", useragent.GetEncodedStripeUserAgent())
-	header.Set("Websocket-Id", c.WebSocketID)
-
-	url := c.URL
-	if c.cfg.NoWSS && strings.HasPrefix(url, "wss") {
-		url = "ws" + strings.TrimPrefix(c.URL, "wss")
-	}
-
-	url = url + "?websocket_feature=" + c.WebSocketAuthorizedFeature
+	header := c.handshakeHeader()
+	url := c.DialURL()
 
 	c.cfg.Log.WithFields(log.Fields{
 		"prefix": "websocket.Client.connect",
@@ -259,7 +333,7 @@ func (c *Client) connect(ctx context.Context) error {
 	defer resp.Body.Close()
 
 	c.changeConnection(conn)
-	c.isConnected = true
+	atomic.StoreInt32(&c.isConnected, 1)
 
 	c.wg = &sync.WaitGroup{}
 	c.wg.Add(2)
@@ -275,6 +349,40 @@ func (c *Client) connect(ctx context.Context) error {
 	return err
 }
 
+// Probe makes a single attempt to establish the websocket connection, and
+// closes it straight away. Unlike Run, it doesn't retry, so the error tells
+// why the handshake failed, including the message sent by Stripe if any.
+func (c *Client) Probe(ctx context.Context) error {
+	conn, resp, err := c.cfg.Dialer.DialContext(ctx, c.DialURL(), c.handshakeHeader())
+	if err != nil {
+		if message := readWSConnectErrorMessage(resp); message != "" {
+			return fmt.Errorf("%v: %s", err, message)
+		}
+
+		return err
+	}
+
+	resp.Body.Close() // #nosec G104
+
+	return conn.Close()
+}
+
+// handshakeHeader returns the headers sent when dialing the websocket.
+func (c *Client) handshakeHeader() http.Header {
+	header := http.Header{}
+	for name, values := range c.cfg.Headers {
+		header[http.CanonicalHeaderKey(name)] = values
+	}
+
+	// Disable compression by requiring "identity"
+	header.Set("Accept-Encoding", "identity")
+	header.Set("User-Agent", useragent.GetEncodedUserAgent())
+	header.Set("X-Stripe-Client-User-Agent", useragent.GetEncodedStripeUserAgent())
+	header.Set("Websocket-Id", c.WebSocketID)
+
+	return header
+}
+
 // changeConnection takes a new connection and recreates the channels.
 func (c *Client) changeConnection(conn *ws.Conn) {
 	c.conn = conn
@@ -350,14 +458,18 @@ func (c *Client) readPump() {
 			"message": string(data),
 		}).Debug("Incoming message")
 
-		var msg IncomingMessage
-		if err = json.Unmarshal(data, &msg); err != nil {
+		msgs, err := decodeIncomingMessages(data)
+		if err != nil {
 			c.cfg.Log.Debug("Received malformed message: ", err)
 
-			continue
+			if malformed(err) && c.cfg.OnMalformedMessage != nil {
+				go c.cfg.OnMalformedMessage(err)
+			}
 		}
 
-		go c.cfg.EventHandler.ProcessEvent(msg)
+		for _, msg := range msgs {
+			go c.cfg.EventHandler.ProcessEvent(msg)
+		}
 	}
 }
 
@@ -514,6 +626,12 @@ var subprotocols = [...]string{"stripecli-devproxy-v1"}
 
 var nullEventHandler = EventHandlerFunc(func(IncomingMessage) {})
 
+// jitterRand is seeded per process so that clients on different machines
+// don't pick the same delays.
+var jitterRand = rand.New(rand.NewSource(time.Now().UnixNano())) // #nosec G404
+
+var jitterRandMu sync.Mutex
+
 //
 // Private functions
 //
@@ -174,3 +174,204 @@ func TestClientExpiredError(t *testing.T) {
 		require.FailNow(t, "Timed out waiting for response from test server")
 	}
 }
+
+func TestClientConcatenatedMessagesInOneFrame(t *testing.T) {
+	upgrader := ws.Upgrader{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+
+		defer c.Close()
+
+		frame := `{"type":"request_log_event","request_log_id":"resp_1","event_payload":"{}"}` +
+			`{"type":"request_log_event","request_log_id":"resp_2","event_payload":"{}"}`
+
+		err = c.WriteMessage(ws.TextMessage, []byte(frame))
+		require.NoError(t, err)
+	}))
+
+	defer ts.Close()
+
+	url := "ws" + strings.TrimPrefix(ts.URL, "http")
+
+	rcvIDs := make(chan string, 2)
+
+	client := NewClient(
+		url,
+		"websocket-random-id",
+		"request-log-payloads",
+		&Config{
+			EventHandler: EventHandlerFunc(func(msg IncomingMessage) {
+				rcvIDs <- msg.RequestLogEvent.RequestLogID
+			}),
+		},
+	)
+
+	go client.Run(context.Background())
+
+	defer client.Stop()
+
+	var ids []string
+
+	for len(ids) < 2 {
+		select {
+		case id := <-rcvIDs:
+			ids = append(ids, id)
+		case <-time.After(500 * time.Millisecond):
+			require.FailNow(t, "Timed out waiting for response from test server")
+		}
+	}
+
+	require.ElementsMatch(t, []string{"resp_1", "resp_2"}, ids)
+}
+
+func TestClientOnMalformedMessage(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+
+	upgrader := ws.Upgrader{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+
+		defer c.Close()
+
+		for _, frame := range []string{`{"type":"unknown_type"}`, `not json`} {
+			require.NoError(t, c.WriteMessage(ws.TextMessage, []byte(frame)))
+		}
+
+		// Keep the connection open so that the client doesn't reconnect
+		<-done
+	}))
+
+	defer ts.Close()
+
+	url := "ws" + strings.TrimPrefix(ts.URL, "http")
+
+	errs := make(chan error, 2)
+
+	client := NewClient(
+		url,
+		"websocket-random-id",
+		"request-log-payloads",
+		&Config{
+			EventHandler:       nullEventHandler,
+			OnMalformedMessage: func(err error) { errs <- err },
+		},
+	)
+
+	go client.Run(context.Background())
+
+	defer client.Stop()
+
+	// Only the invalid frame is reported
+	select {
+	case err := <-errs:
+		require.Contains(t, err.Error(), "invalid character")
+	case <-time.After(500 * time.Millisecond):
+		require.FailNow(t, "Timed out waiting for the malformed frame")
+	}
+
+	select {
+	case err := <-errs:
+		require.FailNow(t, "unexpected malformed frame", err.Error())
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestClientCustomHeaders(t *testing.T) {
+	headers := make(chan http.Header, 1)
+
+	upgrader := ws.Upgrader{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header
+
+		c, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+
+		defer c.Close()
+	}))
+
+	defer ts.Close()
+
+	url := "ws" + strings.TrimPrefix(ts.URL, "http")
+
+	client := NewClient(
+		url,
+		"websocket-random-id",
+		"request-logs",
+		&Config{
+			EventHandler: EventHandlerFunc(func(msg IncomingMessage) {}),
+			Headers: http.Header{
+				"Proxy-Authorization": []string{"Bearer secret"},
+				"x-route":             []string{"us-east-1"},
+			},
+		},
+	)
+
+	go client.Run(context.Background())
+
+	defer client.Stop()
+
+	select {
+	case header := <-headers:
+		require.Equal(t, "Bearer secret", header.Get("Proxy-Authorization"))
+		require.Equal(t, "us-east-1", header.Get("X-Route"))
+		require.Equal(t, "websocket-random-id", header.Get("Websocket-Id"))
+		require.Equal(t, "identity", header.Get("Accept-Encoding"))
+	case <-time.After(500 * time.Millisecond):
+		require.FailNow(t, "Timed out waiting for handshake request")
+	}
+}
+
+func TestValidateHeaders(t *testing.T) {
+	require.NoError(t, ValidateHeaders(nil))
+	require.NoError(t, ValidateHeaders(http.Header{"X-Route": []string{"us-east-1"}}))
+
+	err := ValidateHeaders(http.Header{"websocket-id": []string{"other-id"}})
+	require.EqualError(t, err, "header Websocket-Id is reserved for the websocket handshake and can't be overridden")
+
+	err = ValidateHeaders(http.Header{"Upgrade": []string{"h2c"}})
+	require.EqualError(t, err, "header Upgrade is reserved for the websocket handshake and can't be overridden")
+}
+
+func TestJitter(t *testing.T) {
+	base := 10 * time.Second
+	max := 2 * time.Second
+
+	var shorter, longer bool
+
+	for i := 0; i < 1000; i++ {
+		d := jitter(base, max)
+		require.True(t, d >= base-max && d <= base+max, "delay %v out of bounds", d)
+
+		shorter = shorter || d < base
+		longer = longer || d > base
+	}
+
+	// Delays are spread on both sides of the base
+	require.True(t, shorter)
+	require.True(t, longer)
+}
+
+func TestJitterDisabled(t *testing.T) {
+	require.Equal(t, 10*time.Second, jitter(10*time.Second, 0))
+}
+
+func TestJitterNeverNegative(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		require.True(t, jitter(time.Second, 5*time.Second) >= 0)
+	}
+}
+
+func TestClientConnectAttemptWait(t *testing.T) {
+	client := NewClient("wss://example.com", "websocket-id", "feature", &Config{
+		ConnectAttemptWait: 5 * time.Second,
+		ReconnectJitter:    time.Second,
+	})
+
+	for i := 0; i < 100; i++ {
+		d := client.connectAttemptWait()
+		require.True(t, d >= 4*time.Second && d <= 6*time.Second, "delay %v out of bounds", d)
+	}
+}
@@ -1,14 +1,39 @@
 package websocket
 
 import (
+	"bytes"
 	"encoding/json"
-	"fmt"
+	"errors"
+	"io"
 )
 
+// ErrTruncatedMessage is returned when a frame ends in the middle of a JSON
+// message.
+var ErrTruncatedMessage = errors.New("truncated message")
+
+// unexpectedTypeError is returned for well-formed messages of a type the
+// client doesn't know about.
+type unexpectedTypeError struct {
+	messageType string
+}
+
+func (e *unexpectedTypeError) Error() string {
+	return "Unexpected message type: " + e.messageType
+}
+
+// malformed reports whether err, as returned by decodeIncomingMessages,
+// means that the frame couldn't be decoded, rather than that it contained a
+// message of an unknown type.
+func malformed(err error) bool {
+	var unexpected *unexpectedTypeError
+	return err != nil && !errors.As(err, &unexpected)
+}
+
 // IncomingMessage represents any incoming message sent by Stripe.
 type IncomingMessage struct {
 	*WebhookEvent
 	*RequestLogEvent
+	*ThrottleEvent
 }
 
 // UnmarshalJSON deserializes incoming messages sent by Stripe into the
@@ -36,13 +61,56 @@ func (m *IncomingMessage) UnmarshalJSON(data []byte) error {
 		}
 
 		m.RequestLogEvent = &evt
+	case "throttle_event":
+		var evt ThrottleEvent
+		if err := json.Unmarshal(data, &evt); err != nil {
+			return err
+		}
+
+		m.ThrottleEvent = &evt
 	default:
-		return fmt.Errorf("Unexpected message type: %s", incomingMessageTypeOnly.Type)
+		return &unexpectedTypeError{messageType: incomingMessageTypeOnly.Type}
 	}
 
 	return nil
 }
 
+// decodeIncomingMessages decodes every JSON message in a frame, tolerating
+// frames that contain several concatenated messages. Messages that are well
+// formed but can't be mapped to an IncomingMessage are skipped. Any messages
+// decoded before a syntax error or truncated input are still returned along
+// with the error.
+func decodeIncomingMessages(data []byte) ([]IncomingMessage, error) {
+	var msgs []IncomingMessage
+
+	var skipErr error
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+
+	for {
+		var msg IncomingMessage
+
+		err := decoder.Decode(&msg)
+
+		switch {
+		case err == io.EOF:
+			return msgs, skipErr
+		case err == io.ErrUnexpectedEOF:
+			return msgs, ErrTruncatedMessage
+		case err != nil:
+			if _, ok := err.(*json.SyntaxError); ok {
+				return msgs, err
+			}
+
+			// The decoder has consumed the whole value, so carry on with the
+			// rest of the frame.
+			skipErr = err
+		default:
+			msgs = append(msgs, msg)
+		}
+	}
+}
+
 // MarshalJSON serializes outgoing messages sent to Stripe.
 func (m OutgoingMessage) MarshalJSON() ([]byte, error) {
 	if m.WebhookResponse != nil {
@@ -14,3 +14,54 @@ func TestUnmarshalUnknownIncomingMsg(t *testing.T) {
 	err := json.Unmarshal([]byte(data), &msg)
 	require.EqualError(t, err, "Unexpected message type: unknown_type")
 }
+
+func TestDecodeIncomingMessagesConcatenated(t *testing.T) {
+	var data = `{"type": "request_log_event", "request_log_id": "resp_1"}{"type": "request_log_event", "request_log_id": "resp_2"}
+{"type": "webhook_event", "webhook_id": "wh_3"}`
+
+	msgs, err := decodeIncomingMessages([]byte(data))
+	require.NoError(t, err)
+	require.Len(t, msgs, 3)
+	require.Equal(t, "resp_1", msgs[0].RequestLogEvent.RequestLogID)
+	require.Equal(t, "resp_2", msgs[1].RequestLogEvent.RequestLogID)
+	require.Equal(t, "wh_3", msgs[2].WebhookEvent.WebhookID)
+}
+
+func TestDecodeIncomingMessagesTruncated(t *testing.T) {
+	var data = `{"type": "request_log_event", "request_log_id": "resp_1"}{"type": "request_log_ev`
+
+	msgs, err := decodeIncomingMessages([]byte(data))
+	require.Equal(t, ErrTruncatedMessage, err)
+	require.Len(t, msgs, 1)
+	require.Equal(t, "resp_1", msgs[0].RequestLogEvent.RequestLogID)
+}
+
+func TestDecodeIncomingMessagesSkipsUnknownTypes(t *testing.T) {
+	var data = `{"type": "unknown_type"}{"type": "request_log_event", "request_log_id": "resp_2"}`
+
+	msgs, err := decodeIncomingMessages([]byte(data))
+	require.EqualError(t, err, "Unexpected message type: unknown_type")
+	require.Len(t, msgs, 1)
+	require.Equal(t, "resp_2", msgs[0].RequestLogEvent.RequestLogID)
+}
+
+func TestDecodeIncomingMessagesSyntaxError(t *testing.T) {
+	var data = `{"type": "request_log_event", "request_log_id": "resp_1"} not json`
+
+	msgs, err := decodeIncomingMessages([]byte(data))
+	require.Error(t, err)
+	require.Len(t, msgs, 1)
+}
+
+func TestMalformed(t *testing.T) {
+	_, err := decodeIncomingMessages([]byte(`{"type": "unknown_type"}`))
+	require.False(t, malformed(err))
+
+	_, err = decodeIncomingMessages([]byte(`not json`))
+	require.True(t, malformed(err))
+
+	_, err = decodeIncomingMessages([]byte(`{"type": "request_log_ev`))
+	require.True(t, malformed(err))
+
+	require.False(t, malformed(nil))
+}
@@ -0,0 +1,12 @@
+package websocket
+
+// ThrottleEvent represents incoming throttle notices sent by Stripe when the
+// client should slow down, e.g. because the session is being rate limited.
+type ThrottleEvent struct {
+	Message string `json:"message"`
+
+	// RetryAfter is the number of seconds Stripe asks the client to wait
+	// before reconnecting or retrying. It's zero when unspecified.
+	RetryAfter int    `json:"retry_after"`
+	Type       string `json:"type"`
+}
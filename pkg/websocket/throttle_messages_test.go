@@ -0,0 +1,24 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshalThrottleEvent(t *testing.T) {
+	var data = `{"type": "throttle_event", "message": "Too many request logs", "retry_after": 30}`
+
+	var msg IncomingMessage
+	err := json.Unmarshal([]byte(data), &msg)
+	require.NoError(t, err)
+
+	require.NotNil(t, msg.ThrottleEvent)
+	require.Nil(t, msg.RequestLogEvent)
+	require.Nil(t, msg.WebhookEvent)
+
+	require.Equal(t, "Too many request logs", msg.ThrottleEvent.Message)
+	require.Equal(t, 30, msg.ThrottleEvent.RetryAfter)
+	require.Equal(t, "throttle_event", msg.ThrottleEvent.Type)
+}